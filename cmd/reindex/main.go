@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/app"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// reindex builds a new version of the search index from the shops service
+// and swaps the SEARCH_INDEX alias over to it.
+func main() {
+	var opts entity.ReindexOptions
+	var pageSize int
+	flag.IntVar(&pageSize, "page-size", 100, "shops read from the shops service at a time")
+	flag.IntVar(&opts.Concurrency, "concurrency", 4, "shops indexed at once")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "read and count the catalog without writing anything")
+	flag.BoolVar(&opts.KeepPrevious, "keep-previous", false, "keep the replaced index versions instead of deleting them")
+	checkpoint := flag.String("checkpoint", "reindex.checkpoint.json", "file the progress is saved to and resumed from")
+	flag.Parse()
+	opts.PageSize = int32(pageSize)
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		log.Fatal("can't load config file:", err)
+	}
+
+	err = app.Reindex(config, opts, *checkpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,19 +1,128 @@
 package config
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	HttpPort             string        `mapstructure:"HTTP_PORT"`
-	UsersServiceAddress  string        `mapstructure:"USERS_SERVICE_ADDRESS"`
-	ShopsServiceAddress  string        `mapstructure:"SHOPS_SERVICE_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	HttpPort            string `mapstructure:"HTTP_PORT"`
+	UsersServiceAddress string `mapstructure:"USERS_SERVICE_ADDRESS"`
+	ShopsServiceAddress string `mapstructure:"SHOPS_SERVICE_ADDRESS"`
+	// OrdersServiceAddress is optional; orders are kept in memory without it.
+	OrdersServiceAddress string        `mapstructure:"ORDERS_SERVICE_ADDRESS"`
+	UsersServiceTimeout  time.Duration `mapstructure:"USERS_SERVICE_TIMEOUT"`
+	ShopsServiceTimeout  time.Duration `mapstructure:"SHOPS_SERVICE_TIMEOUT"`
+	OrdersServiceTimeout time.Duration `mapstructure:"ORDERS_SERVICE_TIMEOUT"`
+	// DownstreamRetries is the total number of attempts for idempotent calls.
+	DownstreamRetries        int           `mapstructure:"DOWNSTREAM_RETRIES"`
+	DownstreamRetryBaseDelay time.Duration `mapstructure:"DOWNSTREAM_RETRY_BASE_DELAY"`
+	DownstreamRetryMaxDelay  time.Duration `mapstructure:"DOWNSTREAM_RETRY_MAX_DELAY"`
+	BreakerFailureThreshold  int           `mapstructure:"BREAKER_FAILURE_THRESHOLD"`
+	BreakerOpenTimeout       time.Duration `mapstructure:"BREAKER_OPEN_TIMEOUT"`
+	// TokenMaker is jwt, paseto_local or paseto_public. jwt signs with
+	// TokenSymmetricKey unless TokenKeyFiles are set; paseto_local encrypts
+	// with TokenSymmetricKey and paseto_public signs with Ed25519 key files.
+	TokenMaker        string `mapstructure:"TOKEN_MAKER"`
+	TokenSymmetricKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	// TokenKeyFiles is a comma separated list of PEM key files named after
+	// their kid; TokenSigningKeyID picks the one that signs new tokens.
+	TokenKeyFiles        string        `mapstructure:"TOKEN_KEY_FILES"`
+	TokenSigningKeyID    string        `mapstructure:"TOKEN_SIGNING_KEY_ID"`
 	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	LogLevel             string        `mapstructure:"LOG_LEVEL"`
+	// AdminInviteDuration is how long an admin invite can be redeemed.
+	AdminInviteDuration time.Duration `mapstructure:"ADMIN_INVITE_DURATION"`
+	// PasswordResetDuration and EmailVerificationDuration are how long the
+	// tokens mailed to users stay valid.
+	PasswordResetDuration     time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	EmailVerificationDuration time.Duration `mapstructure:"EMAIL_VERIFICATION_DURATION"`
+	// MFAIssuer names the gateway in authenticator apps. MFAChallengeDuration
+	// is how long a password login waits for the second factor.
+	MFAIssuer            string        `mapstructure:"MFA_ISSUER"`
+	MFAChallengeDuration time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	// OIDCProviders names the OpenID Connect providers users can log in with,
+	// separated by commas. A provider NAME is set up with OIDC_NAME_ISSUER,
+	// OIDC_NAME_CLIENT_ID, OIDC_NAME_CLIENT_SECRET, OIDC_NAME_REDIRECT_URL
+	// and optionally OIDC_NAME_SCOPES, separated by spaces.
+	OIDCProviders string `mapstructure:"OIDC_PROVIDERS"`
+	// OIDCStateDuration is how long a user may take at the provider.
+	OIDCStateDuration time.Duration `mapstructure:"OIDC_STATE_DURATION"`
+	OIDCTimeout       time.Duration `mapstructure:"OIDC_TIMEOUT"`
+	LogLevel          string        `mapstructure:"LOG_LEVEL"`
+	ShutdownTimeout   time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	RabbitMQURL       string        `mapstructure:"RABBITMQ_URL"`
+	RabbitMQExchange  string        `mapstructure:"RABBITMQ_EXCHANGE"`
+	// RedisAddress is optional; state such as carts is kept in memory without it.
+	RedisAddress   string        `mapstructure:"REDIS_ADDRESS"`
+	CartTTL        time.Duration `mapstructure:"CART_TTL"`
+	RequestTimeout time.Duration `mapstructure:"REQUEST_TIMEOUT"`
+	// RouteTimeouts overrides RequestTimeout for single routes, written as
+	// "METHOD /path=duration" pairs separated by commas, for example
+	// "POST /login=3s,GET /shops/=2s".
+	RouteTimeouts string `mapstructure:"ROUTE_TIMEOUTS"`
+	// TrackingBufferSize is how many events of every order are kept for
+	// clients resuming a stream with Last-Event-ID.
+	TrackingBufferSize int `mapstructure:"TRACKING_BUFFER_SIZE"`
+	// RateLimits are token buckets of route groups, written as
+	// "group:key=requests/period" pairs separated by commas, where key is ip,
+	// email or user, for example "login:ip=20/1m,login:email=5/1m".
+	RateLimits string `mapstructure:"RATE_LIMITS"`
+	// TrustedProxies lists the proxies whose X-Forwarded-For is believed when
+	// finding the client IP. Without them the peer address is used.
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+	// APIKeyRateLimit is the requests a minute of API keys created without
+	// one; no key may make more than APIKeyMaxRateLimit.
+	APIKeyRateLimit    int `mapstructure:"API_KEY_RATE_LIMIT"`
+	APIKeyMaxRateLimit int `mapstructure:"API_KEY_MAX_RATE_LIMIT"`
+	// After LoginLockoutThreshold failed logins within LoginFailureWindow an
+	// account is locked for LoginLockoutBase, doubled on every further
+	// failure up to LoginLockoutMax.
+	LoginLockoutThreshold int           `mapstructure:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginFailureWindow    time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginLockoutBase      time.Duration `mapstructure:"LOGIN_LOCKOUT_BASE"`
+	LoginLockoutMax       time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX"`
+	// DispatchInterval is how often ready orders are offered to couriers.
+	// A courier has DeliveryOfferTimeout to answer before the order moves on,
+	// and couriers silent for longer than CourierLocationTTL get no offers.
+	DispatchInterval     time.Duration `mapstructure:"DISPATCH_INTERVAL"`
+	DeliveryOfferTimeout time.Duration `mapstructure:"DELIVERY_OFFER_TIMEOUT"`
+	CourierLocationTTL   time.Duration `mapstructure:"COURIER_LOCATION_TTL"`
+	// DispatchLockTTL bounds how long an instance that died holding the
+	// dispatch lock in Redis keeps the others from dispatching.
+	DispatchLockTTL time.Duration `mapstructure:"DISPATCH_LOCK_TTL"`
+	// DeliveryRadiusKm is how far shops without a delivery zone deliver, and
+	// how far /shops/nearby looks by default.
+	DeliveryRadiusKm float64 `mapstructure:"DELIVERY_RADIUS_KM"`
+	// ElasticsearchURL is optional; the search index is kept in memory
+	// without it. Several nodes are separated by commas.
+	ElasticsearchURL string        `mapstructure:"ELASTICSEARCH_URL"`
+	SearchIndex      string        `mapstructure:"SEARCH_INDEX"`
+	SearchTimeout    time.Duration `mapstructure:"SEARCH_TIMEOUT"`
+
+	routeTimeouts map[string]time.Duration
+	rateLimits    map[string]RateLimit
+	oidcProviders []OIDCProvider
+}
+
+// OIDCProvider is an OpenID Connect provider users can log in with.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// RateLimit allows Requests per Period, all of them at once at most.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
 }
 
 func LoadConfig(path string) (config *Config, err error) {
@@ -29,5 +138,188 @@ func LoadConfig(path string) (config *Config, err error) {
 	}
 
 	err = viper.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	err = config.checkPositive()
+	if err != nil {
+		return
+	}
+
+	config.routeTimeouts, err = parseRouteTimeouts(config.RouteTimeouts)
+	if err != nil {
+		return
+	}
+
+	config.rateLimits, err = parseRateLimits(config.RateLimits)
+	if err != nil {
+		return
+	}
+
+	config.oidcProviders, err = loadOIDCProviders(config.OIDCProviders)
 	return
 }
+
+// RouteTimeout returns the deadline for the route registered as path with the
+// given method, falling back to RequestTimeout.
+func (config *Config) RouteTimeout(method string, path string) time.Duration {
+	if timeout, ok := config.routeTimeouts[method+" "+path]; ok {
+		return timeout
+	}
+	return config.RequestTimeout
+}
+
+// RateLimit returns the limit of the route group per key, ip, email or user,
+// and whether there is one.
+func (config *Config) RateLimit(group string, key string) (RateLimit, bool) {
+	limit, ok := config.rateLimits[group+":"+key]
+	return limit, ok
+}
+
+func (config *Config) OIDCProviderList() []OIDCProvider {
+	return config.oidcProviders
+}
+
+// TrustedProxyList splits TrustedProxies.
+func (config *Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(config.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// ElasticsearchAddresses splits ElasticsearchURL.
+func (config *Config) ElasticsearchAddresses() []string {
+	var addresses []string
+	for _, address := range strings.Split(config.ElasticsearchURL, ",") {
+		address = strings.TrimSpace(address)
+		if address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// checkPositive refuses settings that can't be zero, like intervals and rate
+// limits, at startup instead of leaving the gateway to misbehave with them.
+func (config *Config) checkPositive() error {
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"DISPATCH_INTERVAL", config.DispatchInterval},
+		{"DELIVERY_OFFER_TIMEOUT", config.DeliveryOfferTimeout},
+	}
+	for _, setting := range durations {
+		if setting.value <= 0 {
+			return fmt.Errorf("%s must be a positive duration, got %v", setting.name, setting.value)
+		}
+	}
+
+	numbers := []struct {
+		name  string
+		value int
+	}{
+		{"API_KEY_RATE_LIMIT", config.APIKeyRateLimit},
+		{"API_KEY_MAX_RATE_LIMIT", config.APIKeyMaxRateLimit},
+	}
+	for _, setting := range numbers {
+		if setting.value <= 0 {
+			return fmt.Errorf("%s must be a positive number, got %d", setting.name, setting.value)
+		}
+	}
+	if config.APIKeyRateLimit > config.APIKeyMaxRateLimit {
+		return fmt.Errorf("API_KEY_RATE_LIMIT can't be above API_KEY_MAX_RATE_LIMIT, got %d and %d", config.APIKeyRateLimit, config.APIKeyMaxRateLimit)
+	}
+	return nil
+}
+
+func parseRouteTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, duration, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid route timeout %q", entry)
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q: %w", entry, err)
+		}
+
+		timeouts[strings.Join(strings.Fields(route), " ")] = timeout
+	}
+	return timeouts, nil
+}
+
+func parseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rate, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid rate limit %q", entry)
+		}
+		requests, period, found := strings.Cut(rate, "/")
+		if !found {
+			return nil, fmt.Errorf("invalid rate limit %q", entry)
+		}
+
+		var limit RateLimit
+		var err error
+		limit.Requests, err = strconv.Atoi(strings.TrimSpace(requests))
+		if err != nil || limit.Requests <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: requests must be a positive number", entry)
+		}
+		limit.Period, err = time.ParseDuration(strings.TrimSpace(period))
+		if err != nil || limit.Period <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: period must be a positive duration", entry)
+		}
+
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits, nil
+}
+
+func loadOIDCProviders(names string) ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			Issuer:       viper.GetString(prefix + "ISSUER"),
+			ClientID:     viper.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: viper.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  viper.GetString(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(viper.GetString(prefix + "SCOPES")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("oidc provider %s needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"email", "profile"}
+		}
+
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
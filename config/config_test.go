@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPositive(t *testing.T) {
+	valid := Config{
+		DispatchInterval:     2 * time.Second,
+		DeliveryOfferTimeout: 30 * time.Second,
+		APIKeyRateLimit:      60,
+		APIKeyMaxRateLimit:   600,
+	}
+	err := valid.checkPositive()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		change  func(config *Config)
+		wantErr string
+	}{
+		{"dispatch interval unset", func(config *Config) { config.DispatchInterval = 0 }, "DISPATCH_INTERVAL"},
+		{"negative dispatch interval", func(config *Config) { config.DispatchInterval = -time.Second }, "DISPATCH_INTERVAL"},
+		{"offer timeout unset", func(config *Config) { config.DeliveryOfferTimeout = 0 }, "DELIVERY_OFFER_TIMEOUT"},
+		{"api key rate limit unset", func(config *Config) { config.APIKeyRateLimit = 0 }, "API_KEY_RATE_LIMIT"},
+		{"api key max rate limit unset", func(config *Config) { config.APIKeyMaxRateLimit = 0 }, "API_KEY_MAX_RATE_LIMIT"},
+		{"api key rate limit above the max", func(config *Config) { config.APIKeyRateLimit = 601 }, "API_KEY_RATE_LIMIT can't be above"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := valid
+			tc.change(&config)
+			err := config.checkPositive()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("checkPositive returned %v, want an error about %s", err, tc.wantErr)
+			}
+		})
+	}
+}
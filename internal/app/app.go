@@ -1,82 +1,324 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	// "log"
+	"net/http"
 	"os"
-	// "os/signal"
-	// "syscall"
+	"strings"
+	"time"
 
 	"github.com/elastic/go-elasticsearch"
 	"github.com/gin-gonic/gin"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
 	"github.com/zura-t/go_delivery_system/config"
 	v1 "github.com/zura-t/go_delivery_system/internal/controller/http/v1"
 	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
 	"github.com/zura-t/go_delivery_system/internal/usecase/webapi"
-	// "github.com/zura-t/go_delivery_system/pkg/httpserver"
+	"github.com/zura-t/go_delivery_system/pkg/httpserver"
+	"github.com/zura-t/go_delivery_system/pkg/hub"
 	"github.com/zura-t/go_delivery_system/pkg/logger"
+	"github.com/zura-t/go_delivery_system/pkg/oidc"
+	"github.com/zura-t/go_delivery_system/pkg/ratelimit"
+	"github.com/zura-t/go_delivery_system/pkg/rmq"
+	"github.com/zura-t/go_delivery_system/token"
 )
 
 func Run(cfg *config.Config) {
 	l := logger.New(cfg.LogLevel)
-	esClient, err := elasticsearch.NewDefaultClient()
+	lc := newLifecycle(l, cfg.ShutdownTimeout)
+
+	usersClient := newDownstreamClient(cfg, "users", cfg.UsersServiceAddress, cfg.UsersServiceTimeout)
+	shopsClient := newDownstreamClient(cfg, "shops", cfg.ShopsServiceAddress, cfg.ShopsServiceTimeout)
+	userwebapi := webapi.NewUserWebAPI(cfg, usersClient)
+	shopwebapi := webapi.NewShopWebAPI(cfg, shopsClient)
+
+	downstreams := []v1.Downstream{usersClient, shopsClient}
+
+	var orderwebapi usecase.OrderWebAPI
+	if cfg.OrdersServiceAddress != "" {
+		ordersClient := newDownstreamClient(cfg, "orders", cfg.OrdersServiceAddress, cfg.OrdersServiceTimeout)
+		orderwebapi = webapi.NewOrderWebAPI(cfg, ordersClient)
+		downstreams = append(downstreams, ordersClient)
+	} else {
+		l.Info("app - Run - orders service address is not set, keeping orders in memory")
+		orderwebapi = webapi.NewOrderMemoryAPI()
+	}
+
+	var cartStore usecase.CartStore
+	var revocationStore usecase.RevocationStore
+	var adminStore usecase.AdminStore
+	var loginAttemptStore usecase.LoginAttemptStore
+	var mfaStore usecase.MFAStore
+	var oidcStore usecase.OIDCStore
+	var apiKeyStore usecase.APIKeyStore
+	var sessionStore usecase.SessionStore
+	var courierStore usecase.CourierStore
+	var limiter ratelimit.Limiter
+	if cfg.RedisAddress != "" {
+		rdb := redis.NewClient(&redis.Options{Addr: cfg.RedisAddress})
+		lc.addConnection("redis", rdb.Close)
+		cartStore = repo.NewCartRedisStore(rdb, cfg.CartTTL)
+		revocationStore = repo.NewRevocationRedisStore(rdb)
+		adminStore = repo.NewAdminRedisStore(rdb)
+		loginAttemptStore = repo.NewLoginAttemptRedisStore(rdb)
+		mfaStore = repo.NewMFARedisStore(rdb)
+		oidcStore = repo.NewOIDCRedisStore(rdb)
+		apiKeyStore = repo.NewAPIKeyRedisStore(rdb)
+		sessionStore = repo.NewSessionRedisStore(rdb)
+		courierStore = repo.NewCourierRedisStore(rdb, cfg.DispatchLockTTL)
+		limiter = ratelimit.NewRedisLimiter(rdb)
+	} else {
+		cartStore = repo.NewCartMemoryStore()
+		revocationStore = repo.NewRevocationMemoryStore()
+		adminStore = repo.NewAdminMemoryStore()
+		loginAttemptStore = repo.NewLoginAttemptMemoryStore()
+		mfaStore = repo.NewMFAMemoryStore()
+		oidcStore = repo.NewOIDCMemoryStore()
+		apiKeyStore = repo.NewAPIKeyMemoryStore()
+		sessionStore = repo.NewSessionMemoryStore()
+		courierStore = repo.NewCourierMemoryStore()
+		limiter = ratelimit.NewMemoryLimiter()
+	}
+
+	searchIndex, err := newSearchIndex(cfg, l)
 	if err != nil {
-		l.Fatal("Connection failed")
+		l.Fatal(fmt.Errorf("app - Run - newSearchIndex: %w", err))
 		os.Exit(1)
 	}
-	_ = esClient
-	userwebapi := webapi.NewUserWebAPI(cfg)
-	shopwebapi := webapi.NewShopWebAPI(cfg)
 
-	usersUseCase := usecase.NewUserUseCase(cfg, userwebapi)
-	shopsUseCase := usecase.NewShopUseCase(cfg, shopwebapi)
+	tokenMaker, err := newTokenMaker(cfg)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - newTokenMaker: %w", err))
+		os.Exit(1)
+	}
+
+	var rmqConn *amqp.Connection
+	publisher := &eventPublisher{l: l}
+	if cfg.RabbitMQURL != "" {
+		rmqConn, err = amqp.Dial(cfg.RabbitMQURL)
+		if err != nil {
+			l.Fatal(fmt.Errorf("app - Run - amqp.Dial: %w", err))
+			os.Exit(1)
+		}
+		lc.addConnection("rmqConnection", rmqConn.Close)
+
+		publisher.emitter, err = rmq.NewEmitter(rmqConn, cfg.RabbitMQExchange)
+		if err != nil {
+			l.Fatal(fmt.Errorf("app - Run - rmq.NewEmitter: %w", err))
+			os.Exit(1)
+		}
+	} else {
+		l.Info("app - Run - rabbitmq url is not set, events are not published")
+	}
 
-	// rmqRouter := amqprpc.NewRouter(translationUseCase)
+	sessionUseCase := usecase.NewSessionUseCase(cfg, tokenMaker, revocationStore, sessionStore, userwebapi, shopwebapi)
+	accountUseCase := usecase.NewAccountUseCase(cfg, userwebapi, sessionUseCase, tokenMaker, revocationStore, loginAttemptStore, publisher)
+	mfaUseCase := usecase.NewMFAUseCase(cfg, userwebapi, mfaStore)
+	usersUseCase := usecase.NewUserUseCase(cfg, userwebapi, sessionUseCase, accountUseCase, mfaUseCase, loginAttemptStore, tokenMaker, revocationStore)
+	oidcUseCase := usecase.NewOIDCUseCase(cfg, newIdentityProviders(cfg), userwebapi, usersUseCase, oidcStore)
+	adminUseCase := usecase.NewAdminUseCase(cfg, userwebapi, sessionUseCase, tokenMaker, revocationStore, adminStore)
+	shopsUseCase := usecase.NewShopUseCase(cfg, shopwebapi, publisher)
+	searchUseCase := usecase.NewSearchUseCase(cfg, searchIndex, shopwebapi)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(cfg, apiKeyStore)
+	ordersUseCase := usecase.NewOrderUseCase(cfg, orderwebapi, shopwebapi, publisher)
+	cartUseCase := usecase.NewCartUseCase(cfg, cartStore, shopsUseCase, ordersUseCase, l)
 
-	// rmqServer, err := server.New(cfg.RMQ.URL, cfg.RMQ.ServerExchange, rmqRouter, l)
-	// if err != nil {
-	// 	l.Fatal(fmt.Errorf("app - Run - rmqServer - server.New: %w", err))
-	// }
+	deliveryUseCase := usecase.NewDeliveryUseCase(cfg, courierStore, ordersUseCase, shopsUseCase, publisher)
+
+	trackingHub := hub.New(cfg.TrackingBufferSize)
+	trackingUseCase := usecase.NewTrackingUseCase(trackingHub, ordersUseCase)
+
+	consumers := []eventConsumer{
+		{"tracking", usecase.TrackingTopics, trackingUseCase.Dispatch},
+		{"delivery", usecase.DeliveryTopics, deliveryUseCase.Dispatch},
+		{"search", usecase.SearchTopics, searchUseCase.Dispatch},
+	}
+	if rmqConn == nil {
+		publisher.local = dispatchLocal(l, consumers)
+	}
 
-	runGinServer(l, cfg, usersUseCase, shopsUseCase)
+	runGinServer(lc, l, cfg, tokenMaker, limiter, sessionUseCase, apiKeyUseCase, usersUseCase, accountUseCase, mfaUseCase, oidcUseCase, adminUseCase, shopsUseCase, ordersUseCase, trackingUseCase, cartUseCase, deliveryUseCase, searchUseCase, downstreams, trackingHub.Close)
+
+	// The dispatcher drains before the consumer so that the events of its
+	// last offers are still handled.
+	runDispatcher(lc, l, cfg, deliveryUseCase)
+
+	if rmqConn != nil {
+		runConsumer(lc, l, cfg, rmqConn, consumers)
+	}
+
+	lc.wait()
+	lc.shutdown()
 }
 
-func runGinServer(l *logger.Logger, cfg *config.Config, usersUseCase *usecase.UserUseCase, shopsUseCase *usecase.ShopUseCase) {
+func runGinServer(lc *lifecycle, l *logger.Logger, cfg *config.Config, tokenMaker token.Maker, limiter ratelimit.Limiter, sessionUseCase *usecase.SessionUseCase, apiKeyUseCase *usecase.APIKeyUseCase, usersUseCase *usecase.UserUseCase, accountUseCase *usecase.AccountUseCase, mfaUseCase *usecase.MFAUseCase, oidcUseCase *usecase.OIDCUseCase, adminUseCase *usecase.AdminUseCase, shopsUseCase *usecase.ShopUseCase, ordersUseCase *usecase.OrderUseCase, trackingUseCase *usecase.TrackingUseCase, cartUseCase *usecase.CartUseCase, deliveryUseCase *usecase.DeliveryUseCase, searchUseCase *usecase.SearchUseCase, downstreams []v1.Downstream, onShutdown func()) {
 	handler := gin.New()
-	server, err := v1.New(cfg, l, usersUseCase)
+	err := handler.SetTrustedProxies(cfg.TrustedProxyList())
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - SetTrustedProxies: %w", err))
+		os.Exit(1)
+	}
+
+	server, err := v1.New(cfg, l, tokenMaker, usersUseCase, sessionUseCase, apiKeyUseCase, limiter)
 	if err != nil {
 		l.Fatal(fmt.Errorf("app - Run - runGinServer: %w", err))
 		os.Exit(1)
 	}
-	server.NewRouter(handler, l, usersUseCase, shopsUseCase)
-	handler.Run()
-	// httpServer := httpserver.New(handler, httpserver.Port(cfg.HttpPort))
-	// log.Print(fmt.Sprintf("server started on port %s", cfg.HttpPort))
-
-	// Waiting signal
-	// interrupt := make(chan os.Signal, 1)
-	// signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
-	// select {
-	// case s := <-interrupt:
-	// 	log.Print(s.String())
-	// 	l.Info("app - Run - signal: " + s.String())
-	// case err := <-httpServer.Notify():
-	// 	log.Fatal(err)
-	// 	l.Error(fmt.Errorf("app - Run - httpServer.Notify: %w", err))
-	// 	// case err := <-rmqServer.Notify():
-	// 	// 	l.Error(fmt.Errorf("app - Run - rmqServer.Notify: %w", err))
-	// }
-
-	// // Shutdown
-	// err = httpServer.Shutdown()
-	// if err != nil {
-	// 	log.Fatal(err)
-	// 	l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
-	// }
-
-	// err = rmqServer.Shutdown()
-	// if err != nil {
-	// 	l.Error(fmt.Errorf("app - Run - rmqServer.Shutdown: %w", err))
-	// }
+	server.NewRouter(handler, l, usersUseCase, accountUseCase, mfaUseCase, oidcUseCase, adminUseCase, shopsUseCase, ordersUseCase, trackingUseCase, cartUseCase, deliveryUseCase, searchUseCase, downstreams)
+
+	httpServer := httpserver.New(handler,
+		httpserver.Port(cfg.HttpPort),
+		httpserver.ShutdownTimeout(lc.timeout),
+		httpserver.OnShutdown(onShutdown),
+	)
+	l.Info(fmt.Sprintf("server started on port %s", cfg.HttpPort))
+
+	lc.add("httpServer", httpServer.Notify(), httpServer.ShutdownContext)
+}
+
+// newTokenMaker builds the token.Maker chosen by TOKEN_MAKER.
+func newTokenMaker(cfg *config.Config) (token.Maker, error) {
+	switch cfg.TokenMaker {
+	case "", "jwt":
+		if cfg.TokenKeyFiles == "" {
+			return token.NewJwtMaker(cfg.TokenSymmetricKey)
+		}
+		keys, err := loadTokenKeys(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewAsymmetricMaker(keys, cfg.TokenSigningKeyID)
+	case "paseto_local":
+		return token.NewPasetoLocalMaker(cfg.TokenSymmetricKey)
+	case "paseto_public":
+		keys, err := loadTokenKeys(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewPasetoPublicMaker(keys, cfg.TokenSigningKeyID)
+	}
+	return nil, fmt.Errorf("unknown token maker %q", cfg.TokenMaker)
+}
+
+// newSearchIndex connects to Elasticsearch and creates the index when
+// ElasticsearchURL is set. An unreachable cluster is logged, not fatal, so
+// the gateway still starts and search reports itself unavailable.
+func newSearchIndex(cfg *config.Config, l *logger.Logger) (usecase.SearchIndex, error) {
+	if cfg.ElasticsearchURL == "" {
+		l.Info("app - Run - elasticsearch url is not set, keeping the search index in memory")
+		return repo.NewSearchMemoryIndex(), nil
+	}
+
+	client, err := newElasticsearchClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	index := repo.NewSearchElasticIndex(client, cfg.SearchIndex)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.SearchTimeout)
+	defer cancel()
+	err = index.EnsureIndex(ctx)
+	if err != nil {
+		l.Error(fmt.Errorf("app - Run - EnsureIndex: %w", err))
+	}
+	return index, nil
+}
+
+func newElasticsearchClient(cfg *config.Config) (*elasticsearch.Client, error) {
+	var esConfig elasticsearch.Config
+	// The client reads ELASTICSEARCH_URL itself and refuses to be given
+	// addresses as well.
+	if os.Getenv("ELASTICSEARCH_URL") == "" {
+		esConfig.Addresses = cfg.ElasticsearchAddresses()
+	}
+	return elasticsearch.NewClient(esConfig)
+}
+
+func newIdentityProviders(cfg *config.Config) map[string]usecase.IdentityProvider {
+	client := &http.Client{Timeout: cfg.OIDCTimeout}
+	providers := make(map[string]usecase.IdentityProvider)
+	for _, provider := range cfg.OIDCProviderList() {
+		providers[provider.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		}, client)
+	}
+	return providers
+}
+
+func loadTokenKeys(cfg *config.Config) ([]token.Key, error) {
+	var paths []string
+	for _, path := range strings.Split(cfg.TokenKeyFiles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("TOKEN_KEY_FILES is empty")
+	}
+	return token.LoadKeyFiles(paths)
+}
+
+func newDownstreamClient(cfg *config.Config, name string, address string, timeout time.Duration) *httpclient.HttpClient {
+	return httpclient.New(
+		httpclient.Name(name),
+		httpclient.Address(address),
+		httpclient.Timeout(timeout),
+		httpclient.Retries(cfg.DownstreamRetries),
+		httpclient.Backoff(cfg.DownstreamRetryBaseDelay, cfg.DownstreamRetryMaxDelay),
+		httpclient.BreakerThreshold(cfg.BreakerFailureThreshold),
+		httpclient.BreakerOpenTimeout(cfg.BreakerOpenTimeout),
+	)
+}
+
+func runConsumer(lc *lifecycle, l *logger.Logger, cfg *config.Config, conn *amqp.Connection, consumers []eventConsumer) {
+	consumer, err := rmq.NewConsumer(conn, cfg.RabbitMQExchange)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - rmq.NewConsumer: %w", err))
+		os.Exit(1)
+	}
+
+	var topics []string
+	for _, c := range consumers {
+		topics = append(topics, c.topics...)
+	}
+
+	err = consumer.Listen(topics, func(d amqp.Delivery) {
+		l.Debug(fmt.Sprintf("rmq - received a message: %s", d.Body))
+		dispatch(l, consumers, d.RoutingKey, d.Body)
+	})
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - consumer.Listen: %w", err))
+		os.Exit(1)
+	}
+
+	lc.add("rmqConsumer", consumer.Notify(), consumer.Shutdown)
+}
+
+// runDispatcher offers ready orders to couriers until shutdown.
+func runDispatcher(lc *lifecycle, l *logger.Logger, cfg *config.Config, deliveryUseCase *usecase.DeliveryUseCase) {
+	lc.addWorker("dispatcher", func(ctx context.Context) error {
+		ticker := time.NewTicker(cfg.DispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				err := deliveryUseCase.Assign(ctx)
+				if err != nil {
+					l.Error(fmt.Errorf("app - Run - dispatcher.Assign: %w", err))
+				}
+			}
+		}
+	})
 }
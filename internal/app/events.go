@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+	"github.com/zura-t/go_delivery_system/pkg/rmq"
+)
+
+// eventPublisher sends usecase events through rabbitmq when it is configured
+// and logs failed deliveries, which usecases don't report to clients.
+// Without rabbitmq the events are handed to local instead, so in-process
+// consumers such as order tracking keep working; events no local consumer
+// takes fail with usecase.ErrEventUndeliverable. Event bodies are never
+// logged, as some carry secrets like mailed tokens.
+type eventPublisher struct {
+	emitter *rmq.Emitter
+	local   func(routingKey string, body []byte) error
+	l       logger.Interface
+}
+
+func (p *eventPublisher) Publish(ctx context.Context, routingKey string, event any) error {
+	if p.emitter == nil {
+		p.l.Debug(fmt.Sprintf("events - %s", routingKey))
+		if p.local == nil {
+			return usecase.ErrEventUndeliverable
+		}
+
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		err = p.local(routingKey, body)
+		if err != nil && !errors.Is(err, usecase.ErrEventUndeliverable) {
+			p.l.Error(fmt.Errorf("events - Publish %s: %w", routingKey, err))
+		}
+		return err
+	}
+
+	err := p.emitter.Publish(ctx, routingKey, event)
+	if err != nil {
+		p.l.Error(fmt.Errorf("events - Publish %s: %w", routingKey, err))
+	}
+	return err
+}
+
+// eventConsumer is a usecase interested in the events matching topics.
+type eventConsumer struct {
+	name   string
+	topics []string
+	handle func(routingKey string, body []byte) error
+}
+
+// dispatch hands an event to the consumers whose topics match its routing key
+// and reports whether there were any.
+func dispatch(l logger.Interface, consumers []eventConsumer, routingKey string, body []byte) bool {
+	matched := false
+	for _, c := range consumers {
+		if !matchesAny(c.topics, routingKey) {
+			continue
+		}
+		matched = true
+		err := c.handle(routingKey, body)
+		if err != nil {
+			l.Error(fmt.Errorf("events - %s %s: %w", c.name, routingKey, err))
+		}
+	}
+	return matched
+}
+
+// dispatchLocal delivers published events straight to the consumers when
+// there is no broker in between.
+func dispatchLocal(l logger.Interface, consumers []eventConsumer) func(routingKey string, body []byte) error {
+	return func(routingKey string, body []byte) error {
+		if !dispatch(l, consumers, routingKey, body) {
+			return usecase.ErrEventUndeliverable
+		}
+		return nil
+	}
+}
+
+func matchesAny(topics []string, routingKey string) bool {
+	for _, topic := range topics {
+		if matchTopic(strings.Split(topic, "."), strings.Split(routingKey, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTopic follows rabbitmq topic exchange rules: "*" matches exactly one
+// word and "#" zero or more.
+func matchTopic(pattern []string, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchTopic(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && matchTopic(pattern[1:], words[1:])
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		topic      string
+		routingKey string
+		want       bool
+	}{
+		{"order.placed", "order.placed", true},
+		{"order.placed", "order.accepted", false},
+		{"order.placed", "order", false},
+		{"order.placed", "order.placed.late", false},
+
+		// "*" matches exactly one word.
+		{"order.*", "order.placed", true},
+		{"order.*", "order", false},
+		{"order.*", "order.status.changed", false},
+		{"*.placed", "order.placed", true},
+		{"*.placed", "placed", false},
+		{"*.*", "order.placed", true},
+		{"*", "order", true},
+		{"*", "order.placed", false},
+
+		// "#" matches zero or more words.
+		{"order.#", "order", true},
+		{"order.#", "order.placed", true},
+		{"order.#", "order.status.changed", true},
+		{"order.#", "shop.created", false},
+		{"#.changed", "changed", true},
+		{"#.changed", "order.status.changed", true},
+		{"#.changed", "order.status.changed.twice", false},
+		{"order.#.changed", "order.changed", true},
+		{"order.#.changed", "order.status.changed", true},
+		{"order.#.changed", "order.status.other", false},
+		{"#", "order.status.changed", true},
+		{"#.#", "order", true},
+		{"#.*", "order.placed", true},
+		{"#.*", "", true},
+		{"*.#", "order", true},
+		{"order.*.#", "order", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic+" "+tt.routingKey, func(t *testing.T) {
+			got := matchTopic(strings.Split(tt.topic, "."), strings.Split(tt.routingKey, "."))
+			if got != tt.want {
+				t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.topic, tt.routingKey, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	topics := []string{"order.placed", "user.#"}
+
+	for routingKey, want := range map[string]bool{
+		"order.placed":          true,
+		"user.email.verified":   true,
+		"order.accepted":        false,
+		"shop.menu.item.update": false,
+	} {
+		if got := matchesAny(topics, routingKey); got != want {
+			t.Errorf("matchesAny(%v, %q) = %v, want %v", topics, routingKey, got, want)
+		}
+	}
+	if matchesAny(nil, "order.placed") {
+		t.Error("a consumer without topics matched an event")
+	}
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+const _defaultShutdownTimeout = 15 * time.Second
+
+// component is a long-running part of the gateway: the http server, a rabbitmq
+// consumer or a background worker.
+type component struct {
+	name     string
+	notify   <-chan error
+	shutdown func(ctx context.Context) error
+}
+
+// connection is a client the components share, closed once all of them have
+// stopped.
+type connection struct {
+	name  string
+	close func() error
+}
+
+type componentError struct {
+	name string
+	err  error
+}
+
+// lifecycle starts nothing by itself. Components register once they are
+// running, wait blocks until a signal arrives or one of them fails, and
+// shutdown stops them in registration order within a single deadline before
+// closing the connections.
+type lifecycle struct {
+	l           logger.Interface
+	timeout     time.Duration
+	components  []component
+	connections []connection
+}
+
+func newLifecycle(l logger.Interface, timeout time.Duration) *lifecycle {
+	if timeout <= 0 {
+		timeout = _defaultShutdownTimeout
+	}
+	return &lifecycle{
+		l:       l,
+		timeout: timeout,
+	}
+}
+
+func (lc *lifecycle) add(name string, notify <-chan error, shutdown func(ctx context.Context) error) {
+	lc.components = append(lc.components, component{
+		name:     name,
+		notify:   notify,
+		shutdown: shutdown,
+	})
+}
+
+// addConnection closes conn at the end of shutdown, after every component.
+func (lc *lifecycle) addConnection(name string, close func() error) {
+	lc.connections = append(lc.connections, connection{
+		name:  name,
+		close: close,
+	})
+}
+
+// addWorker runs fn in the background until shutdown cancels its context.
+func (lc *lifecycle) addWorker(name string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	notify := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		err := fn(ctx)
+		if ctx.Err() == nil {
+			if err == nil {
+				err = errors.New("worker stopped unexpectedly")
+			}
+			notify <- err
+		}
+		close(notify)
+	}()
+
+	lc.add(name, notify, func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	})
+}
+
+// wait blocks until SIGINT/SIGTERM is received or a component reports a
+// failure.
+func (lc *lifecycle) wait() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	failed := make(chan componentError, len(lc.components))
+	for _, c := range lc.components {
+		go func(c component) {
+			err, ok := <-c.notify
+			if !ok {
+				err = errors.New("stopped unexpectedly")
+			}
+			failed <- componentError{c.name, err}
+		}(c)
+	}
+
+	select {
+	case s := <-interrupt:
+		lc.l.Info("app - Run - signal: " + s.String())
+	case f := <-failed:
+		lc.l.Error(fmt.Errorf("app - Run - %s.Notify: %w", f.name, f.err))
+	}
+}
+
+// shutdown drains the components one by one in the order they were added so
+// that the http server stops taking requests before the workers and consumers
+// it may depend on go away. The connections are closed last, the most recent
+// first.
+func (lc *lifecycle) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), lc.timeout)
+	defer cancel()
+
+	for _, c := range lc.components {
+		err := c.shutdown(ctx)
+		if err != nil {
+			lc.l.Error(fmt.Errorf("app - Run - %s.Shutdown: %w", c.name, err))
+			continue
+		}
+		lc.l.Info("app - Run - " + c.name + " stopped")
+	}
+
+	for i := len(lc.connections) - 1; i >= 0; i-- {
+		c := lc.connections[i]
+		err := c.close()
+		if err != nil {
+			lc.l.Error(fmt.Errorf("app - Run - %s.Close: %w", c.name, err))
+			continue
+		}
+		lc.l.Info("app - Run - " + c.name + " closed")
+	}
+}
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+// recordingLog keeps everything logged to it.
+type recordingLog struct {
+	logger.Interface
+
+	mu     sync.Mutex
+	infos  []string
+	errors []string
+}
+
+func (log *recordingLog) Info(message string, args ...interface{}) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.infos = append(log.infos, message)
+}
+
+func (log *recordingLog) Error(message interface{}, args ...interface{}) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.errors = append(log.errors, fmt.Sprint(message))
+}
+
+// steps records what the fake components did, in order.
+type steps struct {
+	mu   sync.Mutex
+	done []string
+}
+
+func (s *steps) record(step string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = append(s.done, step)
+}
+
+func (s *steps) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.done...)
+}
+
+func TestLifecycleShutdownOrder(t *testing.T) {
+	log := &recordingLog{}
+	lc := newLifecycle(log, time.Second)
+	s := &steps{}
+
+	// Registered the way Run does: connections first, as they are opened.
+	lc.addConnection("redis", func() error {
+		s.record("close redis")
+		return nil
+	})
+	lc.addConnection("rmqConnection", func() error {
+		s.record("close rmq connection")
+		return nil
+	})
+	lc.add("httpServer", make(chan error), func(ctx context.Context) error {
+		s.record("stop http")
+		return nil
+	})
+	lc.addWorker("dispatcher", func(ctx context.Context) error {
+		<-ctx.Done()
+		s.record("drain workers")
+		return nil
+	})
+	lc.add("rmqConsumer", make(chan error), func(ctx context.Context) error {
+		s.record("close consumer")
+		return nil
+	})
+
+	lc.shutdown()
+
+	want := []string{"stop http", "drain workers", "close consumer", "close rmq connection", "close redis"}
+	if got := s.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("shutdown ran %q, want %q", got, want)
+	}
+	wantLogs := []string{
+		"app - Run - httpServer stopped",
+		"app - Run - dispatcher stopped",
+		"app - Run - rmqConsumer stopped",
+		"app - Run - rmqConnection closed",
+		"app - Run - redis closed",
+	}
+	if !reflect.DeepEqual(log.infos, wantLogs) || len(log.errors) != 0 {
+		t.Errorf("shutdown logged %q and errors %q, want %q", log.infos, log.errors, wantLogs)
+	}
+}
+
+func TestLifecycleShutdownErrors(t *testing.T) {
+	log := &recordingLog{}
+	lc := newLifecycle(log, time.Second)
+	s := &steps{}
+
+	lc.add("httpServer", make(chan error), func(ctx context.Context) error {
+		s.record("stop http")
+		return errors.New("listener closed twice")
+	})
+	lc.add("rmqConsumer", make(chan error), func(ctx context.Context) error {
+		s.record("close consumer")
+		return nil
+	})
+	lc.addConnection("rmqConnection", func() error {
+		s.record("close rmq connection")
+		return errors.New("already closed")
+	})
+
+	lc.shutdown()
+
+	// A failing component doesn't keep the others running.
+	want := []string{"stop http", "close consumer", "close rmq connection"}
+	if got := s.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("shutdown ran %q, want %q", got, want)
+	}
+	wantErrors := []string{
+		"app - Run - httpServer.Shutdown: listener closed twice",
+		"app - Run - rmqConnection.Close: already closed",
+	}
+	if !reflect.DeepEqual(log.errors, wantErrors) {
+		t.Errorf("shutdown logged errors %q, want %q", log.errors, wantErrors)
+	}
+}
+
+func TestLifecycleShutdownTimeout(t *testing.T) {
+	log := &recordingLog{}
+	lc := newLifecycle(log, 50*time.Millisecond)
+	s := &steps{}
+
+	lc.add("httpServer", make(chan error), func(ctx context.Context) error {
+		<-ctx.Done()
+		s.record("stop http")
+		return ctx.Err()
+	})
+	// The worker ignores the cancel and is given up on.
+	stuck := make(chan struct{})
+	defer close(stuck)
+	lc.addWorker("dispatcher", func(ctx context.Context) error {
+		<-stuck
+		return nil
+	})
+	var late error
+	lc.add("rmqConsumer", make(chan error), func(ctx context.Context) error {
+		late = ctx.Err()
+		s.record("close consumer")
+		return nil
+	})
+	lc.addConnection("rmqConnection", func() error {
+		s.record("close rmq connection")
+		return nil
+	})
+
+	start := time.Now()
+	lc.shutdown()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %v, want about the 50ms timeout", elapsed)
+	}
+
+	// The deadline is shared: whatever comes after a slow component only
+	// gets what is left of it, but it still runs.
+	want := []string{"stop http", "close consumer", "close rmq connection"}
+	if got := s.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("shutdown ran %q, want %q", got, want)
+	}
+	if !errors.Is(late, context.DeadlineExceeded) {
+		t.Errorf("rmqConsumer was shut down with %v left, want an expired context", late)
+	}
+	wantErrors := []string{
+		"app - Run - httpServer.Shutdown: context deadline exceeded",
+		"app - Run - dispatcher.Shutdown: context deadline exceeded",
+	}
+	if !reflect.DeepEqual(log.errors, wantErrors) {
+		t.Errorf("shutdown logged errors %q, want %q", log.errors, wantErrors)
+	}
+}
+
+func TestLifecycleDefaultTimeout(t *testing.T) {
+	if lc := newLifecycle(&recordingLog{}, 0); lc.timeout != _defaultShutdownTimeout {
+		t.Errorf("newLifecycle(0) has a timeout of %v, want %v", lc.timeout, _defaultShutdownTimeout)
+	}
+}
+
+func TestLifecycleWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		notify func(notify chan error)
+		want   string
+	}{
+		{
+			name:   "component failed",
+			notify: func(notify chan error) { notify <- errors.New("listen tcp :8080: address in use") },
+			want:   "app - Run - httpServer.Notify: listen tcp :8080: address in use",
+		},
+		{
+			name:   "notify closed",
+			notify: func(notify chan error) { close(notify) },
+			want:   "app - Run - httpServer.Notify: stopped unexpectedly",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &recordingLog{}
+			lc := newLifecycle(log, time.Second)
+			notify := make(chan error, 1)
+			lc.add("httpServer", notify, func(ctx context.Context) error { return nil })
+
+			tt.notify(notify)
+			lc.wait()
+
+			if !reflect.DeepEqual(log.errors, []string{tt.want}) {
+				t.Errorf("wait logged %q, want %q", log.errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestLifecycleWorkerStopped(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"with an error", errors.New("redis: connection pool timeout"), "app - Run - dispatcher.Notify: redis: connection pool timeout"},
+		{"without an error", nil, "app - Run - dispatcher.Notify: worker stopped unexpectedly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &recordingLog{}
+			lc := newLifecycle(log, time.Second)
+			lc.addWorker("dispatcher", func(ctx context.Context) error { return tt.err })
+
+			lc.wait()
+
+			if !reflect.DeepEqual(log.errors, []string{tt.want}) {
+				t.Errorf("wait logged %q, want %q", log.errors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/internal/usecase/webapi"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+// Reindex rebuilds the search index from the shops service. Interrupting it
+// keeps the checkpoint at checkpointPath, and running it again resumes.
+func Reindex(cfg *config.Config, opts entity.ReindexOptions, checkpointPath string) error {
+	l := logger.New(cfg.LogLevel)
+
+	var versions usecase.SearchVersions
+	if cfg.ElasticsearchURL != "" {
+		client, err := newElasticsearchClient(cfg)
+		if err != nil {
+			return fmt.Errorf("app - Reindex - newElasticsearchClient: %w", err)
+		}
+		versions = repo.NewSearchElasticIndex(client, cfg.SearchIndex)
+	} else if !opts.DryRun {
+		return errors.New("app - Reindex - elasticsearch url is not set, only a dry run is possible")
+	}
+
+	shopsClient := newDownstreamClient(cfg, "shops", cfg.ShopsServiceAddress, cfg.ShopsServiceTimeout)
+	shopwebapi := webapi.NewShopWebAPI(cfg, shopsClient)
+	reindexUseCase := usecase.NewReindexUseCase(cfg, shopwebapi, versions, repo.NewReindexCheckpointFileStore(checkpointPath))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := reindexUseCase.Reindex(ctx, opts, func(checkpoint *entity.ReindexCheckpoint) {
+		l.Info(fmt.Sprintf("app - Reindex - %s: %d shops, %d documents", checkpoint.Version, checkpoint.Shops, checkpoint.Documents))
+	})
+	if err != nil {
+		return fmt.Errorf("app - Reindex: %w", err)
+	}
+
+	switch {
+	case report.DryRun:
+		l.Info(fmt.Sprintf("app - Reindex - dry run: would index %d shops, %d documents", report.Shops, report.Documents))
+	case report.Resumed:
+		l.Info(fmt.Sprintf("app - Reindex - resumed and published %s under %s, replacing %v", report.Version, report.Alias, report.Replaced))
+	default:
+		l.Info(fmt.Sprintf("app - Reindex - published %s under %s, replacing %v", report.Version, report.Alias, report.Replaced))
+	}
+	return nil
+}
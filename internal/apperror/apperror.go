@@ -0,0 +1,131 @@
+// Package apperror defines the errors usecases return. They describe what went
+// wrong in domain terms; the transport layer decides how to present them.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindUpstream     Kind = "upstream"
+	KindTooMany      Kind = "too_many_requests"
+)
+
+// Stable codes for upstream failures, exposed to API clients.
+const (
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeUpstreamTimeout     = "upstream_timeout"
+	CodeRequestCanceled     = "request_canceled"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	// RetryAfter tells clients of KindTooMany errors when to try again.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind Kind, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    string(kind),
+		Message: message,
+	}
+}
+
+func NotFound(message string) *Error {
+	return newError(KindNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return newError(KindConflict, message)
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	err := newError(KindValidation, message)
+	err.Fields = fields
+	return err
+}
+
+func Unauthorized(message string) *Error {
+	return newError(KindUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return newError(KindForbidden, message)
+}
+
+// TooMany rejects a request until retryAfter has passed.
+func TooMany(message string, retryAfter time.Duration) *Error {
+	err := newError(KindTooMany, message)
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// Upstream reports a failure of a downstream service. code is one of the
+// CodeUpstream* constants.
+func Upstream(code string, message string, err error) *Error {
+	return &Error{
+		Kind:    KindUpstream,
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func Internal(err error) *Error {
+	return &Error{
+		Kind:    KindInternal,
+		Code:    string(KindInternal),
+		Message: "internal error",
+		Err:     err,
+	}
+}
+
+// As returns err as an *Error, wrapping unknown errors as internal ones.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
+
+// Is reports whether err is an *Error of the given kind.
+func Is(err error, kind Kind) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Kind == kind
+}
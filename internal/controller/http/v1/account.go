@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type accountRoutes struct {
+	accountUsecase usecase.Account
+	logger         logger.Interface
+}
+
+func (server *Server) newAccountRoutes(groups routeGroups, accountUsecase usecase.Account, logger logger.Interface) {
+	routes := &accountRoutes{accountUsecase, logger}
+
+	groups.public.POST("/password/forgot", server.rateLimit("password_forgot"), routes.forgotPassword)
+	groups.public.POST("/password/reset", server.rateLimit("password_reset"), routes.resetPassword)
+	groups.public.PATCH("/users/verify_email", routes.verifyEmail)
+
+	groups.authenticated.POST("/users/verify_email", server.rateLimit("verify_email"), routes.sendVerification)
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// @Summary     Forgot password
+// @Description Mail a password reset link. Succeeds for unknown emails too.
+// @ID          forgotPassword
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body ForgotPasswordRequest true "email"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Failure     503 {object} response
+// @Router      /password/forgot [post]
+func (r *accountRoutes) forgotPassword(ctx *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		r.logger.Error(err, "http - v1 - account routes - forgotPassword")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	err := r.accountUsecase.ForgotPassword(ctx.Request.Context(), req.Email)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - account routes - forgotPassword")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "if the account exists, a reset link was sent to its email")
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// @Summary     Reset password
+// @Description Set a new password with the token of a reset link
+// @ID          resetPassword
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body ResetPasswordRequest true "reset"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     409 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Router      /password/reset [post]
+func (r *accountRoutes) resetPassword(ctx *gin.Context) {
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - account routes - resetPassword")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	resp, err := r.accountUsecase.ResetPassword(ctx.Request.Context(), req.Token, req.Password)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - account routes - resetPassword")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Send verification email
+// @Description Mail a new link to verify my email
+// @ID          sendVerification
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} string
+// @Failure     409 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Failure     503 {object} response
+// @Security 		BearerAuth
+// @Router      /users/verify_email [post]
+func (r *accountRoutes) sendVerification(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	err := r.accountUsecase.SendVerification(ctx.Request.Context(), payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - account routes - sendVerification")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "verification link was sent")
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// @Summary     Verify email
+// @Description Verify an email with the token of a verification link. Renew the access token to use the verified state.
+// @ID          verifyEmail
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body VerifyEmailRequest true "verification"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Router      /users/verify_email [patch]
+func (r *accountRoutes) verifyEmail(ctx *gin.Context) {
+	var req VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - account routes - verifyEmail")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	resp, err := r.accountUsecase.VerifyEmail(ctx.Request.Context(), req.Token)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - account routes - verifyEmail")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
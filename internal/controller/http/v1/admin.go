@@ -0,0 +1,278 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type adminRoutes struct {
+	adminUsecase usecase.Admin
+	logger       logger.Interface
+}
+
+func (server *Server) newAdminRoutes(groups routeGroups, adminUsecase usecase.Admin, logger logger.Interface) {
+	routes := &adminRoutes{adminUsecase, logger}
+
+	adminRoutes := groups.admin.Group("/admin")
+	adminRoutes.POST("/invites", routes.createInvite)
+	adminRoutes.GET("/requests", routes.getRequests)
+	adminRoutes.POST("/requests/:id/approve", routes.approveRequest)
+	adminRoutes.POST("/requests/:id/reject", routes.rejectRequest)
+	adminRoutes.DELETE("/users/:id", routes.revokeAdmin)
+	adminRoutes.POST("/couriers", routes.grantCourier)
+	adminRoutes.GET("/audit", routes.getAudit)
+}
+
+type CreateInviteRequest struct {
+	UserId int64 `json:"user_id" binding:"required,min=1"`
+}
+
+// @Summary     CreateInvite
+// @Description Invite a user to become an admin. The invite expires and can be redeemed once.
+// @ID          createInvite
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateInviteRequest true "invite"
+// @Success     200 {object} entity.AdminInvite
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/invites [post]
+func (r *adminRoutes) createInvite(ctx *gin.Context) {
+	var req CreateInviteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - createInvite")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	invite, err := r.adminUsecase.CreateInvite(ctx.Request.Context(), payload.UserId, req.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - createInvite")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invite)
+}
+
+type GetAdminRequestsRequest struct {
+	Status entity.AdminRequestStatus `form:"status,default=pending" binding:"oneof=pending approved rejected"`
+}
+
+// @Summary     GetAdminRequests
+// @Description List admin requests
+// @ID          getAdminRequests
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       status query string false "pending, approved or rejected"
+// @Success     200 {object} []entity.AdminRequest
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/requests [get]
+func (r *adminRoutes) getRequests(ctx *gin.Context) {
+	var req GetAdminRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	requests, err := r.adminUsecase.ListRequests(ctx.Request.Context(), req.Status)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - getRequests")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, requests)
+}
+
+// @Summary     ApproveAdminRequest
+// @Description Grant the admin role to the user of a pending request
+// @ID          approveAdminRequest
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "user id"
+// @Success     200 {object} entity.AdminRequest
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/requests/{id}/approve [post]
+func (r *adminRoutes) approveRequest(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - approveRequest")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	adminRequest, err := r.adminUsecase.ApproveRequest(ctx.Request.Context(), payload.UserId, params.Id)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - approveRequest")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, adminRequest)
+}
+
+// @Summary     RejectAdminRequest
+// @Description Reject a pending admin request
+// @ID          rejectAdminRequest
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "user id"
+// @Success     200 {object} entity.AdminRequest
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/requests/{id}/reject [post]
+func (r *adminRoutes) rejectRequest(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - rejectRequest")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	adminRequest, err := r.adminUsecase.RejectRequest(ctx.Request.Context(), payload.UserId, params.Id)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - rejectRequest")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, adminRequest)
+}
+
+// @Summary     RevokeAdmin
+// @Description Take the admin role away from a user
+// @ID          revokeAdmin
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "user id"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/users/{id} [delete]
+func (r *adminRoutes) revokeAdmin(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - revokeAdmin")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	resp, err := r.adminUsecase.RevokeAdmin(ctx.Request.Context(), payload.UserId, params.Id)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - revokeAdmin")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type GrantCourierRequest struct {
+	UserId int64 `json:"user_id" binding:"required,min=1"`
+}
+
+// @Summary     GrantCourier
+// @Description Make a user a courier
+// @ID          grantCourier
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       request body GrantCourierRequest true "user"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/couriers [post]
+func (r *adminRoutes) grantCourier(ctx *gin.Context) {
+	var req GrantCourierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - grantCourier")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	resp, err := r.adminUsecase.GrantCourier(ctx.Request.Context(), payload.UserId, req.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - grantCourier")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type GetAuditRequest struct {
+	Limit  int32 `form:"limit,default=20" binding:"min=1,max=100"`
+	Offset int32 `form:"offset,default=0" binding:"min=0"`
+}
+
+// @Summary     GetAudit
+// @Description List admin and courier grants and revocations, newest first
+// @ID          getAudit
+// @Tags  	    admin
+// @Accept      json
+// @Produce     json
+// @Param       limit query string false "rows to return"
+// @Param       offset query string  false  "rows to skip"
+// @Success     200 {object} []entity.AuditEntry
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /admin/audit [get]
+func (r *adminRoutes) getAudit(ctx *gin.Context) {
+	var req GetAuditRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	entries, err := r.adminUsecase.ListAudit(ctx.Request.Context(), req.Limit, req.Offset)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - admin routes - getAudit")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
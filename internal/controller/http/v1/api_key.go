@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type apiKeyRoutes struct {
+	apiKeyUsecase usecase.APIKey
+	logger        logger.Interface
+}
+
+func (server *Server) newAPIKeyRoutes(groups routeGroups, apiKeyUsecase usecase.APIKey, logger logger.Interface) {
+	routes := &apiKeyRoutes{apiKeyUsecase, logger}
+
+	keyRoutes := groups.authenticated.Group("/shops/:id/api_keys", server.requirePermission(entity.PermissionAPIKeysManage, shopParam("id")))
+	keyRoutes.POST("/", routes.createAPIKey)
+	keyRoutes.GET("/", routes.listAPIKeys)
+	keyRoutes.DELETE("/:key_id", routes.revokeAPIKey)
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string              `json:"name" binding:"required"`
+	Scopes []entity.Permission `json:"scopes" binding:"required" example:"menu:write"`
+	// RateLimit is in requests a minute; the configured default applies
+	// when it is left out.
+	RateLimit int        `json:"rate_limit"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyParams struct {
+	Id    int64  `uri:"id" binding:"required,min=1"`
+	KeyId string `uri:"key_id" binding:"required"`
+}
+
+// @Summary     Create API key
+// @Description Create an API key for a partner of my shop. The key is only shown in this response; send it as X-API-Key.
+// @ID          createAPIKey
+// @Tags  	    shops
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "shop id"
+// @Param       request body CreateAPIKeyRequest true "key"
+// @Success     200 {object} entity.CreatedAPIKey
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /shops/{id}/api_keys/ [post]
+func (r *apiKeyRoutes) createAPIKey(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - createAPIKey")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - createAPIKey")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	key, err := r.apiKeyUsecase.CreateAPIKey(ctx.Request.Context(), payload.UserId, params.Id, &entity.CreateAPIKey{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		RateLimit: req.RateLimit,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - createAPIKey")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+// @Summary     List API keys
+// @Description List the API keys of my shop, revoked ones included
+// @ID          listAPIKeys
+// @Tags  	    shops
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "shop id"
+// @Success     200 {object} []entity.APIKey
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /shops/{id}/api_keys/ [get]
+func (r *apiKeyRoutes) listAPIKeys(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - listAPIKeys")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	keys, err := r.apiKeyUsecase.ListAPIKeys(ctx.Request.Context(), params.Id)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - listAPIKeys")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// @Summary     Revoke API key
+// @Description Revoke an API key of my shop
+// @ID          revokeAPIKey
+// @Tags  	    shops
+// @Accept      json
+// @Produce     json
+// @Param       id path int true "shop id"
+// @Param       key_id path string true "key id"
+// @Success     200 {object} entity.APIKey
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     404 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /shops/{id}/api_keys/{key_id} [delete]
+func (r *apiKeyRoutes) revokeAPIKey(ctx *gin.Context) {
+	var params APIKeyParams
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - revokeAPIKey")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	key, err := r.apiKeyUsecase.RevokeAPIKey(ctx.Request.Context(), params.Id, params.KeyId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - api key routes - revokeAPIKey")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
@@ -5,26 +5,47 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/zura-t/go_delivery_system/token"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/pkg/ratelimit"
 )
 
 const (
 	authorizationHeader     = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	apiKeyHeader            = "X-API-Key"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// apiKeyAllowedRoutes are the routes API keys may call, by method and path. Keys
+// act for a shop, so everything about the accounts of people stays out of
+// their reach even when a route would let a shop owner in.
+var apiKeyAllowedRoutes = map[string]bool{
+	http.MethodPatch + " /shops/:id":             true,
+	http.MethodPost + " /shops/menu_items/":      true,
+	http.MethodPatch + " /shops/menu_items/:id":  true,
+	http.MethodDelete + " /shops/menu_items/:id": true,
+	http.MethodPatch + " /orders/:id/status":     true,
+}
+
+// authMiddleware authenticates a bearer access token or, without one, an
+// API key. Either way handlers find the payload under
+// authorizationPayloadKey.
+func (server *Server) authMiddleware() gin.HandlerFunc {
 	abort := func(ctx *gin.Context, err error) {
-		errorResponse(ctx, http.StatusUnauthorized, err.Error())
+		errorResponse(ctx, apperror.Unauthorized(err.Error()))
 		ctx.Abort()
 	}
 
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeader)
 		if len(authorizationHeader) == 0 {
+			if apiKey := ctx.GetHeader(apiKeyHeader); apiKey != "" {
+				server.authenticateAPIKey(ctx, apiKey)
+				return
+			}
 			abort(ctx, errors.New("authorization header is not provided"))
 			return
 		}
@@ -42,9 +63,10 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		}
 
 		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := server.sessionUsecase.Authenticate(ctx.Request.Context(), accessToken)
 		if err != nil {
-			abort(ctx, err)
+			errorResponse(ctx, err)
+			ctx.Abort()
 			return
 		}
 
@@ -52,3 +74,31 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// authenticateAPIKey lets a request in with an API key, on the routes keys
+// may use and within the key's rate limit.
+func (server *Server) authenticateAPIKey(ctx *gin.Context, rawKey string) {
+	if !apiKeyAllowedRoutes[ctx.Request.Method+" "+ctx.FullPath()] {
+		errorResponse(ctx, apperror.Forbidden("api keys can't be used for this route"))
+		return
+	}
+
+	key, payload, err := server.apiKeyUsecase.Authenticate(ctx.Request.Context(), rawKey)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	result, err := server.limiter.Allow(ctx.Request.Context(), "apikey:"+key.ID, ratelimit.Limit{
+		Burst:  key.RateLimit,
+		Period: time.Minute,
+	})
+	if err != nil {
+		server.l.Error(err, "http - v1 - authenticateAPIKey")
+	} else if limited(ctx, result) {
+		return
+	}
+
+	ctx.Set(authorizationPayloadKey, *payload)
+	ctx.Next()
+}
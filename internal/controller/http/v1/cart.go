@@ -0,0 +1,225 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type cartRoutes struct {
+	cartUsecase usecase.Cart
+	logger      logger.Interface
+}
+
+func (server *Server) newCartRoutes(groups routeGroups, cartUsecase usecase.Cart, logger logger.Interface) {
+	routes := &cartRoutes{cartUsecase, logger}
+
+	cartRoutes := groups.authenticated.Group("/cart")
+	cartRoutes.GET("/", routes.getCart)
+	cartRoutes.DELETE("/", routes.clearCart)
+	cartRoutes.POST("/items", routes.addCartItem)
+	cartRoutes.PATCH("/items/:id", routes.updateCartItem)
+	cartRoutes.DELETE("/items/:id", routes.removeCartItem)
+	cartRoutes.POST("/checkout", requireVerifiedEmail(), routes.checkout)
+}
+
+// @Summary     GetCart
+// @Description Get my cart with current prices; items that left the menu are flagged unavailable
+// @ID          getCart
+// @Tags  	    cart
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} entity.Cart
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /cart/ [get]
+func (r *cartRoutes) getCart(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	cart, err := r.cartUsecase.GetCart(ctx.Request.Context(), payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - getCart")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cart)
+}
+
+type AddCartItemRequest struct {
+	MenuItemId int64 `json:"menu_item_id" binding:"required,min=1"`
+	Quantity   int32 `json:"quantity" binding:"required,min=1,max=100"`
+}
+
+// @Summary     AddCartItem
+// @Description Add a menu item to my cart. All items must come from one shop, 100 different ones at most.
+// @ID          addCartItem
+// @Tags  	    cart
+// @Accept      json
+// @Produce     json
+// @Param       request body AddCartItemRequest true "addCartItem"
+// @Success     200 {object} entity.Cart
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /cart/items [post]
+func (r *cartRoutes) addCartItem(ctx *gin.Context) {
+	var req AddCartItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - addCartItem")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	cart, err := r.cartUsecase.AddItem(ctx.Request.Context(), payload.UserId, req.MenuItemId, req.Quantity)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - addCartItem")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cart)
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int32 `json:"quantity" binding:"required,min=1,max=100"`
+}
+
+// @Summary     UpdateCartItem
+// @Description Change the quantity of an item in my cart
+// @ID          updateCartItem
+// @Tags  	    cart
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "menu item id"
+// @Param       request body UpdateCartItemRequest true "updateCartItem"
+// @Success     200 {object} entity.Cart
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /cart/items/{id} [patch]
+func (r *cartRoutes) updateCartItem(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - updateCartItem")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - updateCartItem")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	cart, err := r.cartUsecase.UpdateItemQuantity(ctx.Request.Context(), payload.UserId, params.Id, req.Quantity)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - updateCartItem")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cart)
+}
+
+// @Summary     RemoveCartItem
+// @Description Remove an item from my cart
+// @ID          removeCartItem
+// @Tags  	    cart
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "menu item id"
+// @Success     200 {object} entity.Cart
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /cart/items/{id} [delete]
+func (r *cartRoutes) removeCartItem(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - removeCartItem")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	cart, err := r.cartUsecase.RemoveItem(ctx.Request.Context(), payload.UserId, params.Id)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - removeCartItem")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cart)
+}
+
+// @Summary     ClearCart
+// @Description Remove all items from my cart
+// @ID          clearCart
+// @Tags  	    cart
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} string
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /cart/ [delete]
+func (r *cartRoutes) clearCart(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	err := r.cartUsecase.ClearCart(ctx.Request.Context(), payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - clearCart")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "cart cleared")
+}
+
+type CheckoutRequest struct {
+	DeliveryLocation LocationRequest `json:"delivery_location" binding:"required"`
+}
+
+// @Summary     Checkout
+// @Description Place an order with the items in my cart, delivered to a location inside the shop's delivery zone
+// @ID          checkout
+// @Tags  	    cart
+// @Accept      json
+// @Produce     json
+// @Param       request body CheckoutRequest true "checkout"
+// @Success     200 {object} entity.Order
+// @Failure     400 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /cart/checkout [post]
+func (r *cartRoutes) checkout(ctx *gin.Context) {
+	var req CheckoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	order, err := r.cartUsecase.Checkout(ctx.Request.Context(), payload.UserId, req.DeliveryLocation.location())
+	if err != nil {
+		r.logger.Error(err, "http - v1 - cart routes - checkout")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,268 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type courierRoutes struct {
+	deliveryUsecase usecase.Delivery
+	logger          logger.Interface
+}
+
+func (server *Server) newCourierRoutes(groups routeGroups, deliveryUsecase usecase.Delivery, logger logger.Interface) {
+	routes := &courierRoutes{deliveryUsecase, logger}
+
+	courierRoutes := groups.authenticated.Group("/couriers", server.requirePermission(entity.PermissionDeliveriesWork, nil))
+	courierRoutes.GET("/me", routes.getCourier)
+	courierRoutes.POST("/online", routes.goOnline)
+	courierRoutes.POST("/offline", routes.goOffline)
+	courierRoutes.POST("/location", routes.updateLocation)
+	courierRoutes.POST("/deliveries/:id/accept", routes.acceptDelivery)
+	courierRoutes.POST("/deliveries/:id/reject", routes.rejectDelivery)
+	courierRoutes.PATCH("/deliveries/:id/status", routes.updateDeliveryStatus)
+}
+
+type LocationRequest struct {
+	Lat *float64 `json:"lat" binding:"required,min=-90,max=90"`
+	Lng *float64 `json:"lng" binding:"required,min=-180,max=180"`
+}
+
+func (req *LocationRequest) location() entity.Location {
+	return entity.Location{Lat: *req.Lat, Lng: *req.Lng}
+}
+
+// @Summary     GetCourier
+// @Description Get my courier status and the delivery offered to or accepted by me
+// @ID          getCourier
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} entity.CourierState
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/me [get]
+func (r *courierRoutes) getCourier(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	state, err := r.deliveryUsecase.GetCourier(ctx.Request.Context(), payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - getCourier")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, state)
+}
+
+// @Summary     GoOnline
+// @Description Start receiving delivery offers
+// @ID          goOnline
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Param       request body LocationRequest true "current location"
+// @Success     200 {object} entity.Courier
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/online [post]
+func (r *courierRoutes) goOnline(ctx *gin.Context) {
+	var req LocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - goOnline")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	courier, err := r.deliveryUsecase.GoOnline(ctx.Request.Context(), payload.UserId, req.location())
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - goOnline")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, courier)
+}
+
+// @Summary     GoOffline
+// @Description Stop receiving delivery offers. A pending offer is rejected.
+// @ID          goOffline
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} entity.Courier
+// @Failure     403 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/offline [post]
+func (r *courierRoutes) goOffline(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	courier, err := r.deliveryUsecase.GoOffline(ctx.Request.Context(), payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - goOffline")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, courier)
+}
+
+// @Summary     UpdateLocation
+// @Description Report my GPS position
+// @ID          updateCourierLocation
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Param       request body LocationRequest true "current location"
+// @Success     200 {object} entity.Courier
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/location [post]
+func (r *courierRoutes) updateLocation(ctx *gin.Context) {
+	var req LocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - updateLocation")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	courier, err := r.deliveryUsecase.UpdateLocation(ctx.Request.Context(), payload.UserId, req.location())
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - updateLocation")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, courier)
+}
+
+// @Summary     AcceptDelivery
+// @Description Accept the delivery of an order offered to me
+// @ID          acceptDelivery
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "order id"
+// @Success     200 {object} entity.Delivery
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/deliveries/{id}/accept [post]
+func (r *courierRoutes) acceptDelivery(ctx *gin.Context) {
+	var req IdParam
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - acceptDelivery")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	delivery, err := r.deliveryUsecase.AcceptDelivery(ctx.Request.Context(), req.Id, payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - acceptDelivery")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// @Summary     RejectDelivery
+// @Description Reject the delivery of an order offered to me; it goes to the next courier
+// @ID          rejectDelivery
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "order id"
+// @Success     200 {object} entity.Delivery
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/deliveries/{id}/reject [post]
+func (r *courierRoutes) rejectDelivery(ctx *gin.Context) {
+	var req IdParam
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - rejectDelivery")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	delivery, err := r.deliveryUsecase.RejectDelivery(ctx.Request.Context(), req.Id, payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - rejectDelivery")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+type UpdateDeliveryStatusRequest struct {
+	Status entity.OrderStatus `json:"status" binding:"required" enums:"picked_up,delivered"`
+}
+
+// @Summary     UpdateDeliveryStatus
+// @Description Mark an order I deliver as picked up or delivered
+// @ID          updateDeliveryStatus
+// @Tags  	    couriers
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "order id"
+// @Param       request body UpdateDeliveryStatusRequest true "updateDeliveryStatus"
+// @Success     200 {object} entity.Delivery
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /couriers/deliveries/{id}/status [patch]
+func (r *courierRoutes) updateDeliveryStatus(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - updateDeliveryStatus")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	var req UpdateDeliveryStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - updateDeliveryStatus")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	delivery, err := r.deliveryUsecase.UpdateDeliveryStatus(ctx.Request.Context(), params.Id, payload.UserId, req.Status)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - courier routes - updateDeliveryStatus")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
@@ -1,13 +1,97 @@
 package v1
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
 )
 
 type response struct {
-	Error string `json:"error" example:"message"`
+	Error  string                `json:"error" example:"message"`
+	Code   string                `json:"code" example:"not_found"`
+	Fields []apperror.FieldError `json:"fields,omitempty"`
+}
+
+func errorResponse(c *gin.Context, err error) {
+	appErr := apperror.As(err)
+	if appErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+	c.AbortWithStatusJSON(errorStatus(appErr), response{
+		Error:  appErr.Message,
+		Code:   appErr.Code,
+		Fields: appErr.Fields,
+	})
+}
+
+func errorStatus(err *apperror.Error) int {
+	switch err.Kind {
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict:
+		return http.StatusConflict
+	case apperror.KindValidation:
+		return http.StatusBadRequest
+	case apperror.KindUnauthorized:
+		return http.StatusUnauthorized
+	case apperror.KindForbidden:
+		return http.StatusForbidden
+	case apperror.KindTooMany:
+		return http.StatusTooManyRequests
+	case apperror.KindUpstream:
+		switch err.Code {
+		case apperror.CodeUpstreamUnavailable:
+			return http.StatusServiceUnavailable
+		case apperror.CodeUpstreamTimeout:
+			return http.StatusGatewayTimeout
+		case apperror.CodeRequestCanceled:
+			return httpclient.StatusClientClosedRequest
+		}
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
-func errorResponse(c *gin.Context, code int, msg string) {
-	c.AbortWithStatusJSON(code, response{msg})
-}
\ No newline at end of file
+// bindError turns a request binding failure into a validation error that
+// lists the offending fields.
+func bindError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return apperror.Validation(err.Error())
+	}
+
+	fields := make([]apperror.FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		fields[i] = apperror.FieldError{
+			Field:   fieldErr.Field(),
+			Message: fieldMessage(fieldErr),
+		}
+	}
+	return apperror.Validation("invalid request", fields...)
+}
+
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + err.Param()
+	case "max":
+		return "must be at most " + err.Param()
+	case "gt":
+		return "must be greater than " + err.Param()
+	case "excluded_with":
+		return "can't be set together with " + err.Param()
+	default:
+		return "failed on the " + err.Tag() + " rule"
+	}
+}
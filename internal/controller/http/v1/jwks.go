@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+type jwksRoutes struct {
+	keySet token.KeySet
+}
+
+// newJWKSRoutes publishes the public keys of asymmetric token makers. Tokens
+// signed with the shared symmetric key can't be verified this way, so the
+// route only exists when keys are configured.
+func newJWKSRoutes(handler *gin.RouterGroup, tokenMaker token.Maker) {
+	keySet, ok := tokenMaker.(token.KeySet)
+	if !ok {
+		return
+	}
+	routes := &jwksRoutes{keySet}
+
+	handler.GET("/.well-known/jwks.json", routes.getJWKS)
+}
+
+// @Summary     JWKS
+// @Description Public keys to verify tokens issued by the gateway
+// @ID          getJWKS
+// @Tags  	    status
+// @Produce     json
+// @Success     200 {object} token.JSONWebKeySet
+// @Router      /.well-known/jwks.json [get]
+func (r *jwksRoutes) getJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, r.keySet.PublicKeys())
+}
@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type mfaRoutes struct {
+	mfaUsecase usecase.MFA
+	logger     logger.Interface
+}
+
+func (server *Server) newMFARoutes(groups routeGroups, mfaUsecase usecase.MFA, logger logger.Interface) {
+	routes := &mfaRoutes{mfaUsecase, logger}
+
+	mfaRoutes := groups.authenticated.Group("/users/mfa")
+	mfaRoutes.POST("/", routes.enroll)
+	mfaRoutes.POST("/confirm", server.rateLimit("mfa"), routes.confirm)
+	mfaRoutes.DELETE("/", server.rateLimit("mfa"), routes.disable)
+	mfaRoutes.POST("/recovery_codes", server.rateLimit("mfa"), routes.regenerateRecoveryCodes)
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// @Summary     Enroll MFA
+// @Description Create a TOTP secret for my authenticator app. It takes effect once confirmed.
+// @ID          enrollMFA
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} entity.MFAEnrollment
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/mfa/ [post]
+func (r *mfaRoutes) enroll(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	enrollment, err := r.mfaUsecase.Enroll(ctx.Request.Context(), payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - enroll")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary     Confirm MFA
+// @Description Enable two-factor authentication with a code of my app and get the recovery codes
+// @ID          confirmMFA
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body MFACodeRequest true "code"
+// @Success     200 {object} entity.MFARecoveryCodes
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/mfa/confirm [post]
+func (r *mfaRoutes) confirm(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - confirm")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	codes, err := r.mfaUsecase.Confirm(ctx.Request.Context(), payload.UserId, req.Code)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - confirm")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// @Summary     Disable MFA
+// @Description Disable two-factor authentication with a code of my app or a recovery code
+// @ID          disableMFA
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body MFACodeRequest true "code"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/mfa/ [delete]
+func (r *mfaRoutes) disable(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - disable")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	resp, err := r.mfaUsecase.Disable(ctx.Request.Context(), payload.UserId, req.Code)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - disable")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// @Summary     Regenerate recovery codes
+// @Description Replace my recovery codes, with a code of my app or a recovery code
+// @ID          regenerateRecoveryCodes
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body MFACodeRequest true "code"
+// @Success     200 {object} entity.MFARecoveryCodes
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/mfa/recovery_codes [post]
+func (r *mfaRoutes) regenerateRecoveryCodes(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - regenerateRecoveryCodes")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	codes, err := r.mfaUsecase.RegenerateRecoveryCodes(ctx.Request.Context(), payload.UserId, req.Code)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - mfa routes - regenerateRecoveryCodes")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+// _oidcStateCookie ties a login at an identity provider to the browser that
+// started it, so nobody can finish their own login in someone else's browser.
+const _oidcStateCookie = "oidc_state"
+
+type oidcRoutes struct {
+	oidcUsecase usecase.OIDC
+	stateTTL    time.Duration
+	logger      logger.Interface
+}
+
+func (server *Server) newOIDCRoutes(groups routeGroups, oidcUsecase usecase.OIDC, logger logger.Interface) {
+	routes := &oidcRoutes{oidcUsecase, server.config.OIDCStateDuration, logger}
+
+	oidcRoutes := groups.public.Group("/oauth/:provider", server.rateLimit("oauth"))
+	oidcRoutes.GET("/login", routes.login)
+	oidcRoutes.GET("/callback", routes.callback)
+}
+
+type OIDCProviderParam struct {
+	Provider string `uri:"provider" binding:"required"`
+}
+
+// @Summary     Login with an identity provider
+// @Description Redirect to the OpenID Connect provider to log in there
+// @ID          oidcLogin
+// @Tags  	    users
+// @Param       provider path string true "provider name"
+// @Success     302
+// @Failure     404 {object} response
+// @Failure     502 {object} response
+// @Router      /oauth/{provider}/login [get]
+func (r *oidcRoutes) login(ctx *gin.Context) {
+	var params OIDCProviderParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	authorization, err := r.oidcUsecase.AuthURL(ctx.Request.Context(), params.Provider)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - oidc routes - login")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.SetCookie(_oidcStateCookie, authorization.State, int(r.stateTTL.Seconds()), "/oauth", "", false, true)
+	ctx.Redirect(http.StatusFound, authorization.URL)
+}
+
+type OIDCCallbackRequest struct {
+	Code             string `form:"code"`
+	State            string `form:"state" binding:"required"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
+
+// @Summary     Identity provider callback
+// @Description Finish a login at an OpenID Connect provider. Users with two-factor authentication get a challenge.
+// @ID          oidcCallback
+// @Tags  	    users
+// @Produce     json
+// @Param       provider path string true "provider name"
+// @Param       code query string false "authorization code"
+// @Param       state query string true "state"
+// @Success     200 {object} entity.UserLoginResponse
+// @Success     202 {object} entity.MFAChallenge
+// @Failure     400 {object} response
+// @Failure     401 {object} response
+// @Failure     403 {object} response
+// @Failure     409 {object} response
+// @Failure     502 {object} response
+// @Router      /oauth/{provider}/callback [get]
+func (r *oidcRoutes) callback(ctx *gin.Context) {
+	var params OIDCProviderParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+	var req OIDCCallbackRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	state, _ := ctx.Cookie(_oidcStateCookie)
+	ctx.SetCookie(_oidcStateCookie, "", -1, "/oauth", "", false, true)
+	if req.Error != "" {
+		errorResponse(ctx, apperror.Unauthorized(fmt.Sprintf("the identity provider refused the login: %s %s", req.Error, req.ErrorDescription)))
+		return
+	}
+	if state == "" || state != req.State {
+		errorResponse(ctx, apperror.Unauthorized("the login was started in another browser"))
+		return
+	}
+
+	user, challenge, err := r.oidcUsecase.Callback(ctx.Request.Context(), params.Provider, req.State, req.Code, sessionClient(ctx))
+	if err != nil {
+		r.logger.Error(err, "http - v1 - oidc routes - callback")
+		errorResponse(ctx, err)
+		return
+	}
+	if challenge != nil {
+		ctx.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	ctx.SetCookie("refresh_token", user.RefreshToken, int(time.Until(user.RefreshTokenExpiresAt).Seconds()), "/", "localhost", false, true)
+	ctx.JSON(http.StatusOK, user)
+}
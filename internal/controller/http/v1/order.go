@@ -0,0 +1,236 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type orderRoutes struct {
+	orderUsecase    usecase.Order
+	trackingUsecase usecase.Tracking
+	logger          logger.Interface
+}
+
+func (server *Server) newOrderRoutes(groups routeGroups, orderUsecase usecase.Order, trackingUsecase usecase.Tracking, logger logger.Interface) {
+	routes := &orderRoutes{orderUsecase, trackingUsecase, logger}
+
+	orderRoutes := groups.authenticated.Group("/orders")
+	orderRoutes.POST("/", requireVerifiedEmail(), routes.createOrder)
+	orderRoutes.GET("/", routes.getOrders)
+	orderRoutes.GET("/:id", routes.getOrder)
+	orderRoutes.GET("/:id/stream", routes.streamOrder)
+	orderRoutes.PATCH("/:id/cancel", routes.cancelOrder)
+	orderRoutes.PATCH("/:id/status", server.requirePermission(entity.PermissionOrdersManage, nil), routes.updateOrderStatus)
+}
+
+type OrderItemRequest struct {
+	MenuItemId int64 `json:"menu_item_id" binding:"required,min=1"`
+	Quantity   int32 `json:"quantity" binding:"required,min=1,max=100"`
+}
+
+type CreateOrderRequest struct {
+	ShopId           int64              `json:"shop_id" binding:"required,min=1"`
+	Items            []OrderItemRequest `json:"items" binding:"required,min=1,max=100,dive"`
+	DeliveryLocation LocationRequest    `json:"delivery_location" binding:"required"`
+}
+
+// @Summary     Create Order
+// @Description Place an order for menu items of one shop, delivered to a location inside its delivery zone
+// @ID          create-order
+// @Tags  	    orders
+// @Accept      json
+// @Produce     json
+// @Param       request body CreateOrderRequest true "createOrder"
+// @Success     200 {object} entity.Order
+// @Failure     400 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /orders/ [post]
+func (r *orderRoutes) createOrder(ctx *gin.Context) {
+	var req CreateOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - order routes - createOrder")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	items := make([]entity.OrderItem, len(req.Items))
+	for i := 0; i < len(req.Items); i++ {
+		items[i] = entity.OrderItem{
+			MenuItemId: req.Items[i].MenuItemId,
+			Quantity:   req.Items[i].Quantity,
+		}
+	}
+
+	order, err := r.orderUsecase.CreateOrder(ctx.Request.Context(), &entity.CreateOrder{
+		UserId:           payload.UserId,
+		ShopId:           req.ShopId,
+		Items:            items,
+		DeliveryLocation: req.DeliveryLocation.location(),
+	})
+	if err != nil {
+		r.logger.Error(err, "http - v1 - order routes - createOrder")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+type GetOrdersRequest struct {
+	Limit  int32 `form:"limit,default=20" binding:"min=1,max=100"`
+	Offset int32 `form:"offset,default=0" binding:"min=0"`
+}
+
+// @Summary     GetOrders
+// @Description List my orders, newest first
+// @ID          getOrders
+// @Tags  	    orders
+// @Accept      json
+// @Produce     json
+// @Param       limit query string false "rows to return"
+// @Param       offset query string  false  "rows to skip"
+// @Success     200 {object} []entity.Order
+// @Failure     400 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /orders/ [get]
+func (r *orderRoutes) getOrders(ctx *gin.Context) {
+	var req GetOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	orders, err := r.orderUsecase.GetOrders(ctx.Request.Context(), payload.UserId, req.Limit, req.Offset)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - order routes - getOrders")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, orders)
+}
+
+// @Summary     GetOrder
+// @Description Get one of my orders
+// @ID          getOrder
+// @Tags  	    orders
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "id"
+// @Success     200 {object} entity.Order
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /orders/{id} [get]
+func (r *orderRoutes) getOrder(ctx *gin.Context) {
+	var req IdParam
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		r.logger.Error(err, "http - v1 - order routes - getOrder")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	order, err := r.orderUsecase.GetOrder(ctx.Request.Context(), req.Id, payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - order routes - getOrder")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// @Summary     CancelOrder
+// @Description Cancel one of my orders before the shop accepts it
+// @ID          cancelOrder
+// @Tags  	    orders
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "id"
+// @Success     200 {object} entity.Order
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /orders/{id}/cancel [patch]
+func (r *orderRoutes) cancelOrder(ctx *gin.Context) {
+	var req IdParam
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		r.logger.Error(err, "http - v1 - order routes - cancelOrder")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	order, err := r.orderUsecase.CancelOrder(ctx.Request.Context(), req.Id, payload.UserId)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - order routes - cancelOrder")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+type UpdateOrderStatusRequest struct {
+	Status entity.OrderStatus `json:"status" binding:"required" enums:"accepted,preparing,ready,picked_up,delivered,cancelled,rejected"`
+}
+
+// @Summary     UpdateOrderStatus
+// @Description Move an order of a shop you manage to its next status
+// @ID          updateOrderStatus
+// @Tags  	    orders
+// @Accept      json
+// @Produce     json
+// @Param       id path IdParam true "id"
+// @Param       request body UpdateOrderStatusRequest true "updateOrderStatus"
+// @Success     200 {object} entity.Order
+// @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     404 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /orders/{id}/status [patch]
+func (r *orderRoutes) updateOrderStatus(ctx *gin.Context) {
+	var params IdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - order routes - updateOrderStatus")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - order routes - updateOrderStatus")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	order, err := r.orderUsecase.UpdateOrderStatus(ctx.Request.Context(), params.Id, payload.Roles, req.Status)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - order routes - updateOrderStatus")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+)
+
+const _streamHeartbeat = 15 * time.Second
+
+// @Summary     StreamOrder
+// @Description Server-Sent Events with the order's status changes and courier location.
+// @Description The first event is the current order. Reconnecting clients send
+// @Description Last-Event-ID (or ?last_event_id=) to replay what they missed.
+// @ID          streamOrder
+// @Tags  	    orders
+// @Produce     text/event-stream
+// @Param       id path IdParam true "id"
+// @Success     200 {string} string "event stream"
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Security 		BearerAuth
+// @Router      /orders/{id}/stream [get]
+func (r *orderRoutes) streamOrder(ctx *gin.Context) {
+	var req IdParam
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		r.logger.Error(err, "http - v1 - order routes - streamOrder")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("last_event_id")
+	}
+
+	order, sub, replay, err := r.trackingUsecase.Follow(ctx.Request.Context(), req.Id, payload.UserId, lastEventID)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - order routes - streamOrder")
+		errorResponse(ctx, err)
+		return
+	}
+	defer r.trackingUsecase.Unfollow(sub)
+
+	snapshot, err := json.Marshal(order)
+	if err != nil {
+		errorResponse(ctx, apperror.Internal(err))
+		return
+	}
+
+	// The server's write timeout is meant for regular requests.
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	writeSSE(ctx.Writer, "", "order", snapshot)
+	for _, event := range replay {
+		writeSSE(ctx.Writer, event.ID, event.Type, event.Data)
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(_streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			writeSSE(ctx.Writer, event.ID, event.Type, event.Data)
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": ping\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func writeSSE(w io.Writer, id string, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
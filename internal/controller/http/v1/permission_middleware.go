@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// shopScope finds the shop a request acts on.
+type shopScope func(ctx *gin.Context) (int64, error)
+
+// requirePermission lets the request through when the roles in the access
+// token grant permission in the shop found by scope. A nil scope accepts the
+// permission in any shop, leaving the exact check to the usecase. Shop
+// ownership may have changed since the token was issued, so for shop scoped
+// permissions it is looked up again and handlers get the payload with the
+// current grants.
+func (server *Server) requirePermission(permission entity.Permission, scope shopScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := getJWTPayload(ctx)
+		if ctx.IsAborted() {
+			return
+		}
+
+		if permission.IsShopScoped() {
+			err := server.sessionUsecase.RefreshShopRoles(ctx.Request.Context(), &payload)
+			if err != nil {
+				errorResponse(ctx, err)
+				return
+			}
+			ctx.Set(authorizationPayloadKey, payload)
+		}
+
+		// Don't bother finding the shop for callers without the permission
+		// anywhere.
+		if !payload.HasPermission(permission, 0) {
+			errorResponse(ctx, apperror.Forbidden(fmt.Sprintf("%s permission is required", permission)))
+			return
+		}
+
+		var shopId int64
+		if scope != nil {
+			var err error
+			shopId, err = scope(ctx)
+			if err != nil {
+				errorResponse(ctx, err)
+				return
+			}
+		}
+
+		if !payload.HasPermission(permission, shopId) {
+			errorResponse(ctx, apperror.Forbidden(fmt.Sprintf("%s permission is required", permission)))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requireVerifiedEmail keeps users who haven't verified their email yet away
+// from actions such as ordering or opening a shop.
+func requireVerifiedEmail() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := getJWTPayload(ctx)
+		if ctx.IsAborted() {
+			return
+		}
+
+		if !payload.EmailVerified {
+			errorResponse(ctx, apperror.Forbidden("email must be verified first"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// shopParam reads the shop from the path parameter name.
+func shopParam(name string) shopScope {
+	return func(ctx *gin.Context) (int64, error) {
+		id, err := strconv.ParseInt(ctx.Param(name), 10, 64)
+		if err != nil || id < 1 {
+			return 0, apperror.Validation("invalid request", apperror.FieldError{
+				Field:   name,
+				Message: "must be a positive number",
+			})
+		}
+		return id, nil
+	}
+}
+
+// shopBody reads the shop_id field of the JSON body. The body is kept, so
+// handlers have to bind it with ShouldBindBodyWith.
+func shopBody(ctx *gin.Context) (int64, error) {
+	var body struct {
+		ShopId int64 `json:"shop_id" binding:"required,min=1"`
+	}
+	if err := ctx.ShouldBindBodyWith(&body, binding.JSON); err != nil {
+		return 0, bindError(err)
+	}
+	return body.ShopId, nil
+}
+
+// menuItemShop looks up the shop of the menu item in the path parameter name.
+func (r *shopRoutes) menuItemShop(name string) shopScope {
+	return func(ctx *gin.Context) (int64, error) {
+		id, err := shopParam(name)(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		item, err := r.shopUsecase.GetMenuItem(ctx.Request.Context(), id)
+		if err != nil {
+			return 0, err
+		}
+		return item.ShopId, nil
+	}
+}
@@ -0,0 +1,97 @@
+package v1_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+type outcome string
+
+const (
+	// allowed requests get past the permission check.
+	allowed outcome = "allowed"
+	// forbidden requests are refused for the missing permission.
+	forbidden outcome = "forbidden"
+)
+
+func TestRequirePermission(t *testing.T) {
+	handler, newToken := newTestRouter(t)
+	customer := entity.RoleGrant{Role: entity.RoleCustomer}
+
+	// The shops service says shopOwner owns shop 1; the token doesn't know
+	// yet, as if the shop was opened after the login.
+	owner := newToken(token.Claims{UserId: shopOwner, Roles: []entity.RoleGrant{customer}})
+	// formerOwner was issued a token while owning shop 1.
+	formerOwner := newToken(token.Claims{UserId: 3, Roles: []entity.RoleGrant{customer, {Role: entity.RoleShopOwner, ShopId: 1}}})
+	staff := newToken(token.Claims{UserId: 4, Roles: []entity.RoleGrant{customer, {Role: entity.RoleShopStaff, ShopId: 1}}})
+	admin := newToken(token.Claims{UserId: 5, Roles: []entity.RoleGrant{customer, {Role: entity.RolePlatformAdmin}}})
+	courier := newToken(token.Claims{UserId: 6, Roles: []entity.RoleGrant{customer, {Role: entity.RoleCourier}}})
+	plainCustomer := newToken(token.Claims{UserId: 1, Roles: []entity.RoleGrant{customer}})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		token  string
+		want   outcome
+		// status, when set, is the status a request refused before the
+		// permission check answers.
+		status int
+	}{
+		{name: "owner edits their shop", method: http.MethodPatch, target: "/shops/1", token: owner, want: allowed},
+		{name: "owner deletes their shop", method: http.MethodDelete, target: "/shops/1", token: owner, want: allowed},
+		{name: "owner edits another shop", method: http.MethodPatch, target: "/shops/2", token: owner, want: forbidden},
+		{name: "owner adds to their menu", method: http.MethodPost, target: "/shops/menu_items/", body: `{"shop_id":1}`, token: owner, want: allowed},
+		{name: "owner adds to another menu", method: http.MethodPost, target: "/shops/menu_items/", body: `{"shop_id":2}`, token: owner, want: forbidden},
+		{name: "owner lists their api keys", method: http.MethodGet, target: "/shops/1/api_keys/", token: owner, want: allowed},
+		{name: "former owner edits the shop", method: http.MethodPatch, target: "/shops/1", token: formerOwner, want: forbidden},
+		{name: "former owner lists its api keys", method: http.MethodGet, target: "/shops/1/api_keys/", token: formerOwner, want: forbidden},
+
+		{name: "staff edits the menu", method: http.MethodPost, target: "/shops/menu_items/", body: `{"shop_id":1}`, token: staff, want: allowed},
+		{name: "staff edits the shop", method: http.MethodPatch, target: "/shops/1", token: staff, want: forbidden},
+		{name: "staff moves orders on", method: http.MethodPatch, target: "/orders/1/status", body: `{"status":"accepted"}`, token: staff, want: allowed},
+		{name: "customer moves orders on", method: http.MethodPatch, target: "/orders/1/status", body: `{"status":"accepted"}`, token: plainCustomer, want: forbidden},
+
+		{name: "admin edits any shop", method: http.MethodPatch, target: "/shops/2", token: admin, want: allowed},
+		{name: "admin invites admins", method: http.MethodPost, target: "/admin/invites", token: admin, want: allowed},
+		{name: "owner invites admins", method: http.MethodPost, target: "/admin/invites", token: owner, want: forbidden},
+
+		{name: "courier goes online", method: http.MethodPost, target: "/couriers/online", token: courier, want: allowed},
+		{name: "customer goes online", method: http.MethodPost, target: "/couriers/online", token: plainCustomer, want: forbidden},
+
+		{name: "invalid shop id", method: http.MethodPatch, target: "/shops/abc", token: owner, status: http.StatusBadRequest},
+		{name: "body without a shop", method: http.MethodPost, target: "/shops/menu_items/", token: owner, status: http.StatusBadRequest},
+		{name: "unknown menu item", method: http.MethodPatch, target: "/shops/menu_items/1", token: owner, status: http.StatusNotFound},
+		{name: "customer without the permission anywhere", method: http.MethodPatch, target: "/shops/abc", token: plainCustomer, want: forbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == "" {
+				body = "{}"
+			}
+			status, message := serveBody(handler, tt.method, tt.target, body, tt.token)
+			refused := status == http.StatusForbidden && strings.HasSuffix(message, missingPermissionMessage)
+
+			switch {
+			case tt.status != 0:
+				if status != tt.status {
+					t.Errorf("got %d %q, want %d", status, message, tt.status)
+				}
+			case tt.want == allowed:
+				if refused || status == http.StatusUnauthorized {
+					t.Errorf("got %d %q, want the request let through", status, message)
+				}
+			case tt.want == forbidden:
+				if !refused {
+					t.Errorf("got %d %q, want %d %s", status, message, http.StatusForbidden, missingPermissionMessage)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/pkg/ratelimit"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// rateLimit throttles the routes of group per client IP and per account,
+// with the limits configured in RateLimits. The account is the email of the
+// JSON body, or the user of the access token on authenticated routes.
+// Handlers behind it have to bind the body with ShouldBindBodyWith.
+//
+// The X-RateLimit-* headers describe the tightest bucket. A failing limiter
+// lets requests through rather than locking everybody out.
+func (server *Server) rateLimit(group string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var tightest *ratelimit.Result
+		allow := func(key string, limit ratelimit.Limit) {
+			result, err := server.limiter.Allow(ctx.Request.Context(), group+":"+key, limit)
+			if err != nil {
+				server.l.Error(err, "http - v1 - rateLimit")
+				return
+			}
+			if tightest == nil || tighter(result, *tightest) {
+				tightest = &result
+			}
+		}
+
+		if limit, ok := server.config.RateLimit(group, "ip"); ok {
+			allow("ip:"+ctx.ClientIP(), ratelimit.Limit{Burst: limit.Requests, Period: limit.Period})
+		}
+		if limit, ok := server.config.RateLimit(group, "email"); ok {
+			var body struct {
+				Email string `json:"email"`
+			}
+			if ctx.ShouldBindBodyWith(&body, binding.JSON) == nil && body.Email != "" {
+				email := strings.ToLower(strings.TrimSpace(body.Email))
+				allow("email:"+email, ratelimit.Limit{Burst: limit.Requests, Period: limit.Period})
+			}
+		}
+		if limit, ok := server.config.RateLimit(group, "user"); ok {
+			value, _ := ctx.Get(authorizationPayloadKey)
+			if payload, ok := value.(token.Payload); ok {
+				userId := strconv.FormatInt(payload.UserId, 10)
+				allow("user:"+userId, ratelimit.Limit{Burst: limit.Requests, Period: limit.Period})
+			}
+		}
+
+		if tightest == nil {
+			ctx.Next()
+			return
+		}
+
+		if !limited(ctx, *tightest) {
+			ctx.Next()
+		}
+	}
+}
+
+// limited sets the X-RateLimit-* headers of result and answers 429 when it
+// rejected the request.
+func limited(ctx *gin.Context, result ratelimit.Result) bool {
+	ctx.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	ctx.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+	if !result.Allowed {
+		errorResponse(ctx, apperror.TooMany("rate limit exceeded", result.RetryAfter))
+		return true
+	}
+	return false
+}
+
+// tighter reports whether a leaves less room than b: a rejection with a
+// longer wait, or fewer remaining requests.
+func tighter(a ratelimit.Result, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
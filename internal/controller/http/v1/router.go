@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/entity"
 	"github.com/zura-t/go_delivery_system/internal/usecase"
 	"github.com/zura-t/go_delivery_system/pkg/logger"
 
@@ -12,15 +13,35 @@ import (
 	_ "github.com/zura-t/go_delivery_system/docs"
 )
 
-func (server *Server) NewRouter(handler *gin.Engine, logger logger.Interface, userUsecase usecase.User, shopsUsecase usecase.Shop) {
+// routeGroups split the API by who may call it. Routes pick their group
+// instead of adding authentication themselves; shop scoped checks are added
+// per route on top of the authenticated group.
+type routeGroups struct {
+	public        *gin.RouterGroup
+	authenticated *gin.RouterGroup
+	admin         *gin.RouterGroup
+}
+
+func (server *Server) NewRouter(handler *gin.Engine, logger logger.Interface, userUsecase usecase.User, accountUsecase usecase.Account, mfaUsecase usecase.MFA, oidcUsecase usecase.OIDC, adminUsecase usecase.Admin, shopsUsecase usecase.Shop, ordersUsecase usecase.Order, trackingUsecase usecase.Tracking, cartUsecase usecase.Cart, deliveryUsecase usecase.Delivery, searchUsecase usecase.Search, downstreams []Downstream) {
 	handler.Use(gin.Logger())
 	handler.Use(gin.Recovery())
+	handler.Use(timeoutMiddleware(server.config))
 
 	handler.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// K8s probe
 	handler.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
 
+	authenticated := handler.Group("/", server.authMiddleware())
+	groups := routeGroups{
+		public:        handler.Group("/"),
+		authenticated: authenticated,
+		admin:         authenticated.Group("/", server.requirePermission(entity.PermissionAdminsManage, nil)),
+	}
+
+	newStatusRoutes(groups.public, downstreams)
+	newJWKSRoutes(groups.public, server.tokenMaker)
+
 	// handler.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	handler.GET("/ping", func(c *gin.Context) {
@@ -29,7 +50,17 @@ func (server *Server) NewRouter(handler *gin.Engine, logger logger.Interface, us
 		})
 	})
 	{
-		server.newUserRoutes(handler, userUsecase, logger)
-		server.newShopRoutes(handler, shopsUsecase, logger)
+		server.newUserRoutes(groups, userUsecase, adminUsecase, logger)
+		server.newAccountRoutes(groups, accountUsecase, logger)
+		server.newSessionRoutes(groups, server.sessionUsecase, logger)
+		server.newMFARoutes(groups, mfaUsecase, logger)
+		server.newOIDCRoutes(groups, oidcUsecase, logger)
+		server.newAdminRoutes(groups, adminUsecase, logger)
+		server.newShopRoutes(groups, shopsUsecase, logger)
+		server.newAPIKeyRoutes(groups, server.apiKeyUsecase, logger)
+		server.newOrderRoutes(groups, ordersUsecase, trackingUsecase, logger)
+		server.newCartRoutes(groups, cartUsecase, logger)
+		server.newCourierRoutes(groups, deliveryUsecase, logger)
+		server.newSearchRoutes(groups, searchUsecase, logger)
 	}
 }
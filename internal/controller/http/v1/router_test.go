@@ -0,0 +1,343 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/config"
+	v1 "github.com/zura-t/go_delivery_system/internal/controller/http/v1"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/internal/usecase/webapi"
+	"github.com/zura-t/go_delivery_system/pkg/hub"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+	"github.com/zura-t/go_delivery_system/pkg/ratelimit"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+type access string
+
+const (
+	// public routes answer without an access token.
+	public access = "public"
+	// authenticated routes need an access token of any user.
+	authenticated access = "authenticated"
+	// verified routes need an access token of a user with a verified email.
+	verified access = "verified"
+	// restricted routes need a role beyond customer, like shop owner, courier
+	// or platform admin.
+	restricted access = "restricted"
+)
+
+// routes lists the expected access of every route of the v1 router. A route
+// missing here fails TestRouteAccess, so new routes have to pick a side.
+var routes = []struct {
+	method string
+	path   string
+	access access
+}{
+	{http.MethodGet, "/swagger/*any", public},
+	{http.MethodGet, "/healthz", public},
+	{http.MethodGet, "/ping", public},
+	{http.MethodGet, "/status/downstreams", public},
+	{http.MethodGet, "/.well-known/jwks.json", public},
+
+	{http.MethodPost, "/users", public},
+	{http.MethodPost, "/login", public},
+	{http.MethodPost, "/login/mfa", public},
+	{http.MethodGet, "/oauth/:provider/login", public},
+	{http.MethodGet, "/oauth/:provider/callback", public},
+	{http.MethodPost, "/logout", public},
+	{http.MethodPost, "/renew_token", public},
+	{http.MethodGet, "/users/my_profile", authenticated},
+	{http.MethodPatch, "/users/admin", authenticated},
+	{http.MethodPost, "/users/admin/requests", verified},
+	{http.MethodPatch, "/users/", authenticated},
+	{http.MethodPatch, "/users/phone_number/", authenticated},
+	{http.MethodDelete, "/users/", authenticated},
+	{http.MethodPost, "/password/forgot", public},
+	{http.MethodPost, "/password/reset", public},
+	{http.MethodPatch, "/users/verify_email", public},
+	{http.MethodPost, "/users/verify_email", authenticated},
+	{http.MethodGet, "/users/sessions/", authenticated},
+	{http.MethodDelete, "/users/sessions/", authenticated},
+	{http.MethodDelete, "/users/sessions/:id", authenticated},
+	{http.MethodPost, "/users/mfa/", authenticated},
+	{http.MethodPost, "/users/mfa/confirm", authenticated},
+	{http.MethodDelete, "/users/mfa/", authenticated},
+	{http.MethodPost, "/users/mfa/recovery_codes", authenticated},
+
+	{http.MethodPost, "/admin/invites", restricted},
+	{http.MethodGet, "/admin/requests", restricted},
+	{http.MethodPost, "/admin/requests/:id/approve", restricted},
+	{http.MethodPost, "/admin/requests/:id/reject", restricted},
+	{http.MethodDelete, "/admin/users/:id", restricted},
+	{http.MethodPost, "/admin/couriers", restricted},
+	{http.MethodGet, "/admin/audit", restricted},
+
+	{http.MethodGet, "/shops/:id", public},
+	{http.MethodGet, "/shops/", public},
+	{http.MethodGet, "/shops/menu_items/list/:id", public},
+	{http.MethodGet, "/shops/menu_items/:id", public},
+	{http.MethodPost, "/shops/", verified},
+	{http.MethodGet, "/shops/admin", authenticated},
+	{http.MethodPatch, "/shops/:id", restricted},
+	{http.MethodDelete, "/shops/:id", restricted},
+	{http.MethodPost, "/shops/menu_items/", restricted},
+	{http.MethodPatch, "/shops/menu_items/:id", restricted},
+	{http.MethodDelete, "/shops/menu_items/:id", restricted},
+	{http.MethodPost, "/shops/:id/api_keys/", restricted},
+	{http.MethodGet, "/shops/:id/api_keys/", restricted},
+	{http.MethodDelete, "/shops/:id/api_keys/:key_id", restricted},
+
+	{http.MethodPost, "/orders/", verified},
+	{http.MethodGet, "/orders/", authenticated},
+	{http.MethodGet, "/orders/:id", authenticated},
+	{http.MethodGet, "/orders/:id/stream", authenticated},
+	{http.MethodPatch, "/orders/:id/cancel", authenticated},
+	{http.MethodPatch, "/orders/:id/status", restricted},
+
+	{http.MethodGet, "/cart/", authenticated},
+	{http.MethodDelete, "/cart/", authenticated},
+	{http.MethodPost, "/cart/items", authenticated},
+	{http.MethodPatch, "/cart/items/:id", authenticated},
+	{http.MethodDelete, "/cart/items/:id", authenticated},
+	{http.MethodPost, "/cart/checkout", verified},
+
+	{http.MethodGet, "/couriers/me", restricted},
+	{http.MethodPost, "/couriers/online", restricted},
+	{http.MethodPost, "/couriers/offline", restricted},
+	{http.MethodPost, "/couriers/location", restricted},
+	{http.MethodPost, "/couriers/deliveries/:id/accept", restricted},
+	{http.MethodPost, "/couriers/deliveries/:id/reject", restricted},
+	{http.MethodPatch, "/couriers/deliveries/:id/status", restricted},
+
+	{http.MethodGet, "/search", public},
+	{http.MethodGet, "/shops/nearby", public},
+}
+
+// The messages the auth and permission middlewares answer with; other 401s
+// and 403s come from handlers and usecases.
+const (
+	missingTokenMessage      = "authorization header is not provided"
+	missingPermissionMessage = "permission is required"
+)
+
+// requestTimeout ends streaming routes that accepted the request.
+const requestTimeout = time.Second
+
+// TestRouteAccess sends every route a request without a token and one with
+// the token of a user who is only a customer and hasn't verified their email:
+//   - public routes never ask for a token,
+//   - every other route answers 401 without a token,
+//   - authenticated routes accept the customer,
+//   - verified and restricted routes answer the customer 403.
+func TestRouteAccess(t *testing.T) {
+	handler, newToken := newTestRouter(t)
+	customerToken := newToken(token.Claims{
+		UserId: 1,
+		Email:  "customer@example.com",
+		Roles:  []entity.RoleGrant{{Role: entity.RoleCustomer}},
+	})
+
+	expected := make(map[string]access, len(routes))
+	for _, route := range routes {
+		expected[route.method+" "+route.path] = route.access
+	}
+
+	for _, info := range handler.Routes() {
+		name := info.Method + " " + info.Path
+		t.Run(name, func(t *testing.T) {
+			access, ok := expected[name]
+			if !ok {
+				t.Fatal("missing from the routes table")
+			}
+			err := checkRoute(handler, info.Method, info.Path, access, customerToken)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// shopOwner owns shop 1 in the shops service of the test router.
+const shopOwner = 2
+
+// newTestRouter builds the router the way app.Run does, on memory stores and
+// a shops and users service that answer 404 to everything but the shops
+// shopOwner owns, and returns it with a func issuing access tokens.
+func newTestRouter(t *testing.T) (*gin.Engine, func(claims token.Claims) string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Path == "/shops/admin" {
+			if r.URL.Query().Get("user_id") == fmt.Sprint(shopOwner) {
+				_, _ = w.Write([]byte(`[{"id":1}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"not found"}`))
+	}))
+	t.Cleanup(downstream.Close)
+
+	cfg := &config.Config{
+		UsersServiceAddress:       downstream.URL,
+		ShopsServiceAddress:       downstream.URL,
+		TokenSymmetricKey:         "12345678912345678912345678912201",
+		AccessTokenDuration:       time.Hour,
+		RefreshTokenDuration:      24 * time.Hour,
+		AdminInviteDuration:       time.Hour,
+		PasswordResetDuration:     time.Hour,
+		EmailVerificationDuration: time.Hour,
+		MFAIssuer:                 "test",
+		MFAChallengeDuration:      time.Minute,
+		OIDCStateDuration:         time.Minute,
+		RequestTimeout:            requestTimeout,
+		TrackingBufferSize:        8,
+		APIKeyRateLimit:           60,
+		APIKeyMaxRateLimit:        600,
+		LoginLockoutThreshold:     5,
+		LoginFailureWindow:        time.Minute,
+		LoginLockoutBase:          time.Minute,
+		LoginLockoutMax:           time.Hour,
+		SearchTimeout:             time.Second,
+		DeliveryRadiusKm:          5,
+	}
+	l := logger.New("error")
+
+	newClient := func(name string) *httpclient.HttpClient {
+		return httpclient.New(httpclient.Name(name), httpclient.Address(downstream.URL), httpclient.Retries(1))
+	}
+	usersClient := newClient("users")
+	shopsClient := newClient("shops")
+	userwebapi := webapi.NewUserWebAPI(cfg, usersClient)
+	shopwebapi := webapi.NewShopWebAPI(cfg, shopsClient)
+	orderwebapi := webapi.NewOrderMemoryAPI()
+
+	tokenMaker, err := token.NewJwtMaker(cfg.TokenSymmetricKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revocations := repo.NewRevocationMemoryStore()
+	loginAttempts := repo.NewLoginAttemptMemoryStore()
+	publisher := nopPublisher{}
+
+	sessionUseCase := usecase.NewSessionUseCase(cfg, tokenMaker, revocations, repo.NewSessionMemoryStore(), userwebapi, shopwebapi)
+	accountUseCase := usecase.NewAccountUseCase(cfg, userwebapi, sessionUseCase, tokenMaker, revocations, loginAttempts, publisher)
+	mfaUseCase := usecase.NewMFAUseCase(cfg, userwebapi, repo.NewMFAMemoryStore())
+	usersUseCase := usecase.NewUserUseCase(cfg, userwebapi, sessionUseCase, accountUseCase, mfaUseCase, loginAttempts, tokenMaker, revocations)
+	oidcUseCase := usecase.NewOIDCUseCase(cfg, map[string]usecase.IdentityProvider{}, userwebapi, usersUseCase, repo.NewOIDCMemoryStore())
+	adminUseCase := usecase.NewAdminUseCase(cfg, userwebapi, sessionUseCase, tokenMaker, revocations, repo.NewAdminMemoryStore())
+	shopsUseCase := usecase.NewShopUseCase(cfg, shopwebapi, publisher)
+	searchUseCase := usecase.NewSearchUseCase(cfg, repo.NewSearchMemoryIndex(), shopwebapi)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(cfg, repo.NewAPIKeyMemoryStore())
+	ordersUseCase := usecase.NewOrderUseCase(cfg, orderwebapi, shopwebapi, publisher)
+	cartUseCase := usecase.NewCartUseCase(cfg, repo.NewCartMemoryStore(), shopsUseCase, ordersUseCase, l)
+	deliveryUseCase := usecase.NewDeliveryUseCase(cfg, repo.NewCourierMemoryStore(), ordersUseCase, shopsUseCase, publisher)
+	trackingHub := hub.New(cfg.TrackingBufferSize)
+	t.Cleanup(trackingHub.Close)
+	trackingUseCase := usecase.NewTrackingUseCase(trackingHub, ordersUseCase)
+
+	server, err := v1.New(cfg, l, tokenMaker, usersUseCase, sessionUseCase, apiKeyUseCase, ratelimit.NewMemoryLimiter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := gin.New()
+	server.NewRouter(handler, l, usersUseCase, accountUseCase, mfaUseCase, oidcUseCase, adminUseCase, shopsUseCase, ordersUseCase, trackingUseCase, cartUseCase, deliveryUseCase, searchUseCase, []v1.Downstream{usersClient, shopsClient})
+
+	newToken := func(claims token.Claims) string {
+		t.Helper()
+
+		claims.Type = token.TokenTypeAccess
+		accessToken, _, err := tokenMaker.CreateToken(claims, cfg.AccessTokenDuration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return accessToken
+	}
+	return handler, newToken
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(ctx context.Context, routingKey string, event any) error {
+	return nil
+}
+
+func checkRoute(handler *gin.Engine, method string, path string, access access, customerToken string) error {
+	status, message := serve(handler, method, path, "")
+	switch access {
+	case public:
+		if status == http.StatusUnauthorized && message == missingTokenMessage {
+			return fmt.Errorf("public route asked for a token")
+		}
+		return nil
+	default:
+		if status != http.StatusUnauthorized {
+			return fmt.Errorf("got %d without a token, want %d", status, http.StatusUnauthorized)
+		}
+	}
+
+	status, message = serve(handler, method, path, customerToken)
+	rejected := status == http.StatusUnauthorized ||
+		(status == http.StatusForbidden && strings.HasSuffix(message, missingPermissionMessage))
+	switch access {
+	case authenticated:
+		if rejected {
+			return fmt.Errorf("got %d %q for a customer", status, message)
+		}
+	case verified, restricted:
+		if status != http.StatusForbidden {
+			return fmt.Errorf("got %d %q for a customer, want %d", status, message, http.StatusForbidden)
+		}
+	}
+	return nil
+}
+
+// serve fills path parameters with 1 and returns the status and error message
+// of the response.
+func serve(handler *gin.Engine, method string, path string, accessToken string) (int, string) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "1"
+		}
+	}
+	return serveBody(handler, method, strings.Join(segments, "/"), "{}", accessToken)
+}
+
+// serveBody sends body to target and returns the status and error message of
+// the response.
+func serveBody(handler *gin.Engine, method string, target string, body string, accessToken string) (int, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	var response struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response.Error
+}
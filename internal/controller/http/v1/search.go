@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type searchRoutes struct {
+	searchUsecase usecase.Search
+	logger        logger.Interface
+}
+
+func (server *Server) newSearchRoutes(groups routeGroups, searchUsecase usecase.Search, logger logger.Interface) {
+	routes := &searchRoutes{searchUsecase, logger}
+
+	groups.public.GET("/search", server.rateLimit("search"), routes.search)
+	groups.public.GET("/shops/nearby", server.rateLimit("search"), routes.nearbyShops)
+}
+
+type SearchRequest struct {
+	Q        string `form:"q"`
+	Type     string `form:"type" binding:"omitempty,oneof=shop menu_item"`
+	ShopId   int64  `form:"shop_id" binding:"min=0"`
+	MinPrice *int32 `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice *int32 `form:"max_price" binding:"omitempty,min=0"`
+	OpenNow  bool   `form:"open_now"`
+	Limit    int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Offset   int    `form:"offset,default=0" binding:"min=0"`
+}
+
+// @Summary     Search
+// @Description Full-text search over shops and menu items with typo tolerance, filters and facets
+// @ID          search
+// @Tags  	    search
+// @Accept      json
+// @Produce     json
+// @Param       q query string false "search text"
+// @Param       type query string false "shop or menu_item"
+// @Param       shop_id query int false "only this shop and its items"
+// @Param       min_price query int false "lowest menu item price"
+// @Param       max_price query int false "highest menu item price"
+// @Param       open_now query bool false "only shops open now and their items"
+// @Param       limit query string false "rows to return"
+// @Param       offset query string  false  "rows to skip"
+// @Success     200 {object} entity.SearchResult
+// @Failure     400 {object} response
+// @Failure     429 {object} response
+// @Failure     503 {object} response
+// @Router      /search [get]
+func (r *searchRoutes) search(ctx *gin.Context) {
+	var req SearchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	result, err := r.searchUsecase.Search(ctx.Request.Context(), &entity.SearchQuery{
+		Text:     req.Q,
+		Kind:     req.Type,
+		ShopId:   req.ShopId,
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+		OpenNow:  req.OpenNow,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+	})
+	if err != nil {
+		r.logger.Error(err, "http - v1 - search routes - search")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type NearbyShopsRequest struct {
+	Lat    *float64 `form:"lat" binding:"required,min=-90,max=90"`
+	Lng    *float64 `form:"lng" binding:"required,min=-180,max=180"`
+	Radius float64  `form:"radius" binding:"omitempty,gt=0,max=50"`
+	Limit  int      `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// @Summary     NearbyShops
+// @Description Shops within the radius that deliver to the location, nearest first
+// @ID          nearbyShops
+// @Tags  	    shops
+// @Accept      json
+// @Produce     json
+// @Param       lat query number true "latitude of the delivery location"
+// @Param       lng query number true "longitude of the delivery location"
+// @Param       radius query number false "kilometres to look within, the default delivery radius if left out"
+// @Param       limit query string false "rows to return"
+// @Success     200 {object} []entity.NearbyShop
+// @Failure     400 {object} response
+// @Failure     429 {object} response
+// @Failure     503 {object} response
+// @Router      /shops/nearby [get]
+func (r *searchRoutes) nearbyShops(ctx *gin.Context) {
+	var req NearbyShopsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	shops, err := r.searchUsecase.NearbyShops(ctx.Request.Context(), &entity.NearbyQuery{
+		Location: entity.Location{Lat: *req.Lat, Lng: *req.Lng},
+		RadiusKm: req.Radius,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		r.logger.Error(err, "http - v1 - search routes - nearbyShops")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shops)
+}
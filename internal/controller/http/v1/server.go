@@ -1,30 +1,31 @@
 package v1
 
 import (
-	"fmt"
-
 	"github.com/zura-t/go_delivery_system/config"
 	"github.com/zura-t/go_delivery_system/internal/usecase"
 	"github.com/zura-t/go_delivery_system/pkg/logger"
+	"github.com/zura-t/go_delivery_system/pkg/ratelimit"
 	"github.com/zura-t/go_delivery_system/token"
 )
 
 type Server struct {
-	config      *config.Config
-	tokenMaker  token.Maker
-	l           *logger.Logger
-	userUsecase *usecase.UserUseCase
+	config         *config.Config
+	tokenMaker     token.Maker
+	l              *logger.Logger
+	userUsecase    *usecase.UserUseCase
+	sessionUsecase usecase.Session
+	apiKeyUsecase  usecase.APIKey
+	limiter        ratelimit.Limiter
 }
 
-func New(cfg *config.Config, l *logger.Logger, userUsecase *usecase.UserUseCase) (*Server, error) {
-	tokenMaker, err := token.NewJwtMaker(cfg.TokenSymmetricKey)
-	if err != nil {
-		return nil, fmt.Errorf("can't create token maker: %w", err)
-	}
+func New(cfg *config.Config, l *logger.Logger, tokenMaker token.Maker, userUsecase *usecase.UserUseCase, sessionUsecase usecase.Session, apiKeyUsecase usecase.APIKey, limiter ratelimit.Limiter) (*Server, error) {
 	return &Server{
-		config:      cfg,
-		tokenMaker:  tokenMaker,
-		l:           l,
-		userUsecase: userUsecase,
+		config:         cfg,
+		tokenMaker:     tokenMaker,
+		l:              l,
+		userUsecase:    userUsecase,
+		sessionUsecase: sessionUsecase,
+		apiKeyUsecase:  apiKeyUsecase,
+		limiter:        limiter,
 	}, nil
 }
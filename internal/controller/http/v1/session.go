@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type sessionRoutes struct {
+	sessionUsecase usecase.Session
+	logger         logger.Interface
+}
+
+func (server *Server) newSessionRoutes(groups routeGroups, sessionUsecase usecase.Session, logger logger.Interface) {
+	routes := &sessionRoutes{sessionUsecase, logger}
+
+	sessionRoutes := groups.authenticated.Group("/users/sessions")
+	sessionRoutes.GET("/", routes.listSessions)
+	sessionRoutes.DELETE("/", routes.revokeOtherSessions)
+	sessionRoutes.DELETE("/:id", routes.revokeSession)
+}
+
+type SessionIdParam struct {
+	Id string `uri:"id" binding:"required"`
+}
+
+// @Summary     List sessions
+// @Description List the devices I'm logged in on, the current one marked
+// @ID          listSessions
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} []entity.Session
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/sessions/ [get]
+func (r *sessionRoutes) listSessions(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	sessions, err := r.sessionUsecase.ListSessions(ctx.Request.Context(), payload.UserId, payload.SessionID)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - session routes - listSessions")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// @Summary     Revoke other sessions
+// @Description Log out every device but this one
+// @ID          revokeOtherSessions
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} string
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/sessions/ [delete]
+func (r *sessionRoutes) revokeOtherSessions(ctx *gin.Context) {
+	payload := getJWTPayload(ctx)
+
+	res, err := r.sessionUsecase.RevokeOtherSessions(ctx.Request.Context(), payload.UserId, payload.SessionID)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - session routes - revokeOtherSessions")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// @Summary     Revoke session
+// @Description Log out one device. Its access tokens stop working right away.
+// @ID          revokeSession
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       id path string true "session id"
+// @Success     200 {object} string
+// @Failure     400 {object} response
+// @Failure     404 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/sessions/{id} [delete]
+func (r *sessionRoutes) revokeSession(ctx *gin.Context) {
+	var params SessionIdParam
+	if err := ctx.ShouldBindUri(&params); err != nil {
+		r.logger.Error(err, "http - v1 - session routes - revokeSession")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	res, err := r.sessionUsecase.RevokeSession(ctx.Request.Context(), payload.UserId, params.Id)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - session routes - revokeSession")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
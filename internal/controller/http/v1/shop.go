@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/zura-t/go_delivery_system/internal/entity"
 	"github.com/zura-t/go_delivery_system/internal/usecase"
 	"github.com/zura-t/go_delivery_system/pkg/logger"
@@ -15,34 +16,53 @@ type shopRoutes struct {
 	logger      logger.Interface
 }
 
-func (server *Server) newShopRoutes(handler *gin.Engine, shopUsecase usecase.Shop, logger logger.Interface) {
+func (server *Server) newShopRoutes(groups routeGroups, shopUsecase usecase.Shop, logger logger.Interface) {
 	routes := &shopRoutes{shopUsecase, logger}
 
-	handler.Group("/").Use(authMiddleware(server.tokenMaker))
+	publicRoutes := groups.public.Group("/shops")
+	publicRoutes.GET("/:id", routes.getShop)
+	publicRoutes.GET("/", routes.getShops)
+	publicRoutes.GET("/menu_items/list/:id", routes.getMenuItems)
+	publicRoutes.GET("/menu_items/:id", routes.getMenuItem)
 
-	shopRoutes := handler.Group("/shops")
-	menuItemRoutes := shopRoutes.Group("/menu_items")
+	shopRoutes := groups.authenticated.Group("/shops")
+	shopRoutes.POST("/", requireVerifiedEmail(), server.requirePermission(entity.PermissionShopCreate, nil), routes.createShop)
+	shopRoutes.GET("/admin", routes.getShopsAdmin)
+	shopRoutes.PATCH("/:id", server.requirePermission(entity.PermissionShopWrite, shopParam("id")), routes.updateShop)
+	shopRoutes.DELETE("/:id", server.requirePermission(entity.PermissionShopDelete, shopParam("id")), routes.deleteShop)
 
-	shopRoutes.POST("/", routes.createShop).Use(server.rolesMiddleware())
-	shopRoutes.GET("/:id", routes.getShop)
-	shopRoutes.GET("/", routes.getShops)
-	shopRoutes.GET("/admin", routes.getShopsAdmin).Use(server.rolesMiddleware())
-	shopRoutes.PATCH("/:id", routes.updateShop).Use(server.rolesMiddleware())
-	shopRoutes.DELETE("/:id", routes.deleteShop).Use(server.rolesMiddleware())
-
-	menuItemRoutes.POST("/", routes.createMenuItems).Use(server.rolesMiddleware())
-	menuItemRoutes.GET("/list/:id", routes.getMenuItems)
-	menuItemRoutes.PATCH("/:id", routes.updateMenuItem).Use(server.rolesMiddleware())
-	menuItemRoutes.GET("/:id", routes.getMenuItem)
-	menuItemRoutes.DELETE("/:id", routes.deleteMenuItem).Use(server.rolesMiddleware())
+	menuItemRoutes := shopRoutes.Group("/menu_items")
+	menuItemRoutes.POST("/", server.requirePermission(entity.PermissionMenuWrite, shopBody), routes.createMenuItems)
+	menuItemRoutes.PATCH("/:id", server.requirePermission(entity.PermissionMenuWrite, routes.menuItemShop("id")), routes.updateMenuItem)
+	menuItemRoutes.DELETE("/:id", server.requirePermission(entity.PermissionMenuWrite, routes.menuItemShop("id")), routes.deleteMenuItem)
 }
 
 type CreateShopRequest struct {
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description" example:""`
-	OpenTime    time.Time `json:"open_time" binding:"required" example:""`
-	CloseTime   time.Time `json:"close_time" binding:"required" example:""`
-	IsClosed    bool      `json:"is_closed"`
+	Name         string               `json:"name" binding:"required"`
+	Description  string               `json:"description" example:""`
+	OpenTime     time.Time            `json:"open_time" binding:"required" example:""`
+	CloseTime    time.Time            `json:"close_time" binding:"required" example:""`
+	IsClosed     bool                 `json:"is_closed"`
+	Location     *LocationRequest     `json:"location"`
+	DeliveryZone *DeliveryZoneRequest `json:"delivery_zone"`
+}
+
+// DeliveryZoneRequest is a radius around the shop or a polygon, not both.
+type DeliveryZoneRequest struct {
+	RadiusKm float64           `json:"radius_km" binding:"omitempty,gt=0,max=100,excluded_with=Polygon"`
+	Polygon  []LocationRequest `json:"polygon" binding:"omitempty,min=3,max=200,dive"`
+}
+
+func (req *DeliveryZoneRequest) deliveryZone() *entity.DeliveryZone {
+	if req == nil {
+		return nil
+	}
+
+	zone := &entity.DeliveryZone{RadiusKm: req.RadiusKm}
+	for i := range req.Polygon {
+		zone.Polygon = append(zone.Polygon, req.Polygon[i].location())
+	}
+	return zone
 }
 
 // @Summary     Create Shop
@@ -61,27 +81,35 @@ func (r *shopRoutes) createShop(ctx *gin.Context) {
 	var req CreateShopRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.logger.Error(err, "http - v1 - user routes - createUser")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	payload := getJWTPayload(ctx)
 
-	shop, st, err := r.shopUsecase.CreateShop(&entity.CreateShop{
+	shop := &entity.CreateShop{
 		Name:        req.Name,
 		Description: req.Description,
 		OpenTime:    req.OpenTime,
 		CloseTime:   req.CloseTime,
 		UserId:      payload.UserId,
 		IsClosed:    req.IsClosed,
-	})
+	}
+	if req.Location != nil {
+		shop.Location = req.Location.location()
+	}
+	if zone := req.DeliveryZone.deliveryZone(); zone != nil {
+		shop.DeliveryZone = *zone
+	}
+
+	created, err := r.shopUsecase.CreateShop(ctx.Request.Context(), shop)
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - createShop")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, shop)
+	ctx.JSON(http.StatusOK, created)
 }
 
 type IdParam struct {
@@ -104,15 +132,15 @@ func (r *shopRoutes) getShop(ctx *gin.Context) {
 	var req IdParam
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - getShop")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	shop, st, err := r.shopUsecase.GetShop(req.Id)
+	shop, err := r.shopUsecase.GetShop(ctx.Request.Context(), req.Id)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - getMyProfile")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -140,15 +168,15 @@ type GetShopsRequest struct {
 func (r *shopRoutes) getShops(ctx *gin.Context) {
 	var req GetShopsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	shops, st, err := r.shopUsecase.GetShops(req.Limit, req.Offset)
+	shops, err := r.shopUsecase.GetShops(ctx.Request.Context(), req.Limit, req.Offset)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - getShops")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -169,11 +197,11 @@ func (r *shopRoutes) getShops(ctx *gin.Context) {
 func (r *shopRoutes) getShopsAdmin(ctx *gin.Context) {
 	payload := getJWTPayload(ctx)
 
-	shops, st, err := r.shopUsecase.GetShopsAdmin(payload.UserId)
+	shops, err := r.shopUsecase.GetShopsAdmin(ctx.Request.Context(), payload.UserId)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - getShopsAdmin")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -186,6 +214,9 @@ type UpdateShopRequest struct {
 	OpenTime    time.Time `json:"open_time"`
 	CloseTime   time.Time `json:"close_time"`
 	IsClosed    bool      `json:"is_closed"`
+	// Location and DeliveryZone are kept when left out.
+	Location     *LocationRequest     `json:"location"`
+	DeliveryZone *DeliveryZoneRequest `json:"delivery_zone"`
 }
 
 // @Summary     Update Shop
@@ -205,31 +236,38 @@ func (r *shopRoutes) updateShop(ctx *gin.Context) {
 	var req UpdateShopRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - updateShop")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	var param IdParam
 	if err := ctx.ShouldBindUri(&param); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - updateShop")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	payload := getJWTPayload(ctx)
 
-	data, st, err := r.shopUsecase.UpdateShop(param.Id, &entity.UpdateShopInfo{
-		Name:        req.Name,
-		Description: req.Description,
-		OpenTime:    req.OpenTime,
-		CloseTime:   req.CloseTime,
-		IsClosed:    req.IsClosed,
-		UserId:      payload.UserId,
-	})
+	update := &entity.UpdateShopInfo{
+		Name:         req.Name,
+		Description:  req.Description,
+		OpenTime:     req.OpenTime,
+		CloseTime:    req.CloseTime,
+		IsClosed:     req.IsClosed,
+		DeliveryZone: req.DeliveryZone.deliveryZone(),
+		UserId:       payload.UserId,
+	}
+	if req.Location != nil {
+		location := req.Location.location()
+		update.Location = &location
+	}
+
+	data, err := r.shopUsecase.UpdateShop(ctx.Request.Context(), param.Id, update)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - updateShop")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -262,9 +300,9 @@ type CreateMenuItemsRequest struct {
 // @Router      /shops/menu_items [post]
 func (r *shopRoutes) createMenuItems(ctx *gin.Context) {
 	var req CreateMenuItemsRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - createMenuItems")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
@@ -279,7 +317,7 @@ func (r *shopRoutes) createMenuItems(ctx *gin.Context) {
 		}
 	}
 
-	menuCreated, st, err := r.shopUsecase.CreateMenu(&entity.CreateMenuItem{
+	menuCreated, err := r.shopUsecase.CreateMenu(ctx.Request.Context(), &entity.CreateMenuItem{
 		MenuItems: menuItems,
 		ShopId:    req.ShopId,
 		UserId:    payload.UserId,
@@ -287,7 +325,7 @@ func (r *shopRoutes) createMenuItems(ctx *gin.Context) {
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - createMenuItems")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -314,15 +352,15 @@ func (r *shopRoutes) getMenuItems(ctx *gin.Context) {
 	var req GetMenuRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - getMenuItems")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	menuItems, st, err := r.shopUsecase.GetMenu(req.ShopId)
+	menuItems, err := r.shopUsecase.GetMenu(ctx.Request.Context(), req.ShopId)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - getMenuItems")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -352,20 +390,20 @@ func (r *shopRoutes) updateMenuItem(ctx *gin.Context) {
 	var req UpdateMenuItemRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - updateMenuItems")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	var params IdParam
 	if err := ctx.ShouldBindUri(&params); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - updateMenuItems")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	payload := getJWTPayload(ctx)
 
-	menuItems, st, err := r.shopUsecase.UpdateMenuItem(params.Id, &entity.UpdateMenuItem{
+	menuItems, err := r.shopUsecase.UpdateMenuItem(ctx.Request.Context(), params.Id, &entity.UpdateMenuItem{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
@@ -374,7 +412,7 @@ func (r *shopRoutes) updateMenuItem(ctx *gin.Context) {
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - updateMenuItems")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -397,15 +435,15 @@ func (r *shopRoutes) getMenuItem(ctx *gin.Context) {
 	var req IdParam
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - getMenuItem")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	menuItem, st, err := r.shopUsecase.GetMenuItem(req.Id)
+	menuItem, err := r.shopUsecase.GetMenuItem(ctx.Request.Context(), req.Id)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - getMenuItem")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -432,17 +470,17 @@ func (r *shopRoutes) deleteShop(ctx *gin.Context) {
 	var req IdParam
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - deleteMenuItems")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	payload := getJWTPayload(ctx)
 
-	res, st, err := r.shopUsecase.DeleteShop(req.Id, payload.UserId)
+	res, err := r.shopUsecase.DeleteShop(ctx.Request.Context(), req.Id, payload.UserId)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - deleteMenuItems")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -465,17 +503,17 @@ func (r *shopRoutes) deleteMenuItem(ctx *gin.Context) {
 	var req IdParam
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - deleteMenuItems")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
 	payload := getJWTPayload(ctx)
 
-	res, st, err := r.shopUsecase.DeleteMenuItem(req.Id, payload.UserId)
+	res, err := r.shopUsecase.DeleteMenuItem(ctx.Request.Context(), req.Id, payload.UserId)
 
 	if err != nil {
 		r.logger.Error(err, "http - v1 - shop routes - deleteMenuItems")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
+)
+
+// Downstream is a client of a downstream service that reports its breaker state.
+type Downstream interface {
+	Status() httpclient.Status
+}
+
+type statusRoutes struct {
+	downstreams []Downstream
+}
+
+func newStatusRoutes(handler *gin.RouterGroup, downstreams []Downstream) {
+	routes := &statusRoutes{downstreams}
+
+	handler.GET("/status/downstreams", routes.getDownstreams)
+}
+
+// @Summary     Downstream status
+// @Description Circuit breaker state of every downstream service
+// @ID          getDownstreams
+// @Tags  	    status
+// @Produce     json
+// @Success     200 {object} []httpclient.Status
+// @Router      /status/downstreams [get]
+func (r *statusRoutes) getDownstreams(ctx *gin.Context) {
+	statuses := make([]httpclient.Status, len(r.downstreams))
+	for i, downstream := range r.downstreams {
+		statuses[i] = downstream.Status()
+	}
+
+	ctx.JSON(http.StatusOK, statuses)
+}
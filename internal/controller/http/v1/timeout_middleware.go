@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/config"
+)
+
+// streamingRoutes stay open for as long as the client listens and are never
+// bounded by a request deadline.
+var streamingRoutes = map[string]bool{
+	"GET /orders/:id/stream": true,
+}
+
+// timeoutMiddleware bounds the request context with the deadline configured
+// for the matched route, so downstream calls are cancelled once it passes.
+func timeoutMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		timeout := cfg.RouteTimeout(ctx.Request.Method, ctx.FullPath())
+		if timeout <= 0 || streamingRoutes[ctx.Request.Method+" "+ctx.FullPath()] {
+			ctx.Next()
+			return
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(timeoutCtx)
+		ctx.Next()
+	}
+}
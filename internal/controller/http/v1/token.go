@@ -1,69 +1,67 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
 	"github.com/zura-t/go_delivery_system/token"
 )
 
-type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
-}
-
 // @Summary     RenewAccessToken
-// @Description renewAccessToken
+// @Description Exchange the refresh token cookie for a new token pair. Refresh
+// @Description tokens are single-use; presenting one twice revokes the session.
 // @ID          renewAccessToken
 // @Tags  	    users
 // @Accept      json
 // @Produce     json
-// @Success     200 {object} renewAccessTokenResponse
-// @Failure     400 {object} response
+// @Success     200 {object} entity.SessionTokens
+// @Failure     401 {object} response
 // @Failure     500 {object} response
 // @Router      /renew_token [post]
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	refreshToken, err := ctx.Cookie("refresh_token")
 	if err != nil {
 		server.l.Error(err, "http - v1 - renewAccessToken - context cookie")
-		errorResponse(ctx, http.StatusUnauthorized, "can't renew the token")
+		errorResponse(ctx, apperror.Unauthorized("can't renew the token"))
 		return
 	}
 
-	refreshPayload, err := server.tokenMaker.VerifyToken(refreshToken)
+	tokens, err := server.sessionUsecase.RefreshSession(ctx.Request.Context(), refreshToken, sessionClient(ctx))
 	if err != nil {
-		server.l.Error(err, "http - v1 - renewAccessToken - server.tokenMaker.VerifyToken")
-		errorResponse(ctx, http.StatusUnauthorized, err.Error())
+		server.l.Error(err, "http - v1 - renewAccessToken - server.sessionUsecase.RefreshSession")
+		ctx.SetCookie("refresh_token", "", -1, "/", "localhost", false, true)
+		errorResponse(ctx, err)
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(refreshPayload.UserId, refreshPayload.IsAdmin, refreshPayload.Email, server.config.AccessTokenDuration)
-	if err != nil {
-		server.l.Error(err, "http - v1 - renewAccessToken - server.tokenMaker.CreateToken")
-		errorResponse(ctx, http.StatusInternalServerError, "can't create new token")
-		return
-	}
+	ctx.SetCookie("refresh_token", tokens.RefreshToken, int(time.Until(tokens.RefreshTokenExpiresAt).Seconds()), "/", "localhost", false, true)
+	ctx.JSON(http.StatusOK, tokens)
+}
 
-	rsp := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+// sessionClient describes the device of the request for the session list.
+func sessionClient(ctx *gin.Context) *entity.SessionClient {
+	return &entity.SessionClient{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
 	}
-	ctx.JSON(http.StatusOK, rsp)
 }
 
 func getJWTPayload(ctx *gin.Context) token.Payload {
 	var payload token.Payload
 	payloadData, exists := ctx.Get(authorizationPayloadKey)
 	if !exists {
-		errorResponse(ctx, http.StatusInternalServerError, "couldn't get payload from authtoken")
+		errorResponse(ctx, apperror.Internal(errors.New("couldn't get payload from authtoken")))
 		return token.Payload{}
 	}
 	data, ok := payloadData.(token.Payload)
 	if ok {
 		payload = data
 	} else {
-		errorResponse(ctx, http.StatusInternalServerError, "couldn't get payload from authtoken")
+		errorResponse(ctx, apperror.Internal(errors.New("couldn't get payload from authtoken")))
 		return token.Payload{}
 	}
 	return payload
@@ -5,30 +5,34 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
 	"github.com/zura-t/go_delivery_system/internal/entity"
 	"github.com/zura-t/go_delivery_system/internal/usecase"
 	"github.com/zura-t/go_delivery_system/pkg/logger"
 )
 
 type userRoutes struct {
-	userUsecase usecase.User
-	logger      logger.Interface
+	userUsecase  usecase.User
+	adminUsecase usecase.Admin
+	logger       logger.Interface
 }
 
-func (server *Server) newUserRoutes(handler *gin.Engine, userUsecase usecase.User, logger logger.Interface) {
-	routes := &userRoutes{userUsecase, logger}
+func (server *Server) newUserRoutes(groups routeGroups, userUsecase usecase.User, adminUsecase usecase.Admin, logger logger.Interface) {
+	routes := &userRoutes{userUsecase, adminUsecase, logger}
 
-	handler.POST("/users", routes.createUser)
-	handler.POST("/login", routes.loginUser)
-	handler.POST("/logout", routes.logout)
-	handler.POST("/renew_token", server.renewAccessToken)
+	groups.public.POST("/users", server.rateLimit("signup"), routes.createUser)
+	groups.public.POST("/login", server.rateLimit("login"), routes.loginUser)
+	groups.public.POST("/login/mfa", server.rateLimit("login_mfa"), routes.loginMFA)
+	groups.public.POST("/logout", server.logout)
+	groups.public.POST("/renew_token", server.rateLimit("renew_token"), server.renewAccessToken)
 
-	authRoutes := handler.Use(authMiddleware(server.tokenMaker))
-	authRoutes.GET("/users/my_profile", routes.getMyProfile)
-	authRoutes.PATCH("/users/admin", routes.addAdminRole)
-	authRoutes.PATCH("/users/", routes.updateUser)
-	authRoutes.PATCH("/users/phone_number/", routes.addPhone)
-	authRoutes.DELETE("/users/", routes.deleteUser)
+	groups.authenticated.GET("/users/my_profile", routes.getMyProfile)
+	groups.authenticated.PATCH("/users/admin", routes.addAdminRole)
+	groups.authenticated.POST("/users/admin/requests", requireVerifiedEmail(), routes.requestAdminRole)
+	groups.authenticated.PATCH("/users/", routes.updateUser)
+	groups.authenticated.PATCH("/users/phone_number/", routes.addPhone)
+	groups.authenticated.DELETE("/users/", routes.deleteUser)
 }
 
 type CreateUserRequest struct {
@@ -50,20 +54,20 @@ type CreateUserRequest struct {
 // @Router      /users/ [post]
 func (r *userRoutes) createUser(ctx *gin.Context) {
 	var req CreateUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		r.logger.Error(err, "http - v1 - user routes - createUser")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	user, st, err := r.userUsecase.CreateUser(&entity.UserRegister{
+	user, err := r.userUsecase.CreateUser(ctx.Request.Context(), &entity.UserRegister{
 		Email:    req.Email,
 		Password: req.Password,
 		Name:     req.Name,
 	})
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - createUser")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -91,21 +95,63 @@ type LoginUserResponse struct {
 // @Produce     json
 // @Param       request body LoginUserRequest true "log in"
 // @Success     200 {object} entity.UserLoginResponse
+// @Success     202 {object} entity.MFAChallenge
 // @Failure     400 {object} response
 // @Failure     500 {object} response
 // @Router      /login/ [post]
 func (r *userRoutes) loginUser(ctx *gin.Context) {
 	var req LoginUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		r.logger.Error(err, "http - v1 - user routes - loginUser")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	user, st, err := r.userUsecase.LoginUser(&entity.UserLogin{Email: req.Email, Password: req.Password})
+	user, challenge, err := r.userUsecase.LoginUser(ctx.Request.Context(), &entity.UserLogin{Email: req.Email, Password: req.Password}, sessionClient(ctx))
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - loginUser")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
+		return
+	}
+	if challenge != nil {
+		ctx.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	ctx.SetCookie("refresh_token", user.RefreshToken, int(time.Until(user.RefreshTokenExpiresAt).Seconds()), "/", "localhost", false, true)
+	ctx.JSON(http.StatusOK, user)
+}
+
+type LoginMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// @Summary     Login MFA
+// @Description Finish a login of a user with two-factor authentication with a code of their app or a recovery code
+// @ID          loginMFA
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body LoginMFARequest true "challenge"
+// @Success     200 {object} entity.UserLoginResponse
+// @Failure     400 {object} response
+// @Failure     401 {object} response
+// @Failure     429 {object} response
+// @Failure     500 {object} response
+// @Router      /login/mfa [post]
+func (r *userRoutes) loginMFA(ctx *gin.Context) {
+	var req LoginMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - user routes - loginMFA")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	user, err := r.userUsecase.LoginMFA(ctx.Request.Context(), req.ChallengeToken, req.Code, sessionClient(ctx))
+	if err != nil {
+		r.logger.Error(err, "http - v1 - user routes - loginMFA")
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -126,37 +172,89 @@ func (r *userRoutes) loginUser(ctx *gin.Context) {
 // @Router      /users/my_profile [get]
 func (r *userRoutes) getMyProfile(ctx *gin.Context) {
 	payload := getJWTPayload(ctx)
-	user, st, err := r.userUsecase.GetMyProfile(payload.UserId)
+	user, err := r.userUsecase.GetMyProfile(ctx.Request.Context(), payload.UserId)
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - getMyProfile")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, user)
 }
 
+type AddAdminRoleRequest struct {
+	Invite string `json:"invite" binding:"required"`
+}
+
 // @Summary     Add adminRole
-// @Description addAdminRole
+// @Description Redeem an admin invite issued to me. Renew the token to use the new role.
 // @ID          addAdminRole
 // @Tags  	    users
 // @Accept      json
 // @Produce     json
+// @Param       request body AddAdminRoleRequest true "invite"
 // @Success     200 {object} string
 // @Failure     400 {object} response
+// @Failure     403 {object} response
+// @Failure     409 {object} response
 // @Failure     500 {object} response
 // @Security 		BearerAuth
 // @Router      /users/admin [patch]
 func (r *userRoutes) addAdminRole(ctx *gin.Context) {
+	var req AddAdminRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - user routes - addAdminRole")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
 	payload := getJWTPayload(ctx)
 
-	user, st, err := r.userUsecase.AddAdminRole(payload.UserId)
+	resp, err := r.adminUsecase.RedeemInvite(ctx.Request.Context(), payload.UserId, req.Invite)
 	if err != nil {
-		errorResponse(ctx, st, err.Error())
+		r.logger.Error(err, "http - v1 - user routes - addAdminRole")
+		errorResponse(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, user)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type RequestAdminRoleRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// @Summary     Request adminRole
+// @Description Ask platform admins for the admin role
+// @ID          requestAdminRole
+// @Tags  	    users
+// @Accept      json
+// @Produce     json
+// @Param       request body RequestAdminRoleRequest true "request"
+// @Success     200 {object} entity.AdminRequest
+// @Failure     400 {object} response
+// @Failure     409 {object} response
+// @Failure     500 {object} response
+// @Security 		BearerAuth
+// @Router      /users/admin/requests [post]
+func (r *userRoutes) requestAdminRole(ctx *gin.Context) {
+	var req RequestAdminRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		r.logger.Error(err, "http - v1 - user routes - requestAdminRole")
+		errorResponse(ctx, bindError(err))
+		return
+	}
+
+	payload := getJWTPayload(ctx)
+
+	adminRequest, err := r.adminUsecase.RequestAdmin(ctx.Request.Context(), payload.UserId, req.Reason)
+	if err != nil {
+		r.logger.Error(err, "http - v1 - user routes - requestAdminRole")
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, adminRequest)
 }
 
 type UpdateUserRequest struct {
@@ -180,16 +278,16 @@ func (r *userRoutes) updateUser(ctx *gin.Context) {
 	var req UpdateUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.logger.Error(err, "http - v1 - user routes - updateUser")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	user, st, err := r.userUsecase.UpdateUser(payload.UserId, &entity.UserUpdate{
+	user, err := r.userUsecase.UpdateUser(ctx.Request.Context(), payload.UserId, &entity.UserUpdate{
 		Name: req.Name,
 	})
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - updateUser")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -217,16 +315,16 @@ func (r *userRoutes) addPhone(ctx *gin.Context) {
 	var req AddPhoneRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		r.logger.Error(err, "http - v1 - user routes - addPhone")
-		errorResponse(ctx, http.StatusBadRequest, err.Error())
+		errorResponse(ctx, bindError(err))
 		return
 	}
 
-	resp, st, err := r.userUsecase.AddPhone(payload.UserId, &entity.UserAddPhone{
+	resp, err := r.userUsecase.AddPhone(ctx.Request.Context(), payload.UserId, &entity.UserAddPhone{
 		Phone: req.Phone,
 	})
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - addPhone")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -246,10 +344,10 @@ func (r *userRoutes) addPhone(ctx *gin.Context) {
 // @Router      /users/ [delete]
 func (r *userRoutes) deleteUser(ctx *gin.Context) {
 	payload := getJWTPayload(ctx)
-	res, st, err := r.userUsecase.DeleteUser(payload.UserId)
+	res, err := r.userUsecase.DeleteUser(ctx.Request.Context(), payload.UserId)
 	if err != nil {
 		r.logger.Error(err, "http - v1 - user routes - deleteUser")
-		errorResponse(ctx, st, err.Error())
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -257,7 +355,7 @@ func (r *userRoutes) deleteUser(ctx *gin.Context) {
 }
 
 // @Summary     Logout
-// @Description logout
+// @Description Revoke the session of the refresh token cookie and clear it
 // @ID          logout
 // @Tags  	    users
 // @Accept      json
@@ -266,7 +364,17 @@ func (r *userRoutes) deleteUser(ctx *gin.Context) {
 // @Failure     400 {object} response
 // @Failure     500 {object} response
 // @Router      /logout [post]
-func (r *userRoutes) logout(ctx *gin.Context) {
+func (server *Server) logout(ctx *gin.Context) {
+	refreshToken, err := ctx.Cookie("refresh_token")
+	if err == nil {
+		err = server.sessionUsecase.EndSession(ctx.Request.Context(), refreshToken)
+		if err != nil && !apperror.Is(err, apperror.KindUnauthorized) {
+			server.l.Error(err, "http - v1 - logout - server.sessionUsecase.EndSession")
+			errorResponse(ctx, err)
+			return
+		}
+	}
+
 	ctx.SetCookie("refresh_token", "", -1, "/", "localhost", false, true)
 	ctx.JSON(http.StatusOK, "logged out")
 }
@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+type AdminRequestStatus string
+
+const (
+	AdminRequestPending  AdminRequestStatus = "pending"
+	AdminRequestApproved AdminRequestStatus = "approved"
+	AdminRequestRejected AdminRequestStatus = "rejected"
+)
+
+// AdminRequest is a user asking platform admins for the admin role. Users
+// have at most one request; a new one replaces a reviewed one.
+type AdminRequest struct {
+	UserId     int64              `json:"user_id"`
+	Reason     string             `json:"reason"`
+	Status     AdminRequestStatus `json:"status"`
+	ReviewedBy int64              `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	ReviewedAt *time.Time         `json:"reviewed_at,omitempty"`
+}
+
+// AdminInvite lets UserId become an admin until ExpiresAt. Token is signed by
+// the gateway and can be redeemed once.
+type AdminInvite struct {
+	Token     string    `json:"token"`
+	UserId    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AuditAction string
+
+const (
+	AuditAdminInvited   AuditAction = "admin.invited"
+	AuditAdminRequested AuditAction = "admin.requested"
+	AuditAdminGranted   AuditAction = "admin.granted"
+	AuditAdminRejected  AuditAction = "admin.rejected"
+	AuditAdminRevoked   AuditAction = "admin.revoked"
+	AuditCourierGranted AuditAction = "courier.granted"
+)
+
+// AuditEntry records ActorId doing Action to UserId.
+type AuditEntry struct {
+	Action     AuditAction `json:"action"`
+	ActorId    int64       `json:"actor_id"`
+	UserId     int64       `json:"user_id"`
+	Details    string      `json:"details,omitempty"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
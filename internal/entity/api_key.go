@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// APIKeyScopes are the permissions API keys can be given, all limited to the
+// shop of the key.
+var APIKeyScopes = []Permission{PermissionShopWrite, PermissionMenuWrite, PermissionOrdersManage}
+
+// APIKey lets a partner, such as a POS integration, act for a shop with the
+// permissions in Scopes. Only a hash of its secret is kept.
+type APIKey struct {
+	ID     string       `json:"id"`
+	Name   string       `json:"name"`
+	ShopId int64        `json:"shop_id"`
+	Scopes []Permission `json:"scopes"`
+	// RateLimit is how many requests a minute the key may make.
+	RateLimit int        `json:"rate_limit"`
+	CreatedBy int64      `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateAPIKey struct {
+	Name      string
+	Scopes    []Permission
+	RateLimit int
+	ExpiresAt *time.Time
+}
+
+// CreatedAPIKey carries the key itself, which is shown only once.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
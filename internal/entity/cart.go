@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Cart holds the items a user is about to order. All items belong to ShopId.
+// TotalPrice leaves unavailable items out.
+type Cart struct {
+	UserId     int64      `json:"user_id"`
+	ShopId     int64      `json:"shop_id"`
+	Items      []CartItem `json:"items"`
+	TotalPrice int32      `json:"total_price"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CartItem is a line of a cart. Unavailable lines left the shop's menu; they
+// keep the name and price they were last seen with and have to be removed
+// before checkout.
+type CartItem struct {
+	OrderItem
+	Unavailable bool `json:"unavailable,omitempty"`
+}
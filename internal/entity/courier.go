@@ -0,0 +1,147 @@
+package entity
+
+import (
+	"math"
+	"time"
+)
+
+const _earthRadiusKm = 6371.0
+
+// Location is a point in WGS84 degrees.
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// IsZero reports whether the location was never set.
+func (l Location) IsZero() bool {
+	return l.Lat == 0 && l.Lng == 0
+}
+
+// DistanceTo returns the great-circle distance to other in kilometres using
+// the haversine formula.
+func (l Location) DistanceTo(other Location) float64 {
+	lat1 := l.Lat * math.Pi / 180
+	lat2 := other.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (other.Lng - l.Lng) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * _earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// InPolygon reports whether l lies inside polygon by casting a ray along its
+// latitude. Points on the boundary are inside; polygons of fewer than three
+// vertices contain nothing. Degrees are treated as a plane, which is close
+// enough at city scale; polygons crossing the antimeridian aren't supported.
+func (l Location) InPolygon(polygon []Location) bool {
+	if len(polygon) < 3 {
+		return false
+	}
+
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if l.onSegment(a, b) {
+			return true
+		}
+		if (a.Lat > l.Lat) != (b.Lat > l.Lat) &&
+			l.Lng < (b.Lng-a.Lng)*(l.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// onSegment reports whether l lies on the segment from a to b, give or take
+// the rounding of the degrees.
+func (l Location) onSegment(a Location, b Location) bool {
+	const epsilon = 1e-12
+
+	cross := (b.Lng-a.Lng)*(l.Lat-a.Lat) - (b.Lat-a.Lat)*(l.Lng-a.Lng)
+	if math.Abs(cross) > epsilon {
+		return false
+	}
+	return math.Min(a.Lat, b.Lat) <= l.Lat && l.Lat <= math.Max(a.Lat, b.Lat) &&
+		math.Min(a.Lng, b.Lng) <= l.Lng && l.Lng <= math.Max(a.Lng, b.Lng)
+}
+
+type CourierStatus string
+
+const (
+	CourierStatusOffline    CourierStatus = "offline"
+	CourierStatusAvailable  CourierStatus = "available"
+	CourierStatusOffered    CourierStatus = "offered"
+	CourierStatusDelivering CourierStatus = "delivering"
+)
+
+// Courier is the dispatch state of a user with the courier role. OrderId is
+// the order offered to or being delivered by the courier.
+type Courier struct {
+	UserId            int64         `json:"user_id"`
+	Status            CourierStatus `json:"status"`
+	Location          Location      `json:"location"`
+	LocationUpdatedAt time.Time     `json:"location_updated_at"`
+	OrderId           int64         `json:"order_id,omitempty"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusOffered   DeliveryStatus = "offered"
+	DeliveryStatusAccepted  DeliveryStatus = "accepted"
+	DeliveryStatusPickedUp  DeliveryStatus = "picked_up"
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+)
+
+// Delivery tracks a ready order until a courier brings it to the customer.
+// Declined lists the couriers that rejected the order or let the offer expire.
+type Delivery struct {
+	OrderId   int64          `json:"order_id"`
+	ShopId    int64          `json:"shop_id"`
+	Pickup    Location       `json:"pickup"`
+	Status    DeliveryStatus `json:"status"`
+	CourierId int64          `json:"courier_id,omitempty"`
+	Declined  []int64        `json:"declined,omitempty"`
+	OfferedAt time.Time      `json:"offered_at,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// HasDeclined reports whether the courier already turned the delivery down.
+func (delivery *Delivery) HasDeclined(courierId int64) bool {
+	for _, id := range delivery.Declined {
+		if id == courierId {
+			return true
+		}
+	}
+	return false
+}
+
+// CourierState is what a courier sees about themselves: their dispatch state
+// and the delivery offered to or accepted by them.
+type CourierState struct {
+	Courier  *Courier  `json:"courier"`
+	Delivery *Delivery `json:"delivery,omitempty"`
+}
+
+const (
+	DeliveryEventOffered  = "delivery.offered"
+	DeliveryEventAccepted = "delivery.accepted"
+	DeliveryEventRejected = "delivery.rejected"
+	DeliveryEventExpired  = "delivery.expired"
+)
+
+// DeliveryEvent is published when a delivery changes hands.
+type DeliveryEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OrderId    int64     `json:"order_id"`
+	ShopId     int64     `json:"shop_id"`
+	CourierId  int64     `json:"courier_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,63 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+func TestInPolygon(t *testing.T) {
+	square := []entity.Location{
+		{Lat: 41.70, Lng: 44.80},
+		{Lat: 41.70, Lng: 44.82},
+		{Lat: 41.72, Lng: 44.82},
+		{Lat: 41.72, Lng: 44.80},
+	}
+	// diamond has vertices on the latitude of the points tested against it,
+	// where a ray passes through a vertex.
+	diamond := []entity.Location{
+		{Lat: 41.70, Lng: 44.81},
+		{Lat: 41.71, Lng: 44.82},
+		{Lat: 41.72, Lng: 44.81},
+		{Lat: 41.71, Lng: 44.80},
+	}
+	// notched is a square with a notch cut into its east side.
+	notched := []entity.Location{
+		{Lat: 41.70, Lng: 44.80},
+		{Lat: 41.70, Lng: 44.82},
+		{Lat: 41.71, Lng: 44.81},
+		{Lat: 41.72, Lng: 44.82},
+		{Lat: 41.72, Lng: 44.80},
+	}
+
+	tests := []struct {
+		name    string
+		point   entity.Location
+		polygon []entity.Location
+		want    bool
+	}{
+		{"inside", entity.Location{Lat: 41.71, Lng: 44.81}, square, true},
+		{"outside", entity.Location{Lat: 41.73, Lng: 44.81}, square, false},
+		{"on the south edge", entity.Location{Lat: 41.70, Lng: 44.81}, square, true},
+		{"on the east edge", entity.Location{Lat: 41.71, Lng: 44.82}, square, true},
+		{"on the north edge", entity.Location{Lat: 41.72, Lng: 44.81}, square, true},
+		{"on the west edge", entity.Location{Lat: 41.71, Lng: 44.80}, square, true},
+		{"on a vertex", entity.Location{Lat: 41.72, Lng: 44.82}, square, true},
+		{"in line with an edge", entity.Location{Lat: 41.70, Lng: 44.83}, square, false},
+		{"on a slanted edge", entity.Location{Lat: 41.705, Lng: 44.815}, diamond, true},
+		{"ray through a vertex from inside", entity.Location{Lat: 41.71, Lng: 44.805}, diamond, true},
+		{"ray through a vertex from outside", entity.Location{Lat: 41.71, Lng: 44.79}, diamond, false},
+		{"ray through two vertices", entity.Location{Lat: 41.71, Lng: 44.83}, diamond, false},
+		{"in the notch", entity.Location{Lat: 41.71, Lng: 44.815}, notched, false},
+		{"beside the notch", entity.Location{Lat: 41.705, Lng: 44.815}, notched, true},
+		{"empty polygon", entity.Location{Lat: 41.71, Lng: 44.81}, nil, false},
+		{"two vertices", entity.Location{Lat: 41.70, Lng: 44.81}, square[:2], false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.point.InPolygon(tc.polygon); got != tc.want {
+				t.Fatalf("InPolygon of %+v is %v, want %v", tc.point, got, tc.want)
+			}
+		})
+	}
+}
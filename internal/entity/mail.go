@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// Mail jobs are published for the mailer service, which renders the mail of
+// the job type and links Token into it.
+const (
+	MailJobPasswordReset     = "mail.password_reset"
+	MailJobEmailVerification = "mail.email_verification"
+)
+
+type MailJob struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	To        string    `json:"to"`
+	UserId    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
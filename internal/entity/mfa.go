@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// MFA is the TOTP second factor of a user. It is enrolled but not Enabled
+// until the user confirms a code from their authenticator app. Recovery codes
+// are kept as SHA-256 hashes and each works once.
+type MFA struct {
+	UserId        int64    `json:"user_id"`
+	Secret        string   `json:"secret"`
+	Enabled       bool     `json:"enabled"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// LastCounter is the time step of the last accepted code, so a code
+	// can't be replayed.
+	LastCounter int64     `json:"last_counter"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MFAEnrollment is shown once to set up an authenticator app, usually as a
+// QR code of URI.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge answers the login of a user with two-factor authentication.
+// ChallengeToken and a code are exchanged for the login response.
+type MFAChallenge struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// OIDCState is remembered while a user logs in at an identity provider and
+// taken once they return.
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// OIDCAuthorization sends a user to log in at an identity provider. State has
+// to come back with the code.
+type OIDCAuthorization struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+// OIDCLink ties the identity Subject at Provider to a user, so later logins
+// find the user even if either email changes.
+type OIDCLink struct {
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	UserId   int64     `json:"user_id"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linked_at"`
+}
@@ -0,0 +1,102 @@
+package entity
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderStatusPlaced    OrderStatus = "placed"
+	OrderStatusAccepted  OrderStatus = "accepted"
+	OrderStatusPreparing OrderStatus = "preparing"
+	OrderStatusReady     OrderStatus = "ready"
+	OrderStatusPickedUp  OrderStatus = "picked_up"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRejected  OrderStatus = "rejected"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPlaced:    {OrderStatusAccepted, OrderStatusRejected, OrderStatusCancelled},
+	OrderStatusAccepted:  {OrderStatusPreparing, OrderStatusCancelled},
+	OrderStatusPreparing: {OrderStatusReady},
+	OrderStatusReady:     {OrderStatusPickedUp},
+	OrderStatusPickedUp:  {OrderStatusDelivered},
+}
+
+func (status OrderStatus) IsValid() bool {
+	switch status {
+	case OrderStatusPlaced, OrderStatusAccepted, OrderStatusPreparing, OrderStatusReady,
+		OrderStatusPickedUp, OrderStatusDelivered, OrderStatusCancelled, OrderStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (status OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type Order struct {
+	ID         int64       `json:"id"`
+	UserId     int64       `json:"user_id"`
+	ShopId     int64       `json:"shop_id"`
+	Items      []OrderItem `json:"items"`
+	TotalPrice int32       `json:"total_price"`
+	Status     OrderStatus `json:"status"`
+	// DeliveryLocation is where the order is delivered to.
+	DeliveryLocation Location  `json:"delivery_location"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// MaxItemQuantity is the most of one menu item an order or cart line may
+// hold, MaxOrderItems the most lines an order or cart may hold.
+const (
+	MaxItemQuantity = 100
+	MaxOrderItems   = 100
+)
+
+type OrderItem struct {
+	MenuItemId int64  `json:"menu_item_id"`
+	Name       string `json:"name"`
+	Price      int32  `json:"price"`
+	Quantity   int32  `json:"quantity"`
+}
+
+// CreateOrder is filled with menu item ids and quantities by the client; the
+// gateway sets names, prices and the total from the shop's menu.
+type CreateOrder struct {
+	UserId     int64       `json:"user_id"`
+	ShopId     int64       `json:"shop_id"`
+	Items      []OrderItem `json:"items"`
+	TotalPrice int32       `json:"total_price"`
+	// DeliveryLocation must be inside the shop's delivery zone.
+	DeliveryLocation Location `json:"delivery_location"`
+}
+
+type UpdateOrderStatus struct {
+	Status OrderStatus `json:"status"`
+}
+
+// OrderEvent is published on every status change under the routing key
+// "order.<status>", e.g. "order.ready".
+type OrderEvent struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OrderId    int64       `json:"order_id"`
+	UserId     int64       `json:"user_id"`
+	ShopId     int64       `json:"shop_id"`
+	From       OrderStatus `json:"from,omitempty"`
+	To         OrderStatus `json:"to"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+func OrderEventType(status OrderStatus) string {
+	return "order." + string(status)
+}
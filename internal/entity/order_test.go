@@ -0,0 +1,50 @@
+package entity_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	statuses := []entity.OrderStatus{
+		entity.OrderStatusPlaced,
+		entity.OrderStatusAccepted,
+		entity.OrderStatusPreparing,
+		entity.OrderStatusReady,
+		entity.OrderStatusPickedUp,
+		entity.OrderStatusDelivered,
+		entity.OrderStatusCancelled,
+		entity.OrderStatusRejected,
+	}
+	allowed := map[[2]entity.OrderStatus]bool{
+		{entity.OrderStatusPlaced, entity.OrderStatusAccepted}:    true,
+		{entity.OrderStatusPlaced, entity.OrderStatusRejected}:    true,
+		{entity.OrderStatusPlaced, entity.OrderStatusCancelled}:   true,
+		{entity.OrderStatusAccepted, entity.OrderStatusPreparing}: true,
+		{entity.OrderStatusAccepted, entity.OrderStatusCancelled}: true,
+		{entity.OrderStatusPreparing, entity.OrderStatusReady}:    true,
+		{entity.OrderStatusReady, entity.OrderStatusPickedUp}:     true,
+		{entity.OrderStatusPickedUp, entity.OrderStatusDelivered}: true,
+	}
+
+	// Every pair, so a transition added or dropped by mistake shows up; an
+	// order never stays in its status either.
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]entity.OrderStatus{from, to}]
+			t.Run(fmt.Sprintf("%s to %s", from, to), func(t *testing.T) {
+				if got := from.CanTransitionTo(to); got != want {
+					t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want)
+				}
+			})
+		}
+	}
+
+	for _, status := range []entity.OrderStatus{"", "unknown"} {
+		if status.CanTransitionTo(entity.OrderStatusAccepted) || entity.OrderStatusPlaced.CanTransitionTo(status) {
+			t.Errorf("an order moves from or to the unknown status %q", status)
+		}
+	}
+}
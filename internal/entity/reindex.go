@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// SearchIndexVersion names a version of the search index served under
+// alias, so versions sort by when they were built.
+func SearchIndexVersion(alias string, t time.Time) string {
+	return alias + "-" + t.UTC().Format("20060102150405")
+}
+
+type ReindexOptions struct {
+	// PageSize is how many shops are read from the shops service at a time.
+	PageSize int32
+	// Concurrency is how many shops are indexed at once.
+	Concurrency int
+	// DryRun reads the whole catalog and counts it without touching the
+	// index or the checkpoint.
+	DryRun bool
+	// KeepPrevious leaves the versions the alias pointed at in place instead
+	// of deleting them after the swap.
+	KeepPrevious bool
+}
+
+// ReindexCheckpoint is how far a reindex got; a run that stops is resumed
+// from Offset into the same Version.
+type ReindexCheckpoint struct {
+	Alias     string    `json:"alias"`
+	Version   string    `json:"version"`
+	Offset    int32     `json:"offset"`
+	Shops     int       `json:"shops"`
+	Documents int       `json:"documents"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReindexReport struct {
+	ReindexCheckpoint
+	Resumed bool `json:"resumed"`
+	DryRun  bool `json:"dry_run"`
+	// Replaced are the versions the alias pointed at before the swap.
+	Replaced []string `json:"replaced"`
+}
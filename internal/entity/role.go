@@ -0,0 +1,84 @@
+package entity
+
+type Role string
+
+const (
+	RoleCustomer      Role = "customer"
+	RoleShopOwner     Role = "shop_owner"
+	RoleShopStaff     Role = "shop_staff"
+	RoleCourier       Role = "courier"
+	RolePlatformAdmin Role = "platform_admin"
+)
+
+type Permission string
+
+const (
+	PermissionShopCreate     Permission = "shop:create"
+	PermissionShopWrite      Permission = "shop:write"
+	PermissionShopDelete     Permission = "shop:delete"
+	PermissionMenuWrite      Permission = "menu:write"
+	PermissionOrdersManage   Permission = "orders:manage"
+	PermissionAPIKeysManage  Permission = "apikeys:manage"
+	PermissionDeliveriesWork Permission = "deliveries:work"
+	PermissionAdminsManage   Permission = "admins:manage"
+)
+
+// shopPermissions only apply to the shop of the grant.
+var shopPermissions = map[Permission]bool{
+	PermissionShopWrite:     true,
+	PermissionShopDelete:    true,
+	PermissionMenuWrite:     true,
+	PermissionOrdersManage:  true,
+	PermissionAPIKeysManage: true,
+}
+
+// IsShopScoped reports whether the permission only applies to the shop of the
+// grant.
+func (permission Permission) IsShopScoped() bool {
+	return shopPermissions[permission]
+}
+
+// rolePermissions lists what every role may do. Platform admins may do
+// everything everywhere. Any customer may open a shop, the shops service
+// makes them its owner.
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer:  {PermissionShopCreate},
+	RoleShopOwner: {PermissionShopWrite, PermissionShopDelete, PermissionMenuWrite, PermissionOrdersManage, PermissionAPIKeysManage},
+	RoleShopStaff: {PermissionMenuWrite, PermissionOrdersManage},
+	RoleCourier:   {PermissionDeliveriesWork},
+}
+
+// RoleGrant gives a user a role, limited to ShopId for shop roles.
+type RoleGrant struct {
+	Role   Role  `json:"role"`
+	ShopId int64 `json:"shop_id,omitempty"`
+}
+
+// HasPermission reports whether grants allow permission in the shop. A zero
+// shopId asks whether the permission is held for any shop.
+func HasPermission(grants []RoleGrant, permission Permission, shopId int64) bool {
+	for _, grant := range grants {
+		if grant.Role == RolePlatformAdmin {
+			return true
+		}
+		if !grant.allows(permission) {
+			continue
+		}
+		if !shopPermissions[permission] {
+			return true
+		}
+		if grant.ShopId != 0 && (shopId == 0 || grant.ShopId == shopId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (grant RoleGrant) allows(permission Permission) bool {
+	for _, p := range rolePermissions[grant.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+func TestHasPermission(t *testing.T) {
+	customer := entity.RoleGrant{Role: entity.RoleCustomer}
+	owner := entity.RoleGrant{Role: entity.RoleShopOwner, ShopId: 1}
+	staff := entity.RoleGrant{Role: entity.RoleShopStaff, ShopId: 2}
+	courier := entity.RoleGrant{Role: entity.RoleCourier}
+	admin := entity.RoleGrant{Role: entity.RolePlatformAdmin}
+
+	tests := []struct {
+		name       string
+		grants     []entity.RoleGrant
+		permission entity.Permission
+		shopId     int64
+		want       bool
+	}{
+		{"no grants", nil, entity.PermissionShopCreate, 0, false},
+		{"customer opens a shop", []entity.RoleGrant{customer}, entity.PermissionShopCreate, 0, true},
+		{"customer edits a shop", []entity.RoleGrant{customer}, entity.PermissionShopWrite, 1, false},
+		{"customer delivers", []entity.RoleGrant{customer}, entity.PermissionDeliveriesWork, 0, false},
+
+		{"owner in their shop", []entity.RoleGrant{customer, owner}, entity.PermissionShopWrite, 1, true},
+		{"owner in any shop", []entity.RoleGrant{customer, owner}, entity.PermissionShopDelete, 0, true},
+		{"owner in another shop", []entity.RoleGrant{customer, owner}, entity.PermissionShopWrite, 2, false},
+		{"owner manages keys", []entity.RoleGrant{owner}, entity.PermissionAPIKeysManage, 1, true},
+		{"owner manages admins", []entity.RoleGrant{owner}, entity.PermissionAdminsManage, 0, false},
+		{"owner of several shops", []entity.RoleGrant{owner, {Role: entity.RoleShopOwner, ShopId: 3}}, entity.PermissionMenuWrite, 3, true},
+		{"shop role without a shop", []entity.RoleGrant{{Role: entity.RoleShopOwner}}, entity.PermissionShopWrite, 0, false},
+
+		{"staff edits the menu", []entity.RoleGrant{staff}, entity.PermissionMenuWrite, 2, true},
+		{"staff manages orders", []entity.RoleGrant{staff}, entity.PermissionOrdersManage, 2, true},
+		{"staff edits the shop", []entity.RoleGrant{staff}, entity.PermissionShopWrite, 2, false},
+		{"staff manages keys", []entity.RoleGrant{staff}, entity.PermissionAPIKeysManage, 2, false},
+		{"staff in another shop", []entity.RoleGrant{staff}, entity.PermissionMenuWrite, 1, false},
+		{"staff and owner of different shops", []entity.RoleGrant{owner, staff}, entity.PermissionShopWrite, 2, false},
+
+		{"courier delivers", []entity.RoleGrant{customer, courier}, entity.PermissionDeliveriesWork, 0, true},
+		{"courier edits a menu", []entity.RoleGrant{courier}, entity.PermissionMenuWrite, 0, false},
+
+		{"admin manages admins", []entity.RoleGrant{customer, admin}, entity.PermissionAdminsManage, 0, true},
+		{"admin in any shop", []entity.RoleGrant{admin}, entity.PermissionShopDelete, 7, true},
+		{"admin delivers", []entity.RoleGrant{admin}, entity.PermissionDeliveriesWork, 0, true},
+
+		{"unknown role", []entity.RoleGrant{{Role: "janitor", ShopId: 1}}, entity.PermissionShopWrite, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entity.HasPermission(tt.grants, tt.permission, tt.shopId); got != tt.want {
+				t.Errorf("HasPermission(%v, %s, %d) = %v, want %v", tt.grants, tt.permission, tt.shopId, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionIsShopScoped(t *testing.T) {
+	for permission, want := range map[entity.Permission]bool{
+		entity.PermissionShopCreate:     false,
+		entity.PermissionShopWrite:      true,
+		entity.PermissionShopDelete:     true,
+		entity.PermissionMenuWrite:      true,
+		entity.PermissionOrdersManage:   true,
+		entity.PermissionAPIKeysManage:  true,
+		entity.PermissionDeliveriesWork: false,
+		entity.PermissionAdminsManage:   false,
+	} {
+		if got := permission.IsShopScoped(); got != want {
+			t.Errorf("%s.IsShopScoped() = %v, want %v", permission, got, want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	SearchKindShop     = "shop"
+	SearchKindMenuItem = "menu_item"
+)
+
+// SearchDocument is a shop or a menu item as the search index keeps it. Menu
+// items carry the name and hours of their shop, so they are found by the shop
+// name too and can be filtered by whether the shop is open.
+type SearchDocument struct {
+	Kind        string    `json:"kind"`
+	ID          int64     `json:"id"`
+	ShopId      int64     `json:"shop_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ShopName    string    `json:"shop_name"`
+	Photo       string    `json:"photo,omitempty"`
+	Price       *int32    `json:"price,omitempty"`
+	Hours       OpenHours `json:"hours"`
+	// Location and DeliveryZone are set on documents of shops with a
+	// location, for finding shops nearby.
+	Location     *Location     `json:"location,omitempty"`
+	DeliveryZone *DeliveryZone `json:"delivery_zone,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+func NewShopDocument(shop *Shop) *SearchDocument {
+	doc := &SearchDocument{
+		Kind:        SearchKindShop,
+		ID:          shop.ID,
+		ShopId:      shop.ID,
+		Name:        shop.Name,
+		Description: shop.Description,
+		ShopName:    shop.Name,
+		Hours:       NewOpenHours(shop),
+		CreatedAt:   shop.CreatedAt,
+	}
+	if !shop.Location.IsZero() {
+		location := shop.Location
+		zone := shop.DeliveryZone
+		doc.Location = &location
+		doc.DeliveryZone = &zone
+	}
+	return doc
+}
+
+func NewMenuItemDocument(item *GetMenuItem, shop *Shop) *SearchDocument {
+	price := item.Price
+	return &SearchDocument{
+		Kind:        SearchKindMenuItem,
+		ID:          item.ID,
+		ShopId:      shop.ID,
+		Name:        item.Name,
+		Description: item.Description,
+		ShopName:    shop.Name,
+		Photo:       item.Photo,
+		Price:       &price,
+		Hours:       NewOpenHours(shop),
+		CreatedAt:   item.CreatedAt,
+	}
+}
+
+// Key tells documents of different kinds with the same id apart.
+func (doc *SearchDocument) Key() string {
+	return SearchDocumentKey(doc.Kind, doc.ID)
+}
+
+func SearchDocumentKey(kind string, id int64) string {
+	return kind + "-" + strconv.FormatInt(id, 10)
+}
+
+// OpenHours are the hours of a shop in minutes of the day in UTC, so an index
+// can tell whether a shop is open without knowing its time zone. Overnight
+// shops close on the day after they open, at a Closes before Opens.
+type OpenHours struct {
+	Closed     bool `json:"closed"`
+	AlwaysOpen bool `json:"always_open"`
+	Overnight  bool `json:"overnight"`
+	Opens      int  `json:"opens"`
+	Closes     int  `json:"closes"`
+}
+
+// NewOpenHours agrees with Shop.IsOpenAt for shops with a fixed UTC offset.
+func NewOpenHours(shop *Shop) OpenHours {
+	opens := minuteOfDay(shop.OpenTime.UTC())
+	closes := minuteOfDay(shop.CloseTime.UTC())
+	return OpenHours{
+		Closed:     shop.IsClosed,
+		AlwaysOpen: opens == closes,
+		Overnight:  closes < opens,
+		Opens:      opens,
+		Closes:     closes,
+	}
+}
+
+func (hours OpenHours) IsOpenAt(t time.Time) bool {
+	now := minuteOfDay(t.UTC())
+	switch {
+	case hours.Closed:
+		return false
+	case hours.AlwaysOpen:
+		return true
+	case hours.Overnight:
+		return now >= hours.Opens || now < hours.Closes
+	default:
+		return now >= hours.Opens && now < hours.Closes
+	}
+}
+
+// SearchQuery matches Text fuzzily against names and descriptions; an empty
+// Text matches everything the filters let through.
+type SearchQuery struct {
+	Text string
+	// Kind limits the results to shops or menu items.
+	Kind     string
+	ShopId   int64
+	MinPrice *int32
+	MaxPrice *int32
+	// OpenNow keeps shops, and items of shops, open at At.
+	OpenNow bool
+	At      time.Time
+	Limit   int
+	Offset  int
+}
+
+// SearchResult is a page of hits and the facets of every match.
+type SearchResult struct {
+	Total  int          `json:"total"`
+	Hits   []SearchHit  `json:"hits"`
+	Facets SearchFacets `json:"facets"`
+}
+
+type SearchHit struct {
+	SearchDocument
+	Score float64 `json:"score"`
+	// Highlights holds the matching parts of fields, the matches wrapped in
+	// <em> tags.
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type SearchFacets struct {
+	Kinds  []FacetBucket `json:"kinds"`
+	Shops  []FacetBucket `json:"shops"`
+	Prices []FacetBucket `json:"prices"`
+}
+
+type FacetBucket struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+// PriceRange is a bucket of the price facet, From inclusive and To exclusive.
+// A zero To has no upper bound.
+type PriceRange struct {
+	From int32
+	To   int32
+}
+
+func (r PriceRange) Key() string {
+	if r.To == 0 {
+		return strconv.Itoa(int(r.From)) + "-"
+	}
+	return strconv.Itoa(int(r.From)) + "-" + strconv.Itoa(int(r.To))
+}
+
+func (r PriceRange) Contains(price int32) bool {
+	return price >= r.From && (r.To == 0 || price < r.To)
+}
+
+var SearchPriceRanges = []PriceRange{{0, 500}, {500, 1000}, {1000, 2000}, {2000, 0}}
+
+// SearchShopFacetSize is how many shops with the most matches the shop facet
+// lists.
+const SearchShopFacetSize = 10
@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// SessionTokens is the token pair handed out when a session starts and every
+// time its refresh token is rotated.
+type SessionTokens struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// SessionClient describes the device a request of a session came from.
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is a login of a user on one device. It lasts until it is revoked
+// or its refresh token expires unused.
+type Session struct {
+	ID        string `json:"id"`
+	UserId    int64  `json:"user_id"`
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	// Current marks the session of the request listing the sessions.
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -11,17 +11,75 @@ type Shop struct {
 	OpenTime    time.Time `json:"open_time"`
 	CloseTime   time.Time `json:"close_time"`
 	IsClosed    bool      `json:"is_closed"`
-	CreatedAt   time.Time `json:"created_at"`
+	Location    Location  `json:"location"`
+	// DeliveryZone is empty for shops delivering within the default radius.
+	DeliveryZone DeliveryZone `json:"delivery_zone"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// DeliveryZone is where a shop delivers: inside Polygon when it has one,
+// otherwise within RadiusKm of the shop.
+type DeliveryZone struct {
+	RadiusKm float64    `json:"radius_km,omitempty"`
+	Polygon  []Location `json:"polygon,omitempty"`
+}
+
+// Covers reports whether the zone of a shop at center reaches point. A shop
+// without a location doesn't deliver, whatever its zone, as it isn't found
+// nearby either. Zones without a radius use defaultRadiusKm; a non-positive
+// default doesn't limit where they deliver.
+func (zone DeliveryZone) Covers(center Location, point Location, defaultRadiusKm float64) bool {
+	if center.IsZero() {
+		return false
+	}
+	if len(zone.Polygon) > 0 {
+		return point.InPolygon(zone.Polygon)
+	}
+
+	radius := zone.RadiusKm
+	if radius == 0 {
+		radius = defaultRadiusKm
+	}
+	if radius <= 0 {
+		return true
+	}
+	return center.DistanceTo(point) <= radius
+}
+
+// DeliversTo reports whether the shop delivers to point.
+func (shop *Shop) DeliversTo(point Location, defaultRadiusKm float64) bool {
+	return shop.DeliveryZone.Covers(shop.Location, point, defaultRadiusKm)
+}
+
+// NearbyQuery looks for shops within RadiusKm of Location that deliver there.
+type NearbyQuery struct {
+	Location Location
+	RadiusKm float64
+	Limit    int
+}
+
+// NearbyShop is a shop delivering to the place it was looked up from.
+type NearbyShop struct {
+	ID           int64        `json:"id"`
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	Location     Location     `json:"location"`
+	DeliveryZone DeliveryZone `json:"delivery_zone"`
+	OpenNow      bool         `json:"open_now"`
+	DistanceKm   float64      `json:"distance_km"`
 }
 
 type CreateShop struct {
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	OpenTime    time.Time     `json:"open_time"`
-	CloseTime   time.Time     `json:"close_time"`
-	IsClosed    bool          `json:"is_closed"`
-	Menuitems   []GetMenuItem `json:"menuitems"`
-	UserId      int64         `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	OpenTime    time.Time `json:"open_time"`
+	CloseTime   time.Time `json:"close_time"`
+	IsClosed    bool      `json:"is_closed"`
+	Location    Location  `json:"location"`
+	// DeliveryZone may be left empty to deliver within the default radius.
+	DeliveryZone DeliveryZone  `json:"delivery_zone"`
+	Menuitems    []GetMenuItem `json:"menuitems"`
+	UserId       int64         `json:"user_id"`
 }
 
 type GetMenuItem struct {
@@ -40,7 +98,10 @@ type UpdateShopInfo struct {
 	OpenTime    time.Time `json:"open_time"`
 	CloseTime   time.Time `json:"close_time"`
 	IsClosed    bool      `json:"is_closed"`
-	UserId      int64     `json:"user_id"`
+	// Location and DeliveryZone are left as they are when nil.
+	Location     *Location     `json:"location,omitempty"`
+	DeliveryZone *DeliveryZone `json:"delivery_zone,omitempty"`
+	UserId       int64         `json:"user_id"`
 }
 
 type MenuItem struct {
@@ -62,3 +123,59 @@ type UpdateMenuItem struct {
 	Price       int32  `json:"price"`
 	UserId      int64  `json:"user_id"`
 }
+
+// IsOpenAt reports whether the shop takes orders at t. Only the time of day of
+// OpenTime and CloseTime is used; a CloseTime before OpenTime means the shop
+// closes after midnight, equal times mean it never closes.
+func (shop *Shop) IsOpenAt(t time.Time) bool {
+	if shop.IsClosed {
+		return false
+	}
+
+	open := minuteOfDay(shop.OpenTime)
+	close := minuteOfDay(shop.CloseTime)
+	now := minuteOfDay(t.In(shop.OpenTime.Location()))
+
+	switch {
+	case open == close:
+		return true
+	case open < close:
+		return now >= open && now < close
+	default:
+		return now >= open || now < close
+	}
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+const (
+	ShopEventCreated     = "shop.created"
+	ShopEventUpdated     = "shop.updated"
+	ShopEventDeleted     = "shop.deleted"
+	MenuItemEventCreated = "menu_item.created"
+	MenuItemEventUpdated = "menu_item.updated"
+	MenuItemEventDeleted = "menu_item.deleted"
+)
+
+// ShopEvent is published when a shop changes. Shop is left out for deleted
+// shops.
+type ShopEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	ShopId     int64     `json:"shop_id"`
+	Shop       *Shop     `json:"shop,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// MenuItemEvent is published when a menu item changes. MenuItem is left out
+// for deleted items.
+type MenuItemEvent struct {
+	ID         string       `json:"id"`
+	Type       string       `json:"type"`
+	MenuItemId int64        `json:"menu_item_id"`
+	ShopId     int64        `json:"shop_id,omitempty"`
+	MenuItem   *GetMenuItem `json:"menu_item,omitempty"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
@@ -0,0 +1,50 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+func TestDeliveryZoneCovers(t *testing.T) {
+	shop := entity.Location{Lat: 41.7151, Lng: 44.8271}
+	// near is about 1.1 km north of the shop, far about 3.3 km.
+	near := entity.Location{Lat: 41.7251, Lng: 44.8271}
+	far := entity.Location{Lat: 41.7451, Lng: 44.8271}
+	polygon := []entity.Location{
+		{Lat: 41.74, Lng: 44.82},
+		{Lat: 41.74, Lng: 44.84},
+		{Lat: 41.76, Lng: 44.84},
+		{Lat: 41.76, Lng: 44.82},
+	}
+
+	tests := []struct {
+		name          string
+		zone          entity.DeliveryZone
+		center        entity.Location
+		point         entity.Location
+		defaultRadius float64
+		want          bool
+	}{
+		{"within the default radius", entity.DeliveryZone{}, shop, near, 2, true},
+		{"beyond the default radius", entity.DeliveryZone{}, shop, far, 2, false},
+		{"within the shop's radius", entity.DeliveryZone{RadiusKm: 5}, shop, far, 2, true},
+		{"beyond the shop's radius", entity.DeliveryZone{RadiusKm: 0.5}, shop, near, 2, false},
+		{"no default radius", entity.DeliveryZone{}, shop, far, 0, true},
+		{"at the shop", entity.DeliveryZone{}, shop, shop, 2, true},
+		{"inside the polygon", entity.DeliveryZone{Polygon: polygon}, shop, far, 2, true},
+		{"outside the polygon", entity.DeliveryZone{Polygon: polygon, RadiusKm: 5}, shop, near, 2, false},
+		{"on the polygon's edge", entity.DeliveryZone{Polygon: polygon}, shop, entity.Location{Lat: 41.74, Lng: 44.83}, 2, true},
+		{"empty polygon falls back to the radius", entity.DeliveryZone{Polygon: []entity.Location{}}, shop, near, 2, true},
+		{"shop without a location", entity.DeliveryZone{}, entity.Location{}, near, 2, false},
+		{"shop without a location and no default radius", entity.DeliveryZone{}, entity.Location{}, near, 0, false},
+		{"shop without a location with a polygon", entity.DeliveryZone{Polygon: polygon}, entity.Location{}, far, 2, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.zone.Covers(tc.center, tc.point, tc.defaultRadius); got != tc.want {
+				t.Fatalf("Covers of %+v is %v, want %v", tc.point, got, tc.want)
+			}
+		})
+	}
+}
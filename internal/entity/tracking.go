@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+const CourierLocationEventType = "courier.location"
+
+// CourierLocationEvent is published when the courier delivering an order
+// reports a new position.
+type CourierLocationEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OrderId    int64     `json:"order_id"`
+	CourierId  int64     `json:"courier_id"`
+	Lat        float64   `json:"lat"`
+	Lng        float64   `json:"lng"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
@@ -3,12 +3,17 @@ package entity
 import "time"
 
 type User struct {
-	Id        int64     `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Phone     string    `json:"phone"`
-	IsAdmin   bool      `json:"is_admin"`
-	CreatedAt time.Time `json:"created_at"`
+	Id        int64  `json:"id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	IsAdmin   bool   `json:"is_admin"`
+	IsCourier bool   `json:"is_courier"`
+	// EmailVerified is set once the user followed the verification mail.
+	EmailVerified bool `json:"email_verified"`
+	// Roles are grants kept by the accounts service, such as shop staff.
+	Roles     []RoleGrant `json:"roles,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
 }
 
 type UserRegister struct {
@@ -41,3 +46,7 @@ type UserAddPhone struct {
 type UserAddAdminRole struct {
 	IsAdmin bool `json:"is_admin"`
 }
+
+type UserResetPassword struct {
+	Password string `json:"password"`
+}
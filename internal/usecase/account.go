@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// AccountUseCase mails users single-use tokens to reset their password and
+// to verify their email. The tokens are signed by the gateway, reserved in
+// the revocation store while a request uses them and remembered there once
+// used.
+type AccountUseCase struct {
+	config     *config.Config
+	users      UserWebAPI
+	sessions   Session
+	tokenMaker token.Maker
+	used       RevocationStore
+	attempts   LoginAttemptStore
+	publisher  EventPublisher
+}
+
+func NewAccountUseCase(config *config.Config, users UserWebAPI, sessions Session, tokenMaker token.Maker, used RevocationStore, attempts LoginAttemptStore, publisher EventPublisher) *AccountUseCase {
+	return &AccountUseCase{
+		config:     config,
+		users:      users,
+		sessions:   sessions,
+		tokenMaker: tokenMaker,
+		used:       used,
+		attempts:   attempts,
+		publisher:  publisher,
+	}
+}
+
+// ForgotPassword mails a reset token to the account with email. Unknown
+// emails succeed too, so the endpoint doesn't tell which accounts exist.
+func (uc *AccountUseCase) ForgotPassword(ctx context.Context, email string) error {
+	user, err := uc.users.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if apperror.Is(err, apperror.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return uc.mail(ctx, user, entity.MailJobPasswordReset, token.TokenTypePasswordReset, uc.config.PasswordResetDuration)
+}
+
+// ResetPassword sets a new password for the owner of resetToken, logs them
+// out everywhere and lifts a lockout caused by failed logins. Whoever got
+// hold of the old password loses their sessions with it.
+func (uc *AccountUseCase) ResetPassword(ctx context.Context, resetToken string, password string) (string, error) {
+	reservation, err := uc.reserveMailed(ctx, resetToken, token.TokenTypePasswordReset)
+	if err != nil {
+		return "", err
+	}
+	payload := reservation.payload
+
+	resp, err := uc.users.ResetPassword(ctx, payload.UserId, &entity.UserResetPassword{Password: password})
+	if err != nil {
+		reservation.release()
+		return "", err
+	}
+	err = reservation.use(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.sessions.RevokeAllSessions(ctx, payload.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.attempts.Reset(ctx, strings.ToLower(payload.Email))
+	if err != nil {
+		return "", apperror.Internal(err)
+	}
+	return resp, nil
+}
+
+// SendVerification mails a verification token to the user's current email.
+func (uc *AccountUseCase) SendVerification(ctx context.Context, userId int64) error {
+	user, err := uc.users.GetMyProfile(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return apperror.Conflict("email is already verified")
+	}
+
+	return uc.mail(ctx, user, entity.MailJobEmailVerification, token.TokenTypeEmailVerification, uc.config.EmailVerificationDuration)
+}
+
+// VerifyEmail marks the email verificationToken was mailed to as verified,
+// unless the user changed it in the meantime. Tokens in use keep the old
+// state until they are renewed.
+func (uc *AccountUseCase) VerifyEmail(ctx context.Context, verificationToken string) (string, error) {
+	reservation, err := uc.reserveMailed(ctx, verificationToken, token.TokenTypeEmailVerification)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := uc.verifyEmail(ctx, reservation.payload)
+	if err != nil {
+		reservation.release()
+		return "", err
+	}
+	err = reservation.use(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+func (uc *AccountUseCase) verifyEmail(ctx context.Context, payload *token.Payload) (string, error) {
+	user, err := uc.users.GetMyProfile(ctx, payload.UserId)
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(user.Email, payload.Email) {
+		return "", apperror.Conflict("email changed after the verification mail was sent")
+	}
+	return uc.users.VerifyEmail(ctx, user.Id)
+}
+
+func (uc *AccountUseCase) mail(ctx context.Context, user *entity.User, jobType string, tokenType token.TokenType, duration time.Duration) error {
+	tok, payload, err := uc.tokenMaker.CreateToken(token.Claims{
+		UserId: user.Id,
+		Email:  user.Email,
+		Type:   tokenType,
+	}, duration)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+
+	err = uc.publisher.Publish(ctx, jobType, entity.MailJob{
+		ID:        payload.ID.String(),
+		Type:      jobType,
+		To:        user.Email,
+		UserId:    user.Id,
+		Name:      user.Name,
+		Token:     tok,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if errors.Is(err, ErrEventUndeliverable) {
+		return apperror.Upstream(apperror.CodeUpstreamUnavailable, "mail delivery is unavailable", err)
+	}
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// reserveMailed verifies a mailed token that wasn't used yet and reserves
+// it. Callers use the reservation once the change it authorizes went
+// through, and release it when the change failed, so the token stays usable
+// for another try.
+func (uc *AccountUseCase) reserveMailed(ctx context.Context, tokenString string, tokenType token.TokenType) (*tokenReservation, error) {
+	payload, err := uc.tokenMaker.VerifyToken(tokenString)
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("invalid token: %s", err), apperror.FieldError{
+			Field:   "token",
+			Message: err.Error(),
+		})
+	}
+	if payload.Type != tokenType {
+		return nil, apperror.Validation("invalid token", apperror.FieldError{
+			Field:   "token",
+			Message: fmt.Sprintf("must be a %s token", tokenType),
+		})
+	}
+
+	return reserveToken(ctx, uc.used, payload, "token was already used")
+}
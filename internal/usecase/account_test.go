@@ -0,0 +1,228 @@
+package usecase_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// mailbox keeps the mail jobs published to it; without a broker it refuses
+// them the way the app's publisher does.
+type mailbox struct {
+	noBroker bool
+	jobs     []entity.MailJob
+}
+
+func (box *mailbox) Publish(ctx context.Context, routingKey string, event any) error {
+	if box.noBroker {
+		return usecase.ErrEventUndeliverable
+	}
+	box.jobs = append(box.jobs, event.(entity.MailJob))
+	return nil
+}
+
+type accountTest struct {
+	uc       *usecase.AccountUseCase
+	sessions *usecase.SessionUseCase
+	users    *memoryUsers
+	mailbox  *mailbox
+}
+
+func newAccountTest(t *testing.T) *accountTest {
+	t.Helper()
+
+	tokenMaker, err := token.NewJwtMaker("12345678912345678912345678912345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AccessTokenDuration:       time.Hour,
+		RefreshTokenDuration:      24 * time.Hour,
+		PasswordResetDuration:     time.Hour,
+		EmailVerificationDuration: time.Hour,
+	}
+	revocations := repo.NewRevocationMemoryStore()
+
+	test := &accountTest{
+		users:   newMemoryUsers(),
+		mailbox: &mailbox{},
+	}
+	test.sessions = usecase.NewSessionUseCase(cfg, tokenMaker, revocations, repo.NewSessionMemoryStore(), test.users, newMemoryShops())
+	test.uc = usecase.NewAccountUseCase(cfg, test.users, test.sessions, tokenMaker, revocations, repo.NewLoginAttemptMemoryStore(), test.mailbox)
+	return test
+}
+
+// forgotPassword returns the reset token mailed to user.
+func (test *accountTest) forgotPassword(t *testing.T, user *entity.User) string {
+	t.Helper()
+
+	err := test.uc.ForgotPassword(context.Background(), user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := test.mailbox.jobs
+	if len(jobs) == 0 || jobs[len(jobs)-1].To != user.Email {
+		t.Fatalf("ForgotPassword mailed %+v, want a job to %s", jobs, user.Email)
+	}
+	return jobs[len(jobs)-1].Token
+}
+
+func TestResetPasswordKeepsTokenUntilReset(t *testing.T) {
+	test := newAccountTest(t)
+	ctx := context.Background()
+	user := test.users.add(&entity.User{Email: "user@example.com"})
+	resetToken := test.forgotPassword(t, user)
+
+	// A reset the users service fails doesn't use up the token.
+	test.users.failResets = 1
+	_, err := test.uc.ResetPassword(ctx, resetToken, "new password")
+	if !apperror.Is(err, apperror.KindUpstream) {
+		t.Fatalf("ResetPassword returned %v, want the users service error", err)
+	}
+
+	_, err = test.uc.ResetPassword(ctx, resetToken, "new password")
+	if err != nil {
+		t.Fatalf("ResetPassword after a failed try: %v", err)
+	}
+
+	_, err = test.uc.ResetPassword(ctx, resetToken, "other password")
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("ResetPassword with a used token returned %v, want a conflict", err)
+	}
+}
+
+func TestResetPasswordTokenUsedConcurrently(t *testing.T) {
+	test := newAccountTest(t)
+	ctx := context.Background()
+	user := test.users.add(&entity.User{Email: "user@example.com"})
+	resetToken := test.forgotPassword(t, user)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = test.uc.ResetPassword(ctx, resetToken, "new password")
+		}(i)
+	}
+	wg.Wait()
+
+	reset := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			reset++
+		case !apperror.Is(err, apperror.KindConflict):
+			t.Errorf("ResetPassword returned %v, want a conflict", err)
+		}
+	}
+	if reset != 1 || test.users.resets != 1 {
+		t.Errorf("one token was accepted %d times and reset the password %d times, want once", reset, test.users.resets)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	test := newAccountTest(t)
+	ctx := context.Background()
+	user := test.users.add(&entity.User{Email: "user@example.com"})
+
+	err := test.uc.SendVerification(ctx, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verificationToken := test.mailbox.jobs[len(test.mailbox.jobs)-1].Token
+
+	_, err = test.uc.VerifyEmail(ctx, "not a token")
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("VerifyEmail with an invalid token returned %v, want a validation error", err)
+	}
+	_, err = test.uc.VerifyEmail(ctx, test.forgotPassword(t, user))
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("VerifyEmail with a reset token returned %v, want a validation error", err)
+	}
+
+	_, err = test.uc.VerifyEmail(ctx, verificationToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := test.users.GetMyProfile(ctx, user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !profile.EmailVerified {
+		t.Error("the email isn't verified after VerifyEmail")
+	}
+
+	_, err = test.uc.VerifyEmail(ctx, verificationToken)
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Errorf("VerifyEmail with a used token returned %v, want a conflict", err)
+	}
+}
+
+func TestResetPasswordEndsSessions(t *testing.T) {
+	test := newAccountTest(t)
+	ctx := context.Background()
+	user := test.users.add(&entity.User{Email: "user@example.com"})
+	other := test.users.add(&entity.User{Email: "other@example.com"})
+
+	var tokens []*entity.SessionTokens
+	for _, u := range []*entity.User{user, user, other} {
+		tok, err := test.sessions.StartSession(ctx, u, &entity.SessionClient{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, tok)
+	}
+
+	_, err := test.uc.ResetPassword(ctx, test.forgotPassword(t, user), "new password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tok := range tokens[:2] {
+		_, err = test.sessions.Authenticate(ctx, tok.AccessToken)
+		if !apperror.Is(err, apperror.KindUnauthorized) {
+			t.Errorf("session %d: Authenticate returned %v, want the access token revoked", i, err)
+		}
+		_, err = test.sessions.RefreshSession(ctx, tok.RefreshToken, &entity.SessionClient{})
+		if !apperror.Is(err, apperror.KindUnauthorized) {
+			t.Errorf("session %d: RefreshSession returned %v, want the refresh token revoked", i, err)
+		}
+	}
+	sessions, err := test.sessions.ListSessions(ctx, user.Id, uuid.Nil)
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("ListSessions returned %d sessions, %v, want none", len(sessions), err)
+	}
+
+	// Other users stay logged in.
+	_, err = test.sessions.Authenticate(ctx, tokens[2].AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate of another user's session: %v", err)
+	}
+}
+
+func TestMailWithoutBroker(t *testing.T) {
+	test := newAccountTest(t)
+	ctx := context.Background()
+	test.mailbox.noBroker = true
+	user := test.users.add(&entity.User{Email: "user@example.com"})
+
+	err := test.uc.ForgotPassword(ctx, user.Email)
+	if e := apperror.As(err); e == nil || e.Code != apperror.CodeUpstreamUnavailable {
+		t.Fatalf("ForgotPassword returned %v, want mail delivery to be unavailable", err)
+	}
+	err = test.uc.SendVerification(ctx, user.Id)
+	if e := apperror.As(err); e == nil || e.Code != apperror.CodeUpstreamUnavailable {
+		t.Fatalf("SendVerification returned %v, want mail delivery to be unavailable", err)
+	}
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// AdminUseCase is the only way to become a platform admin: an admin either
+// invites a user or approves their request. Every grant, rejection and
+// revocation goes to the audit log. Couriers are onboarded by admins too.
+//
+// Granted roles show up in access tokens once the session is renewed.
+// Revoking the role ends the user's sessions, so it doesn't outlive the
+// revocation in tokens already issued.
+type AdminUseCase struct {
+	config     *config.Config
+	users      UserWebAPI
+	sessions   Session
+	tokenMaker token.Maker
+	redeemed   RevocationStore
+	store      AdminStore
+}
+
+func NewAdminUseCase(config *config.Config, users UserWebAPI, sessions Session, tokenMaker token.Maker, redeemed RevocationStore, store AdminStore) *AdminUseCase {
+	return &AdminUseCase{
+		config:     config,
+		users:      users,
+		sessions:   sessions,
+		tokenMaker: tokenMaker,
+		redeemed:   redeemed,
+		store:      store,
+	}
+}
+
+// CreateInvite signs an invite only userId can redeem, valid for
+// AdminInviteDuration.
+func (uc *AdminUseCase) CreateInvite(ctx context.Context, actorId int64, userId int64) (*entity.AdminInvite, error) {
+	user, err := uc.users.GetMyProfile(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsAdmin {
+		return nil, apperror.Conflict("user is already an admin")
+	}
+
+	invite, payload, err := uc.tokenMaker.CreateToken(token.Claims{
+		UserId: user.Id,
+		Email:  user.Email,
+		Type:   token.TokenTypeAdminInvite,
+	}, uc.config.AdminInviteDuration)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	err = uc.audit(ctx, entity.AuditAdminInvited, actorId, userId, fmt.Sprintf("invite %s", payload.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.AdminInvite{
+		Token:     invite,
+		UserId:    user.Id,
+		ExpiresAt: payload.ExpiredAt,
+	}, nil
+}
+
+// RedeemInvite makes userId an admin if invite was issued to them and wasn't
+// redeemed before. An invite is only used up once the role was granted.
+func (uc *AdminUseCase) RedeemInvite(ctx context.Context, userId int64, invite string) (string, error) {
+	payload, err := uc.tokenMaker.VerifyToken(invite)
+	if err != nil {
+		return "", apperror.Forbidden(fmt.Sprintf("invalid invite: %s", err))
+	}
+	if payload.Type != token.TokenTypeAdminInvite {
+		return "", apperror.Forbidden("invalid invite: not an admin invite")
+	}
+	if payload.UserId != userId {
+		return "", apperror.Forbidden("invalid invite: issued to another user")
+	}
+
+	reservation, err := reserveToken(ctx, uc.redeemed, payload, "invite was already redeemed")
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := uc.grant(ctx, userId, userId, fmt.Sprintf("redeemed invite %s", payload.ID))
+	if err != nil {
+		reservation.release()
+		return "", err
+	}
+	err = reservation.use(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+// RequestAdmin asks platform admins for the admin role.
+func (uc *AdminUseCase) RequestAdmin(ctx context.Context, userId int64, reason string) (*entity.AdminRequest, error) {
+	req, err := uc.store.GetRequest(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if req != nil && req.Status == entity.AdminRequestPending {
+		return nil, apperror.Conflict("an admin request is already pending")
+	}
+
+	req = &entity.AdminRequest{
+		UserId:    userId,
+		Reason:    reason,
+		Status:    entity.AdminRequestPending,
+		CreatedAt: time.Now(),
+	}
+	err = uc.store.SaveRequest(ctx, req)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	err = uc.audit(ctx, entity.AuditAdminRequested, userId, userId, reason)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (uc *AdminUseCase) ListRequests(ctx context.Context, status entity.AdminRequestStatus) ([]*entity.AdminRequest, error) {
+	requests, err := uc.store.ListRequests(ctx, status)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return requests, nil
+}
+
+// ApproveRequest grants the admin role to the author of a pending request.
+// The request stays pending until the role was granted, so a failed grant
+// can be approved again.
+func (uc *AdminUseCase) ApproveRequest(ctx context.Context, actorId int64, userId int64) (*entity.AdminRequest, error) {
+	req, err := uc.pendingRequest(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = uc.grant(ctx, actorId, userId, "approved request")
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.review(ctx, req, actorId, entity.AdminRequestApproved)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (uc *AdminUseCase) RejectRequest(ctx context.Context, actorId int64, userId int64) (*entity.AdminRequest, error) {
+	req, err := uc.pendingRequest(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.review(ctx, req, actorId, entity.AdminRequestRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.audit(ctx, entity.AuditAdminRejected, actorId, userId, "")
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// RevokeAdmin takes the admin role away and ends the user's sessions. Admins
+// can't revoke themselves, so the platform can't be left without one by
+// accident.
+func (uc *AdminUseCase) RevokeAdmin(ctx context.Context, actorId int64, userId int64) (string, error) {
+	if actorId == userId {
+		return "", apperror.Forbidden("admins can't revoke their own role")
+	}
+
+	resp, err := uc.users.RemoveAdminRole(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.sessions.RevokeAllSessions(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.audit(ctx, entity.AuditAdminRevoked, actorId, userId, "")
+	if err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+// GrantCourier makes userId a courier. Users can't become couriers on their
+// own: couriers see customers' addresses.
+func (uc *AdminUseCase) GrantCourier(ctx context.Context, actorId int64, userId int64) (string, error) {
+	user, err := uc.users.GetMyProfile(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+	if user.IsCourier {
+		return "", apperror.Conflict("user is already a courier")
+	}
+
+	resp, err := uc.users.AddCourierRole(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.audit(ctx, entity.AuditCourierGranted, actorId, userId, "")
+	if err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+func (uc *AdminUseCase) ListAudit(ctx context.Context, limit int32, offset int32) ([]*entity.AuditEntry, error) {
+	entries, err := uc.store.ListAuditEntries(ctx, limit, offset)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return entries, nil
+}
+
+func (uc *AdminUseCase) pendingRequest(ctx context.Context, userId int64) (*entity.AdminRequest, error) {
+	req, err := uc.store.GetRequest(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if req == nil {
+		return nil, apperror.NotFound("admin request not found")
+	}
+	if req.Status != entity.AdminRequestPending {
+		return nil, apperror.Conflict(fmt.Sprintf("admin request was already %s", req.Status))
+	}
+	return req, nil
+}
+
+// review closes a pending request.
+func (uc *AdminUseCase) review(ctx context.Context, req *entity.AdminRequest, actorId int64, status entity.AdminRequestStatus) error {
+	now := time.Now()
+	req.Status = status
+	req.ReviewedBy = actorId
+	req.ReviewedAt = &now
+	err := uc.store.SaveRequest(ctx, req)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+func (uc *AdminUseCase) grant(ctx context.Context, actorId int64, userId int64, details string) (string, error) {
+	resp, err := uc.users.AddAdminRole(ctx, userId)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.audit(ctx, entity.AuditAdminGranted, actorId, userId, details)
+	if err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+func (uc *AdminUseCase) audit(ctx context.Context, action entity.AuditAction, actorId int64, userId int64, details string) error {
+	err := uc.store.AddAuditEntry(ctx, &entity.AuditEntry{
+		Action:     action,
+		ActorId:    actorId,
+		UserId:     userId,
+		Details:    details,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+type adminTest struct {
+	uc       *usecase.AdminUseCase
+	sessions *usecase.SessionUseCase
+	users    *memoryUsers
+	admin    *entity.User
+	user     *entity.User
+}
+
+func newAdminTest(t *testing.T) *adminTest {
+	t.Helper()
+
+	tokenMaker, err := token.NewJwtMaker("12345678912345678912345678912345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AccessTokenDuration:  time.Hour,
+		RefreshTokenDuration: 24 * time.Hour,
+		AdminInviteDuration:  time.Hour,
+	}
+	revocations := repo.NewRevocationMemoryStore()
+
+	test := &adminTest{users: newMemoryUsers()}
+	test.admin = test.users.add(&entity.User{Email: "admin@example.com", IsAdmin: true})
+	test.user = test.users.add(&entity.User{Email: "user@example.com"})
+	test.sessions = usecase.NewSessionUseCase(cfg, tokenMaker, revocations, repo.NewSessionMemoryStore(), test.users, newMemoryShops())
+	test.uc = usecase.NewAdminUseCase(cfg, test.users, test.sessions, tokenMaker, revocations, repo.NewAdminMemoryStore())
+	return test
+}
+
+// audit returns the actions in the audit log, oldest first.
+func (test *adminTest) audit(t *testing.T) []entity.AuditAction {
+	t.Helper()
+
+	entries, err := test.uc.ListAudit(context.Background(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make([]entity.AuditAction, len(entries))
+	for i, entry := range entries {
+		actions[len(entries)-1-i] = entry.Action
+	}
+	return actions
+}
+
+func TestRedeemInvite(t *testing.T) {
+	test := newAdminTest(t)
+	ctx := context.Background()
+
+	invite, err := test.uc.CreateInvite(ctx, test.admin.Id, test.user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = test.uc.CreateInvite(ctx, test.admin.Id, test.admin.Id)
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("CreateInvite for an admin returned %v, want a conflict", err)
+	}
+
+	_, err = test.uc.RedeemInvite(ctx, test.admin.Id, invite.Token)
+	if !apperror.Is(err, apperror.KindForbidden) {
+		t.Fatalf("RedeemInvite by another user returned %v, want forbidden", err)
+	}
+
+	// A grant the users service fails doesn't use up the invite.
+	test.users.failRoles = 1
+	_, err = test.uc.RedeemInvite(ctx, test.user.Id, invite.Token)
+	if !apperror.Is(err, apperror.KindUpstream) {
+		t.Fatalf("RedeemInvite returned %v, want the users service error", err)
+	}
+	if test.users.isAdmin(test.user.Id) {
+		t.Fatal("user is an admin after a failed grant")
+	}
+
+	_, err = test.uc.RedeemInvite(ctx, test.user.Id, invite.Token)
+	if err != nil {
+		t.Fatalf("RedeemInvite after a failed try: %v", err)
+	}
+	if !test.users.isAdmin(test.user.Id) {
+		t.Fatal("user isn't an admin after redeeming the invite")
+	}
+
+	_, err = test.uc.RedeemInvite(ctx, test.user.Id, invite.Token)
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("RedeemInvite of a redeemed invite returned %v, want a conflict", err)
+	}
+
+	want := []entity.AuditAction{entity.AuditAdminInvited, entity.AuditAdminGranted}
+	if got := test.audit(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("audit log is %v, want %v", got, want)
+	}
+}
+
+func TestRedeemInviteRefusesOtherTokens(t *testing.T) {
+	test := newAdminTest(t)
+
+	tokens, err := test.sessions.StartSession(context.Background(), test.user, &entity.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = test.uc.RedeemInvite(context.Background(), test.user.Id, tokens.AccessToken)
+	if !apperror.Is(err, apperror.KindForbidden) {
+		t.Fatalf("RedeemInvite of an access token returned %v, want forbidden", err)
+	}
+	if test.users.isAdmin(test.user.Id) {
+		t.Fatal("user is an admin after redeeming an access token")
+	}
+}
+
+func TestApproveRequest(t *testing.T) {
+	test := newAdminTest(t)
+	ctx := context.Background()
+
+	_, err := test.uc.ApproveRequest(ctx, test.admin.Id, test.user.Id)
+	if !apperror.Is(err, apperror.KindNotFound) {
+		t.Fatalf("ApproveRequest without a request returned %v, want not found", err)
+	}
+
+	_, err = test.uc.RequestAdmin(ctx, test.user.Id, "I run the support team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = test.uc.RequestAdmin(ctx, test.user.Id, "again")
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("RequestAdmin with a pending request returned %v, want a conflict", err)
+	}
+
+	// A grant the users service fails leaves the request pending.
+	test.users.failRoles = 1
+	_, err = test.uc.ApproveRequest(ctx, test.admin.Id, test.user.Id)
+	if !apperror.Is(err, apperror.KindUpstream) {
+		t.Fatalf("ApproveRequest returned %v, want the users service error", err)
+	}
+	pending, err := test.uc.ListRequests(ctx, entity.AdminRequestPending)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("ListRequests returned %d pending requests, %v, want the request", len(pending), err)
+	}
+
+	req, err := test.uc.ApproveRequest(ctx, test.admin.Id, test.user.Id)
+	if err != nil {
+		t.Fatalf("ApproveRequest after a failed try: %v", err)
+	}
+	if req.Status != entity.AdminRequestApproved || req.ReviewedBy != test.admin.Id || req.ReviewedAt == nil {
+		t.Fatalf("ApproveRequest returned %+v, want it approved by the admin", req)
+	}
+	if !test.users.isAdmin(test.user.Id) {
+		t.Fatal("user isn't an admin after the approval")
+	}
+
+	_, err = test.uc.ApproveRequest(ctx, test.admin.Id, test.user.Id)
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("ApproveRequest of an approved request returned %v, want a conflict", err)
+	}
+
+	want := []entity.AuditAction{entity.AuditAdminRequested, entity.AuditAdminGranted}
+	if got := test.audit(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("audit log is %v, want %v", got, want)
+	}
+}
+
+func TestRejectRequest(t *testing.T) {
+	test := newAdminTest(t)
+	ctx := context.Background()
+
+	_, err := test.uc.RequestAdmin(ctx, test.user.Id, "please")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := test.uc.RejectRequest(ctx, test.admin.Id, test.user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Status != entity.AdminRequestRejected {
+		t.Fatalf("RejectRequest returned %+v, want it rejected", req)
+	}
+	if test.users.isAdmin(test.user.Id) {
+		t.Fatal("user is an admin after the rejection")
+	}
+
+	_, err = test.uc.ApproveRequest(ctx, test.admin.Id, test.user.Id)
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("ApproveRequest of a rejected request returned %v, want a conflict", err)
+	}
+
+	// A rejected user may ask again.
+	_, err = test.uc.RequestAdmin(ctx, test.user.Id, "please, again")
+	if err != nil {
+		t.Fatalf("RequestAdmin after a rejection: %v", err)
+	}
+
+	want := []entity.AuditAction{entity.AuditAdminRequested, entity.AuditAdminRejected, entity.AuditAdminRequested}
+	if got := test.audit(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("audit log is %v, want %v", got, want)
+	}
+}
+
+func TestRevokeAdminEndsSessions(t *testing.T) {
+	test := newAdminTest(t)
+	ctx := context.Background()
+	other := test.users.add(&entity.User{Email: "other@example.com", IsAdmin: true})
+
+	tokens, err := test.sessions.StartSession(ctx, other, &entity.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = test.uc.RevokeAdmin(ctx, test.admin.Id, test.admin.Id)
+	if !apperror.Is(err, apperror.KindForbidden) {
+		t.Fatalf("RevokeAdmin of oneself returned %v, want forbidden", err)
+	}
+
+	_, err = test.uc.RevokeAdmin(ctx, test.admin.Id, other.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if test.users.isAdmin(other.Id) {
+		t.Fatal("user is still an admin after the revocation")
+	}
+	_, err = test.sessions.Authenticate(ctx, tokens.AccessToken)
+	if !apperror.Is(err, apperror.KindUnauthorized) {
+		t.Fatalf("Authenticate after the revocation returned %v, want the access token revoked", err)
+	}
+	sessions, err := test.sessions.ListSessions(ctx, other.Id, uuid.Nil)
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("ListSessions returned %d sessions, %v, want none", len(sessions), err)
+	}
+}
+
+func TestGrantCourier(t *testing.T) {
+	test := newAdminTest(t)
+	ctx := context.Background()
+
+	_, err := test.uc.GrantCourier(ctx, test.admin.Id, test.user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := test.users.GetMyProfile(ctx, test.user.Id)
+	if err != nil || !profile.IsCourier {
+		t.Fatalf("GetMyProfile returned %+v, %v, want a courier", profile, err)
+	}
+
+	entries, err := test.uc.ListAudit(ctx, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != entity.AuditCourierGranted || entries[0].ActorId != test.admin.Id || entries[0].UserId != test.user.Id {
+		t.Fatalf("audit log is %+v, want the grant by the admin", entries)
+	}
+
+	_, err = test.uc.GrantCourier(ctx, test.admin.Id, test.user.Id)
+	if !apperror.Is(err, apperror.KindConflict) {
+		t.Fatalf("GrantCourier of a courier returned %v, want a conflict", err)
+	}
+	_, err = test.uc.GrantCourier(ctx, test.admin.Id, 100)
+	if !apperror.Is(err, apperror.KindNotFound) {
+		t.Fatalf("GrantCourier of an unknown user returned %v, want not found", err)
+	}
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// _apiKeyPrefix makes keys easy to spot in configs and secret scanners.
+const _apiKeyPrefix = "gk_"
+
+// APIKeyUseCase manages the API keys shop owners give partners. A key is
+// "gk_<id>.<secret>"; the id finds it and only a hash of the secret is kept.
+type APIKeyUseCase struct {
+	config *config.Config
+	store  APIKeyStore
+}
+
+func NewAPIKeyUseCase(config *config.Config, store APIKeyStore) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		config: config,
+		store:  store,
+	}
+}
+
+// CreateAPIKey issues a key for the shop. The key is only returned here.
+func (uc *APIKeyUseCase) CreateAPIKey(ctx context.Context, actorId int64, shopId int64, req *entity.CreateAPIKey) (*entity.CreatedAPIKey, error) {
+	err := uc.validate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit := req.RateLimit
+	if rateLimit == 0 {
+		rateLimit = uc.config.APIKeyRateLimit
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+
+	key := entity.APIKey{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		ShopId:    shopId,
+		Scopes:    req.Scopes,
+		RateLimit: rateLimit,
+		CreatedBy: actorId,
+		CreatedAt: time.Now(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	err = uc.store.SaveAPIKey(ctx, &key, hashAPIKeySecret(encodedSecret))
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return &entity.CreatedAPIKey{
+		APIKey: key,
+		Key:    _apiKeyPrefix + key.ID + "." + encodedSecret,
+	}, nil
+}
+
+func (uc *APIKeyUseCase) ListAPIKeys(ctx context.Context, shopId int64) ([]*entity.APIKey, error) {
+	keys, err := uc.store.ListAPIKeys(ctx, shopId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key of the shop from working. Revoking it again
+// changes nothing.
+func (uc *APIKeyUseCase) RevokeAPIKey(ctx context.Context, shopId int64, id string) (*entity.APIKey, error) {
+	key, secretHash, err := uc.store.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if key == nil || key.ShopId != shopId {
+		return nil, apperror.NotFound("api key not found")
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	err = uc.store.SaveAPIKey(ctx, key, secretHash)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return key, nil
+}
+
+// Authenticate checks a key and returns the payload its requests act with:
+// the shop owner role in the key's shop only, limited to the key's scopes.
+func (uc *APIKeyUseCase) Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, *token.Payload, error) {
+	invalid := apperror.Unauthorized("invalid api key")
+
+	id, secret, ok := strings.Cut(strings.TrimPrefix(rawKey, _apiKeyPrefix), ".")
+	if !ok || !strings.HasPrefix(rawKey, _apiKeyPrefix) {
+		return nil, nil, invalid
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, invalid
+	}
+
+	key, secretHash, err := uc.store.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(secretHash)) != 1 {
+		return nil, nil, invalid
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, nil, apperror.Unauthorized("api key was revoked")
+	}
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, nil, apperror.Unauthorized("api key has expired")
+	}
+
+	// Keys without an expiry are good for the request.
+	expiredAt := now.Add(uc.config.RequestTimeout)
+	if key.ExpiresAt != nil {
+		expiredAt = *key.ExpiresAt
+	}
+
+	payload := &token.Payload{
+		ID:            uuid.MustParse(key.ID),
+		Type:          token.TokenTypeAPIKey,
+		UserId:        key.CreatedBy,
+		EmailVerified: true,
+		Roles:         []entity.RoleGrant{{Role: entity.RoleShopOwner, ShopId: key.ShopId}},
+		Scopes:        key.Scopes,
+		IssuedAt:      key.CreatedAt,
+		ExpiredAt:     expiredAt,
+	}
+	return key, payload, nil
+}
+
+func (uc *APIKeyUseCase) validate(req *entity.CreateAPIKey) error {
+	var fields []apperror.FieldError
+	if strings.TrimSpace(req.Name) == "" {
+		fields = append(fields, apperror.FieldError{Field: "name", Message: "is required"})
+	}
+	if len(req.Scopes) == 0 {
+		fields = append(fields, apperror.FieldError{Field: "scopes", Message: "needs at least one scope"})
+	}
+	for _, scope := range req.Scopes {
+		if !isAPIKeyScope(scope) {
+			fields = append(fields, apperror.FieldError{
+				Field:   "scopes",
+				Message: fmt.Sprintf("%q can't be given to api keys", scope),
+			})
+		}
+	}
+	if req.RateLimit < 0 || req.RateLimit > uc.config.APIKeyMaxRateLimit {
+		fields = append(fields, apperror.FieldError{
+			Field:   "rate_limit",
+			Message: fmt.Sprintf("must be between 1 and %d requests a minute", uc.config.APIKeyMaxRateLimit),
+		})
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		fields = append(fields, apperror.FieldError{Field: "expires_at", Message: "must be in the future"})
+	}
+
+	if len(fields) > 0 {
+		return apperror.Validation("invalid api key", fields...)
+	}
+	return nil
+}
+
+func isAPIKeyScope(permission entity.Permission) bool {
+	for _, scope := range entity.APIKeyScopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
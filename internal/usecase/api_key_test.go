@@ -0,0 +1,180 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+const (
+	apiKeyShop  = 7
+	apiKeyOwner = 3
+)
+
+func newAPIKeyUseCase() *usecase.APIKeyUseCase {
+	return usecase.NewAPIKeyUseCase(&config.Config{
+		RequestTimeout:     time.Second,
+		APIKeyRateLimit:    60,
+		APIKeyMaxRateLimit: 600,
+	}, repo.NewAPIKeyMemoryStore())
+}
+
+func createAPIKey(t *testing.T, uc *usecase.APIKeyUseCase, req *entity.CreateAPIKey) *entity.CreatedAPIKey {
+	t.Helper()
+
+	created, err := uc.CreateAPIKey(context.Background(), apiKeyOwner, apiKeyShop, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	uc := newAPIKeyUseCase()
+	ctx := context.Background()
+
+	created := createAPIKey(t, uc, &entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionMenuWrite}})
+	if created.RateLimit != 60 {
+		t.Errorf("key without a rate limit got %d requests a minute, want the configured 60", created.RateLimit)
+	}
+	if !strings.HasPrefix(created.Key, "gk_"+created.ID+".") {
+		t.Errorf("key %q doesn't start with gk_<id>.", created.Key)
+	}
+
+	created = createAPIKey(t, uc, &entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionMenuWrite}, RateLimit: 600})
+	if created.RateLimit != 600 {
+		t.Errorf("key got %d requests a minute, want the 600 asked for", created.RateLimit)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name  string
+		req   entity.CreateAPIKey
+		field string
+	}{
+		{"no name", entity.CreateAPIKey{Scopes: []entity.Permission{entity.PermissionMenuWrite}}, "name"},
+		{"no scopes", entity.CreateAPIKey{Name: "pos"}, "scopes"},
+		{"scope beyond the shop", entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionAPIKeysManage}}, "scopes"},
+		{"rate limit above the max", entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionMenuWrite}, RateLimit: 601}, "rate_limit"},
+		{"negative rate limit", entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionMenuWrite}, RateLimit: -1}, "rate_limit"},
+		{"expired", entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionMenuWrite}, ExpiresAt: &past}, "expires_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.CreateAPIKey(ctx, apiKeyOwner, apiKeyShop, &tt.req)
+			e := apperror.As(err)
+			if e == nil || e.Kind != apperror.KindValidation || len(e.Fields) != 1 || e.Fields[0].Field != tt.field {
+				t.Fatalf("CreateAPIKey returned %v, want a validation error of %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	uc := newAPIKeyUseCase()
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	created := createAPIKey(t, uc, &entity.CreateAPIKey{
+		Name:      "pos",
+		Scopes:    []entity.Permission{entity.PermissionMenuWrite},
+		ExpiresAt: &expiresAt,
+	})
+
+	key, payload, err := uc.Authenticate(ctx, created.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID != created.ID {
+		t.Errorf("Authenticate returned key %s, want %s", key.ID, created.ID)
+	}
+	if payload.Type != token.TokenTypeAPIKey || payload.UserId != apiKeyOwner || !payload.ExpiredAt.Equal(expiresAt) {
+		t.Errorf("Authenticate returned payload %+v, want an api key payload of user %d expiring at %v", payload, apiKeyOwner, expiresAt)
+	}
+
+	// The key acts as the shop owner, but only within its scopes and shop.
+	permissions := []struct {
+		permission entity.Permission
+		shopId     int64
+		want       bool
+	}{
+		{entity.PermissionMenuWrite, apiKeyShop, true},
+		{entity.PermissionMenuWrite, apiKeyShop + 1, false},
+		{entity.PermissionOrdersManage, apiKeyShop, false},
+		{entity.PermissionAPIKeysManage, apiKeyShop, false},
+		{entity.PermissionShopCreate, 0, false},
+	}
+	for _, p := range permissions {
+		if got := payload.HasPermission(p.permission, p.shopId); got != p.want {
+			t.Errorf("HasPermission(%s, %d) = %v, want %v", p.permission, p.shopId, got, p.want)
+		}
+	}
+
+	id, secret, _ := strings.Cut(strings.TrimPrefix(created.Key, "gk_"), ".")
+	for name, rawKey := range map[string]string{
+		"no prefix":    id + "." + secret,
+		"no secret":    "gk_" + id,
+		"bad id":       "gk_not-a-uuid." + secret,
+		"unknown id":   "gk_00000000-0000-0000-0000-000000000001." + secret,
+		"wrong secret": "gk_" + id + ".wrong",
+	} {
+		_, _, err := uc.Authenticate(ctx, rawKey)
+		if !apperror.Is(err, apperror.KindUnauthorized) {
+			t.Errorf("%s: Authenticate returned %v, want an unauthorized error", name, err)
+		}
+	}
+}
+
+func TestAuthenticateAPIKeyWithoutExpiry(t *testing.T) {
+	uc := newAPIKeyUseCase()
+	created := createAPIKey(t, uc, &entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionOrdersManage}})
+
+	_, payload, err := uc.Authenticate(context.Background(), created.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The payload is good for the request.
+	if until := time.Until(payload.ExpiredAt); until <= 0 || until > time.Second {
+		t.Errorf("payload expires in %v, want within the request timeout", until)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	uc := newAPIKeyUseCase()
+	ctx := context.Background()
+	created := createAPIKey(t, uc, &entity.CreateAPIKey{Name: "pos", Scopes: []entity.Permission{entity.PermissionMenuWrite}})
+	other := createAPIKey(t, uc, &entity.CreateAPIKey{Name: "other", Scopes: []entity.Permission{entity.PermissionMenuWrite}})
+
+	_, err := uc.RevokeAPIKey(ctx, apiKeyShop+1, created.ID)
+	if !apperror.Is(err, apperror.KindNotFound) {
+		t.Fatalf("RevokeAPIKey of another shop's key returned %v, want not found", err)
+	}
+
+	revoked, err := uc.RevokeAPIKey(ctx, apiKeyShop, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked.RevokedAt == nil {
+		t.Fatal("RevokeAPIKey returned a key without RevokedAt")
+	}
+	again, err := uc.RevokeAPIKey(ctx, apiKeyShop, created.ID)
+	if err != nil || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("revoking again returned %+v, %v, want the key revoked at the first time", again, err)
+	}
+
+	_, _, err = uc.Authenticate(ctx, created.Key)
+	if !apperror.Is(err, apperror.KindUnauthorized) {
+		t.Errorf("Authenticate with a revoked key returned %v, want an unauthorized error", err)
+	}
+	_, _, err = uc.Authenticate(ctx, other.Key)
+	if err != nil {
+		t.Errorf("Authenticate with another key of the shop: %v", err)
+	}
+}
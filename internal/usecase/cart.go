@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+type CartUseCase struct {
+	config *config.Config
+	store  CartStore
+	shops  Shop
+	orders Order
+	l      logger.Interface
+}
+
+func NewCartUseCase(config *config.Config, store CartStore, shops Shop, orders Order, l logger.Interface) *CartUseCase {
+	return &CartUseCase{
+		config: config,
+		store:  store,
+		shops:  shops,
+		orders: orders,
+		l:      l,
+	}
+}
+
+// GetCart returns the cart with prices refreshed from the shop's menu and
+// the items that left it flagged unavailable.
+func (uc *CartUseCase) GetCart(ctx context.Context, userId int64) (*entity.Cart, error) {
+	cart, err := uc.store.Get(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	err = uc.reprice(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (uc *CartUseCase) AddItem(ctx context.Context, userId int64, menuItemId int64, quantity int32) (*entity.Cart, error) {
+	menuItem, err := uc.shops.GetMenuItem(ctx, menuItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := uc.store.Get(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	if len(cart.Items) > 0 && cart.ShopId != menuItem.ShopId {
+		return nil, apperror.Conflict("cart already contains items from another shop")
+	}
+	cart.ShopId = menuItem.ShopId
+
+	i := findCartItem(cart, menuItemId)
+	if i < 0 {
+		if len(cart.Items) >= entity.MaxOrderItems {
+			return nil, apperror.Validation("cart is full", apperror.FieldError{
+				Field:   "menu_item_id",
+				Message: fmt.Sprintf("a cart holds at most %d different items", entity.MaxOrderItems),
+			})
+		}
+		cart.Items = append(cart.Items, entity.CartItem{OrderItem: entity.OrderItem{MenuItemId: menuItemId}})
+		i = len(cart.Items) - 1
+	}
+	total := int64(cart.Items[i].Quantity) + int64(quantity)
+	if total < 1 || total > entity.MaxItemQuantity {
+		return nil, quantityError()
+	}
+	cart.Items[i].Quantity = int32(total)
+
+	return uc.save(ctx, cart)
+}
+
+func (uc *CartUseCase) UpdateItemQuantity(ctx context.Context, userId int64, menuItemId int64, quantity int32) (*entity.Cart, error) {
+	if quantity < 1 || quantity > entity.MaxItemQuantity {
+		return nil, quantityError()
+	}
+
+	cart, err := uc.store.Get(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	i := findCartItem(cart, menuItemId)
+	if i < 0 {
+		return nil, apperror.NotFound("item is not in the cart")
+	}
+	cart.Items[i].Quantity = quantity
+
+	return uc.save(ctx, cart)
+}
+
+func (uc *CartUseCase) RemoveItem(ctx context.Context, userId int64, menuItemId int64) (*entity.Cart, error) {
+	cart, err := uc.store.Get(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	i := findCartItem(cart, menuItemId)
+	if i < 0 {
+		return nil, apperror.NotFound("item is not in the cart")
+	}
+	cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+
+	if len(cart.Items) == 0 {
+		err = uc.ClearCart(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.Cart{UserId: userId, Items: []entity.CartItem{}}, nil
+	}
+	return uc.save(ctx, cart)
+}
+
+func (uc *CartUseCase) ClearCart(ctx context.Context, userId int64) error {
+	err := uc.store.Delete(ctx, userId)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// Checkout places an order with the cart's items, delivered to
+// deliveryLocation, and empties the cart.
+func (uc *CartUseCase) Checkout(ctx context.Context, userId int64, deliveryLocation entity.Location) (*entity.Order, error) {
+	cart, err := uc.store.Get(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if len(cart.Items) == 0 {
+		return nil, apperror.Validation("cart is empty")
+	}
+
+	items := make([]entity.OrderItem, len(cart.Items))
+	for i, item := range cart.Items {
+		items[i] = entity.OrderItem{
+			MenuItemId: item.MenuItemId,
+			Quantity:   item.Quantity,
+		}
+	}
+
+	order, err := uc.orders.CreateOrder(ctx, &entity.CreateOrder{
+		UserId:           userId,
+		ShopId:           cart.ShopId,
+		Items:            items,
+		DeliveryLocation: deliveryLocation,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The order is placed either way; a cart left behind is only in the way.
+	err = uc.ClearCart(ctx, userId)
+	if err != nil {
+		uc.l.Error(err, "usecase - cart - Checkout - ClearCart")
+	}
+	return order, nil
+}
+
+func (uc *CartUseCase) save(ctx context.Context, cart *entity.Cart) (*entity.Cart, error) {
+	err := uc.reprice(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+
+	cart.UpdatedAt = time.Now()
+	err = uc.store.Save(ctx, cart)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return cart, nil
+}
+
+// reprice loads the current name and price of every item from the shop's
+// menu and recomputes the total. Items missing from the menu, or all of them
+// once the shop is gone, are flagged unavailable and left out of the total.
+func (uc *CartUseCase) reprice(ctx context.Context, cart *entity.Cart) error {
+	cart.TotalPrice = 0
+	if len(cart.Items) == 0 {
+		return nil
+	}
+
+	menu, err := uc.shops.GetMenu(ctx, cart.ShopId)
+	if err != nil && !apperror.Is(err, apperror.KindNotFound) {
+		return err
+	}
+	menuItems := make(map[int64]*entity.GetMenuItem, len(menu))
+	for _, menuItem := range menu {
+		menuItems[menuItem.ID] = menuItem
+	}
+
+	available := make([]entity.OrderItem, 0, len(cart.Items))
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		menuItem, ok := menuItems[item.MenuItemId]
+		item.Unavailable = !ok
+		if !ok {
+			continue
+		}
+		item.Name = menuItem.Name
+		item.Price = menuItem.Price
+		available = append(available, item.OrderItem)
+	}
+
+	total, ok := itemsTotal(available)
+	if !ok {
+		return apperror.Validation("cart total is too large", apperror.FieldError{
+			Field:   "quantity",
+			Message: "total price is too large",
+		})
+	}
+	cart.TotalPrice = total
+	return nil
+}
+
+func quantityError() error {
+	return apperror.Validation("invalid quantity", apperror.FieldError{
+		Field:   "quantity",
+		Message: fmt.Sprintf("must be between 1 and %d per item", entity.MaxItemQuantity),
+	})
+}
+
+func findCartItem(cart *entity.Cart, menuItemId int64) int {
+	for i := range cart.Items {
+		if cart.Items[i].MenuItemId == menuItemId {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,261 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/pkg/logger"
+)
+
+// errorLog keeps the errors logged to it.
+type errorLog struct {
+	logger.Interface
+
+	mu     sync.Mutex
+	errors []string
+}
+
+func (log *errorLog) Error(message interface{}, args ...interface{}) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.errors = append(log.errors, fmt.Sprint(append([]interface{}{message}, args...)...))
+}
+
+func newCartUseCase(shops *memoryShops) *usecase.CartUseCase {
+	return newCartUseCaseWith(shops, repo.NewCartMemoryStore(), &errorLog{})
+}
+
+func newCartUseCaseWith(shops *memoryShops, store usecase.CartStore, l logger.Interface) *usecase.CartUseCase {
+	cfg := &config.Config{}
+	shopUseCase := usecase.NewShopUseCase(cfg, shops, nopPublisher{})
+	return usecase.NewCartUseCase(cfg, store, shopUseCase, newOrderUseCase(shops), l)
+}
+
+func TestCartQuantityLimit(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, 100)
+	uc := newCartUseCase(shops)
+	ctx := context.Background()
+
+	_, err := uc.AddItem(ctx, 1, items[0], entity.MaxItemQuantity-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart, err := uc.AddItem(ctx, 1, items[0], 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.Items[0].Quantity != entity.MaxItemQuantity {
+		t.Fatalf("cart line has %d items, want %d", cart.Items[0].Quantity, entity.MaxItemQuantity)
+	}
+
+	_, err = uc.AddItem(ctx, 1, items[0], 1)
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("AddItem over the limit returned %v, want a validation error", err)
+	}
+	_, err = uc.AddItem(ctx, 1, items[0], math.MaxInt32)
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("AddItem of MaxInt32 returned %v, want a validation error", err)
+	}
+	_, err = uc.UpdateItemQuantity(ctx, 1, items[0], entity.MaxItemQuantity+1)
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("UpdateItemQuantity over the limit returned %v, want a validation error", err)
+	}
+
+	cart, err = uc.GetCart(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.Items[0].Quantity != entity.MaxItemQuantity || cart.TotalPrice != 100*entity.MaxItemQuantity {
+		t.Fatalf("refused changes left the cart at %+v", cart)
+	}
+}
+
+func TestCartTotalOverflow(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, math.MaxInt32/2, math.MaxInt32/2)
+	uc := newCartUseCase(shops)
+	ctx := context.Background()
+
+	cart, err := uc.AddItem(ctx, 1, items[0], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.TotalPrice != math.MaxInt32-1 {
+		t.Fatalf("cart total is %d, want %d", cart.TotalPrice, math.MaxInt32-1)
+	}
+
+	_, err = uc.AddItem(ctx, 1, items[1], 1)
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("AddItem past the largest total returned %v, want a validation error", err)
+	}
+}
+
+func TestCartRepricesFromOneMenu(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, 100, 200, 300)
+	uc := newCartUseCase(shops)
+	ctx := context.Background()
+
+	for _, item := range items {
+		_, err := uc.AddItem(ctx, 1, item, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	shops.addMenuItem(&entity.GetMenuItem{ID: items[0], Name: "item", Price: 150, ShopId: 1})
+
+	reads := shops.menuReads
+	cart, err := uc.GetCart(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shops.menuReads-reads != 1 {
+		t.Fatalf("GetCart of %d items read the menu %d times, want once", len(items), shops.menuReads-reads)
+	}
+	if cart.TotalPrice != 650 {
+		t.Fatalf("cart total is %d, want the new price in it: 650", cart.TotalPrice)
+	}
+
+}
+
+func TestCartFlagsItemsGoneFromTheMenu(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, 100, 200, 300)
+	uc := newCartUseCase(shops)
+	ctx := context.Background()
+
+	for _, item := range items {
+		_, err := uc.AddItem(ctx, 1, item, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shops.mu.Lock()
+	delete(shops.menu, items[1])
+	shops.mu.Unlock()
+	cart, err := uc.GetCart(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetCart with an item gone from the menu: %v", err)
+	}
+	for i, item := range cart.Items {
+		if item.Unavailable != (i == 1) {
+			t.Errorf("item %d has Unavailable %v, want %v", item.MenuItemId, item.Unavailable, i == 1)
+		}
+	}
+	if cart.Items[1].Price != 200 {
+		t.Errorf("unavailable item lost its last price: %+v", cart.Items[1])
+	}
+	if cart.TotalPrice != 400 {
+		t.Errorf("cart total is %d, want the available items only: 400", cart.TotalPrice)
+	}
+
+	// The cart can still be changed, and removing the item makes it whole
+	// again.
+	cart, err = uc.UpdateItemQuantity(ctx, 1, items[0], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.TotalPrice != 500 || !cart.Items[1].Unavailable {
+		t.Errorf("UpdateItemQuantity returned %+v, want a total of 500 and item %d still flagged", cart, items[1])
+	}
+	cart, err = uc.RemoveItem(ctx, 1, items[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range cart.Items {
+		if item.Unavailable {
+			t.Errorf("item %d is flagged unavailable after the gone one was removed", item.MenuItemId)
+		}
+	}
+
+	// Without the shop nothing in the cart is available.
+	shops.mu.Lock()
+	shops.menu = make(map[int64]*entity.GetMenuItem)
+	shops.mu.Unlock()
+	cart, err = uc.GetCart(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range cart.Items {
+		if !item.Unavailable {
+			t.Errorf("item %d of a shop without a menu isn't flagged", item.MenuItemId)
+		}
+	}
+	if cart.TotalPrice != 0 {
+		t.Errorf("cart total is %d, want 0", cart.TotalPrice)
+	}
+}
+
+func TestCartLineLimit(t *testing.T) {
+	shops := newMemoryShops()
+	prices := make([]int32, entity.MaxOrderItems+1)
+	for i := range prices {
+		prices[i] = 100
+	}
+	items := newTestShop(shops, 1, prices...)
+	uc := newCartUseCase(shops)
+	ctx := context.Background()
+
+	for _, item := range items[:entity.MaxOrderItems] {
+		_, err := uc.AddItem(ctx, 1, item, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := uc.AddItem(ctx, 1, items[entity.MaxOrderItems], 1)
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("AddItem of line %d returned %v, want a validation error", entity.MaxOrderItems+1, err)
+	}
+	cart, err := uc.AddItem(ctx, 1, items[0], 1)
+	if err != nil {
+		t.Fatalf("AddItem to a line of a full cart: %v", err)
+	}
+	if len(cart.Items) != entity.MaxOrderItems {
+		t.Fatalf("cart has %d lines, want %d", len(cart.Items), entity.MaxOrderItems)
+	}
+}
+
+// undeletableCarts fails to delete carts.
+type undeletableCarts struct {
+	*repo.CartMemoryStore
+}
+
+func (undeletableCarts) Delete(ctx context.Context, userId int64) error {
+	return errors.New("cart store is down")
+}
+
+func TestCheckoutKeepsOrderWhenCartStays(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, 100)
+	log := &errorLog{}
+	uc := newCartUseCaseWith(shops, undeletableCarts{repo.NewCartMemoryStore()}, log)
+	ctx := context.Background()
+
+	_, err := uc.AddItem(ctx, 1, items[0], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := uc.Checkout(ctx, 1, testShopLocation)
+	if err != nil {
+		t.Fatalf("Checkout with a cart that can't be cleared: %v", err)
+	}
+	if order == nil || order.TotalPrice != 200 {
+		t.Fatalf("Checkout returned %+v, want the placed order", order)
+	}
+	if len(log.errors) != 1 {
+		t.Fatalf("Checkout logged %v, want the failure to clear the cart", log.errors)
+	}
+}
@@ -0,0 +1,548 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// DeliveryTopics are the routing keys of the order events the dispatcher
+// reacts to.
+var DeliveryTopics = []string{
+	entity.OrderEventType(entity.OrderStatusReady),
+	entity.OrderEventType(entity.OrderStatusCancelled),
+}
+
+type DeliveryUseCase struct {
+	config    *config.Config
+	store     CourierStore
+	orders    Order
+	shops     Shop
+	publisher EventPublisher
+}
+
+func NewDeliveryUseCase(config *config.Config, store CourierStore, orders Order, shops Shop, publisher EventPublisher) *DeliveryUseCase {
+	return &DeliveryUseCase{
+		config:    config,
+		store:     store,
+		orders:    orders,
+		shops:     shops,
+		publisher: publisher,
+	}
+}
+
+// GetCourier returns the courier with the delivery offered to or accepted by
+// them.
+func (uc *DeliveryUseCase) GetCourier(ctx context.Context, userId int64) (*entity.CourierState, error) {
+	courier, err := uc.store.GetCourier(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	state := &entity.CourierState{Courier: courier}
+	if courier.OrderId != 0 {
+		state.Delivery, err = uc.store.GetDelivery(ctx, courier.OrderId)
+		if err != nil {
+			return nil, apperror.Internal(err)
+		}
+	}
+	return state, nil
+}
+
+func (uc *DeliveryUseCase) GoOnline(ctx context.Context, userId int64, location entity.Location) (*entity.Courier, error) {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	courier, err := uc.store.GetCourier(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	now := time.Now()
+	if courier.Status == entity.CourierStatusOffline {
+		courier.Status = entity.CourierStatusAvailable
+		courier.UpdatedAt = now
+	}
+	courier.Location = location
+	courier.LocationUpdatedAt = now
+
+	err = uc.store.SaveCourier(ctx, courier)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return courier, nil
+}
+
+// GoOffline takes the courier out of dispatch. A pending offer counts as
+// rejected; an accepted delivery has to be finished first.
+func (uc *DeliveryUseCase) GoOffline(ctx context.Context, userId int64) (*entity.Courier, error) {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	courier, err := uc.store.GetCourier(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	switch courier.Status {
+	case entity.CourierStatusDelivering:
+		return nil, apperror.Conflict("finish the current delivery before going offline")
+	case entity.CourierStatusOffered:
+		delivery, err := uc.store.GetDelivery(ctx, courier.OrderId)
+		if err != nil {
+			return nil, apperror.Internal(err)
+		}
+		if delivery != nil {
+			err = uc.decline(ctx, delivery, courier, entity.DeliveryEventRejected)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	courier.Status = entity.CourierStatusOffline
+	courier.OrderId = 0
+	courier.UpdatedAt = time.Now()
+	err = uc.store.SaveCourier(ctx, courier)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return courier, nil
+}
+
+// UpdateLocation stores a GPS ping. While delivering, the position is
+// published for the customer following the order.
+func (uc *DeliveryUseCase) UpdateLocation(ctx context.Context, userId int64, location entity.Location) (*entity.Courier, error) {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	courier, err := uc.store.GetCourier(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if courier.Status == entity.CourierStatusOffline {
+		return nil, apperror.Conflict("go online before reporting a location")
+	}
+
+	courier.Location = location
+	courier.LocationUpdatedAt = time.Now()
+	err = uc.store.SaveCourier(ctx, courier)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	if courier.Status == entity.CourierStatusDelivering {
+		_ = uc.publisher.Publish(ctx, entity.CourierLocationEventType, entity.CourierLocationEvent{
+			ID:         uuid.NewString(),
+			Type:       entity.CourierLocationEventType,
+			OrderId:    courier.OrderId,
+			CourierId:  courier.UserId,
+			Lat:        location.Lat,
+			Lng:        location.Lng,
+			RecordedAt: courier.LocationUpdatedAt,
+		})
+	}
+	return courier, nil
+}
+
+func (uc *DeliveryUseCase) AcceptDelivery(ctx context.Context, orderId int64, userId int64) (*entity.Delivery, error) {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	delivery, courier, err := uc.offer(ctx, orderId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(delivery.ExpiresAt) {
+		return nil, apperror.Conflict("the offer has expired")
+	}
+
+	now := time.Now()
+	delivery.Status = entity.DeliveryStatusAccepted
+	delivery.UpdatedAt = now
+	courier.Status = entity.CourierStatusDelivering
+	courier.UpdatedAt = now
+
+	err = uc.save(ctx, delivery, courier)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publish(ctx, entity.DeliveryEventAccepted, delivery, courier.UserId)
+	return delivery, nil
+}
+
+func (uc *DeliveryUseCase) RejectDelivery(ctx context.Context, orderId int64, userId int64) (*entity.Delivery, error) {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	delivery, courier, err := uc.offer(ctx, orderId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.decline(ctx, delivery, courier, entity.DeliveryEventRejected)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// UpdateDeliveryStatus lets the courier of an accepted delivery mark the
+// order picked up and then delivered, which frees the courier. The orders
+// service is called without holding the dispatch lock, so the delivery is
+// checked again under it before the result is applied.
+func (uc *DeliveryUseCase) UpdateDeliveryStatus(ctx context.Context, orderId int64, userId int64, status entity.OrderStatus) (*entity.Delivery, error) {
+	_, err := uc.courierDelivery(ctx, orderId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = uc.orders.UpdateDeliveryStatus(ctx, orderId, status)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	delivery, err := uc.courierDelivery(ctx, orderId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery.UpdatedAt = now
+	if status == entity.OrderStatusPickedUp {
+		delivery.Status = entity.DeliveryStatusPickedUp
+		err = uc.store.SaveDelivery(ctx, delivery)
+		if err != nil {
+			return nil, apperror.Internal(err)
+		}
+		return delivery, nil
+	}
+
+	delivery.Status = entity.DeliveryStatusDelivered
+	courier, err := uc.store.GetCourier(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	courier.Status = entity.CourierStatusAvailable
+	courier.OrderId = 0
+	courier.UpdatedAt = now
+	err = uc.store.SaveCourier(ctx, courier)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	err = uc.store.DeleteDelivery(ctx, orderId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return delivery, nil
+}
+
+// Dispatch handles an order event received from the broker: ready orders wait
+// for a courier, cancelled ones are withdrawn.
+func (uc *DeliveryUseCase) Dispatch(routingKey string, body []byte) error {
+	ready := routingKey == entity.OrderEventType(entity.OrderStatusReady)
+	cancelled := routingKey == entity.OrderEventType(entity.OrderStatusCancelled)
+	if !ready && !cancelled {
+		return nil
+	}
+
+	var event entity.OrderEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if ready {
+		return uc.enqueue(ctx, &event)
+	}
+	return uc.withdraw(ctx, event.OrderId)
+}
+
+// Assign runs one dispatch round: offers nobody answered in time go to the
+// next courier, and every waiting delivery is offered to the nearest
+// available courier that hasn't declined it.
+func (uc *DeliveryUseCase) Assign(ctx context.Context) error {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := time.Now()
+	offered, err := uc.store.ListDeliveries(ctx, entity.DeliveryStatusOffered)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range offered {
+		if now.Before(delivery.ExpiresAt) {
+			continue
+		}
+		courier, err := uc.store.GetCourier(ctx, delivery.CourierId)
+		if err != nil {
+			return err
+		}
+		err = uc.decline(ctx, delivery, courier, entity.DeliveryEventExpired)
+		if err != nil {
+			return err
+		}
+	}
+
+	pending, err := uc.store.ListDeliveries(ctx, entity.DeliveryStatusPending)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	available, err := uc.store.ListCouriers(ctx, entity.CourierStatusAvailable)
+	if err != nil {
+		return err
+	}
+	couriers := available[:0]
+	for _, courier := range available {
+		if uc.config.CourierLocationTTL <= 0 || now.Sub(courier.LocationUpdatedAt) <= uc.config.CourierLocationTTL {
+			couriers = append(couriers, courier)
+		}
+	}
+
+	for _, delivery := range pending {
+		if len(couriers) == 0 {
+			return nil
+		}
+
+		i := nearestCourier(delivery, couriers)
+		if i < 0 {
+			// Everybody around has declined; start over with the next round.
+			delivery.Declined = nil
+			err = uc.store.SaveDelivery(ctx, delivery)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		courier := couriers[i]
+		couriers = append(couriers[:i], couriers[i+1:]...)
+
+		delivery.Status = entity.DeliveryStatusOffered
+		delivery.CourierId = courier.UserId
+		delivery.OfferedAt = now
+		delivery.ExpiresAt = now.Add(uc.config.DeliveryOfferTimeout)
+		delivery.UpdatedAt = now
+		courier.Status = entity.CourierStatusOffered
+		courier.OrderId = delivery.OrderId
+		courier.UpdatedAt = now
+
+		err = uc.save(ctx, delivery, courier)
+		if err != nil {
+			return err
+		}
+		uc.publish(ctx, entity.DeliveryEventOffered, delivery, courier.UserId)
+	}
+	return nil
+}
+
+// nearestCourier picks the courier closest to the pickup. Without a known
+// pickup location the courier who has been waiting longest gets the order.
+// It returns -1 when every courier has declined the delivery.
+func nearestCourier(delivery *entity.Delivery, couriers []*entity.Courier) int {
+	best := -1
+	bestDistance := math.Inf(1)
+	for i, courier := range couriers {
+		if delivery.HasDeclined(courier.UserId) {
+			continue
+		}
+		if delivery.Pickup.IsZero() {
+			return i
+		}
+		distance := delivery.Pickup.DistanceTo(courier.Location)
+		if distance < bestDistance {
+			best = i
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+func (uc *DeliveryUseCase) enqueue(ctx context.Context, event *entity.OrderEvent) error {
+	// The shop is looked up before locking, it is a call to another service.
+	var pickup entity.Location
+	shop, shopErr := uc.shops.GetShop(ctx, event.ShopId)
+	if shopErr == nil {
+		pickup = shop.Location
+	}
+
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := uc.store.GetDelivery(ctx, event.OrderId)
+	if err != nil || existing != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = uc.store.SaveDelivery(ctx, &entity.Delivery{
+		OrderId:   event.OrderId,
+		ShopId:    event.ShopId,
+		Pickup:    pickup,
+		Status:    entity.DeliveryStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	if shopErr != nil {
+		return fmt.Errorf("order %d is dispatched without the shop location: %w", event.OrderId, shopErr)
+	}
+	return nil
+}
+
+func (uc *DeliveryUseCase) withdraw(ctx context.Context, orderId int64) error {
+	unlock, err := uc.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	delivery, err := uc.store.GetDelivery(ctx, orderId)
+	if err != nil || delivery == nil {
+		return err
+	}
+
+	if delivery.CourierId != 0 {
+		courier, err := uc.store.GetCourier(ctx, delivery.CourierId)
+		if err != nil {
+			return err
+		}
+		if courier.OrderId == orderId {
+			courier.Status = entity.CourierStatusAvailable
+			courier.OrderId = 0
+			courier.UpdatedAt = time.Now()
+			err = uc.store.SaveCourier(ctx, courier)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return uc.store.DeleteDelivery(ctx, orderId)
+}
+
+// courierDelivery returns the delivery the courier accepted and hasn't
+// finished.
+func (uc *DeliveryUseCase) courierDelivery(ctx context.Context, orderId int64, userId int64) (*entity.Delivery, error) {
+	delivery, err := uc.store.GetDelivery(ctx, orderId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if delivery == nil || delivery.CourierId != userId {
+		return nil, apperror.NotFound("delivery not found")
+	}
+	if delivery.Status != entity.DeliveryStatusAccepted && delivery.Status != entity.DeliveryStatusPickedUp {
+		return nil, apperror.Conflict("the delivery hasn't been accepted")
+	}
+	return delivery, nil
+}
+
+// offer returns the delivery currently offered to the courier.
+func (uc *DeliveryUseCase) offer(ctx context.Context, orderId int64, userId int64) (*entity.Delivery, *entity.Courier, error) {
+	delivery, err := uc.store.GetDelivery(ctx, orderId)
+	if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+	if delivery == nil || delivery.Status != entity.DeliveryStatusOffered || delivery.CourierId != userId {
+		return nil, nil, apperror.Conflict("the order isn't offered to you")
+	}
+
+	courier, err := uc.store.GetCourier(ctx, userId)
+	if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+	return delivery, courier, nil
+}
+
+// decline puts the delivery back in the queue without the courier and makes
+// the courier available for other orders.
+func (uc *DeliveryUseCase) decline(ctx context.Context, delivery *entity.Delivery, courier *entity.Courier, eventType string) error {
+	now := time.Now()
+	delivery.Status = entity.DeliveryStatusPending
+	delivery.Declined = append(delivery.Declined, courier.UserId)
+	delivery.CourierId = 0
+	delivery.UpdatedAt = now
+
+	if courier.OrderId == delivery.OrderId {
+		courier.Status = entity.CourierStatusAvailable
+		courier.OrderId = 0
+		courier.UpdatedAt = now
+	}
+
+	err := uc.save(ctx, delivery, courier)
+	if err != nil {
+		return err
+	}
+
+	uc.publish(ctx, eventType, delivery, courier.UserId)
+	return nil
+}
+
+// lock takes the dispatch lock of the store.
+func (uc *DeliveryUseCase) lock(ctx context.Context) (func(), error) {
+	unlock, err := uc.store.Lock(ctx)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return unlock, nil
+}
+
+func (uc *DeliveryUseCase) save(ctx context.Context, delivery *entity.Delivery, courier *entity.Courier) error {
+	err := uc.store.SaveDelivery(ctx, delivery)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	err = uc.store.SaveCourier(ctx, courier)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+func (uc *DeliveryUseCase) publish(ctx context.Context, eventType string, delivery *entity.Delivery, courierId int64) {
+	_ = uc.publisher.Publish(ctx, eventType, entity.DeliveryEvent{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OrderId:    delivery.OrderId,
+		ShopId:     delivery.ShopId,
+		CourierId:  courierId,
+		OccurredAt: time.Now(),
+	})
+}
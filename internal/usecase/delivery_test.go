@@ -0,0 +1,186 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+// blockingOrders is the orders usecase as the delivery usecase sees it.
+// UpdateDeliveryStatus reports on called and then waits for release.
+type blockingOrders struct {
+	usecase.Order
+
+	called  chan struct{}
+	release chan struct{}
+}
+
+func (orders *blockingOrders) UpdateDeliveryStatus(ctx context.Context, id int64, status entity.OrderStatus) (*entity.Order, error) {
+	if orders.called != nil {
+		orders.called <- struct{}{}
+		<-orders.release
+	}
+	return &entity.Order{ID: id, Status: status}, nil
+}
+
+func newDeliveryUseCase(store usecase.CourierStore, shops *memoryShops, orders usecase.Order) *usecase.DeliveryUseCase {
+	cfg := &config.Config{DeliveryOfferTimeout: time.Minute}
+	shopUseCase := usecase.NewShopUseCase(cfg, shops, nopPublisher{})
+	return usecase.NewDeliveryUseCase(cfg, store, orders, shopUseCase, nopPublisher{})
+}
+
+func orderReady(t *testing.T, uc *usecase.DeliveryUseCase, orderId int64, shopId int64) {
+	t.Helper()
+
+	body, err := json.Marshal(entity.OrderEvent{OrderId: orderId, ShopId: shopId, To: entity.OrderStatusReady})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uc.Dispatch(entity.OrderEventType(entity.OrderStatusReady), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAssignAcrossInstances runs the dispatchers of two gateway instances
+// sharing a Redis store at once: every order is offered to one courier and no
+// courier gets two offers.
+func TestAssignAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	shops := newMemoryShops()
+	newTestShop(shops, 1)
+
+	instances := make([]*usecase.DeliveryUseCase, 2)
+	stores := make([]*repo.CourierRedisStore, 2)
+	for i := range instances {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		stores[i] = repo.NewCourierRedisStore(client, time.Minute)
+		instances[i] = newDeliveryUseCase(stores[i], shops, &blockingOrders{})
+	}
+	ctx := context.Background()
+
+	const couriers, orders = 20, 30
+	for id := int64(1); id <= couriers; id++ {
+		_, err := instances[id%2].GoOnline(ctx, id, testShopLocation)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id := int64(1); id <= orders; id++ {
+		orderReady(t, instances[id%2], 100+id, 1)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(uc *usecase.DeliveryUseCase) {
+			defer wg.Done()
+			for round := 0; round < 5; round++ {
+				err := uc.Assign(ctx)
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}(instances[i%2])
+	}
+	wg.Wait()
+
+	offered := make(map[int64]int64)
+	for id := int64(1); id <= couriers; id++ {
+		state, err := instances[0].GetCourier(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Courier.Status != entity.CourierStatusOffered || state.Delivery == nil {
+			t.Fatalf("courier %d is %+v, want an offer", id, state.Courier)
+		}
+		if state.Delivery.CourierId != id {
+			t.Fatalf("courier %d holds order %d, offered to courier %d", id, state.Delivery.OrderId, state.Delivery.CourierId)
+		}
+		if other, ok := offered[state.Delivery.OrderId]; ok {
+			t.Fatalf("order %d is offered to couriers %d and %d", state.Delivery.OrderId, other, id)
+		}
+		offered[state.Delivery.OrderId] = id
+	}
+
+	deliveries, err := stores[1].ListDeliveries(ctx, entity.DeliveryStatusOffered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != couriers {
+		t.Fatalf("%d orders are offered, want one per courier: %d", len(deliveries), couriers)
+	}
+	for _, delivery := range deliveries {
+		if offered[delivery.OrderId] != delivery.CourierId {
+			t.Fatalf("order %d is offered to courier %d, who holds another order", delivery.OrderId, delivery.CourierId)
+		}
+	}
+}
+
+// TestUpdateDeliveryStatusOutsideLock checks couriers aren't held up while
+// the orders service records a delivery status.
+func TestUpdateDeliveryStatusOutsideLock(t *testing.T) {
+	shops := newMemoryShops()
+	newTestShop(shops, 1)
+	orders := &blockingOrders{called: make(chan struct{}), release: make(chan struct{})}
+	uc := newDeliveryUseCase(repo.NewCourierMemoryStore(), shops, orders)
+	ctx := context.Background()
+
+	_, err := uc.GoOnline(ctx, 1, testShopLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderReady(t, uc, 100, 1)
+	err = uc.Assign(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.AcceptDelivery(ctx, 100, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		delivery *entity.Delivery
+		err      error
+	}
+	done := make(chan result)
+	go func() {
+		delivery, err := uc.UpdateDeliveryStatus(ctx, 100, 1, entity.OrderStatusDelivered)
+		done <- result{delivery, err}
+	}()
+	<-orders.called
+
+	lockCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err = uc.GoOnline(lockCtx, 2, testShopLocation)
+	if err != nil {
+		t.Fatalf("GoOnline while the orders service is called: %v", err)
+	}
+
+	close(orders.release)
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.delivery.Status != entity.DeliveryStatusDelivered {
+		t.Fatalf("delivery is %s, want delivered", res.delivery.Status)
+	}
+	state, err := uc.GetCourier(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Courier.Status != entity.CourierStatusAvailable || state.Delivery != nil {
+		t.Fatalf("courier is %+v after delivering, want available", state.Courier)
+	}
+}
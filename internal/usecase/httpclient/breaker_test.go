@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.now = clock.now.Add(d)
+}
+
+func newTestBreaker(threshold int) (*breaker, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	b := newBreaker()
+	b.failureThreshold = threshold
+	b.openTimeout = 30 * time.Second
+	b.now = clock.Now
+	return b, clock
+}
+
+func assertState(t *testing.T, b *breaker, state BreakerState, failures int) {
+	t.Helper()
+
+	gotState, gotFailures := b.snapshot()
+	if gotState != state || gotFailures != failures {
+		t.Fatalf("breaker is %s with %d failures, want %s with %d", gotState, gotFailures, state, failures)
+	}
+}
+
+func assertAllow(t *testing.T, b *breaker, want error) {
+	t.Helper()
+
+	if err := b.allow(); !errors.Is(err, want) {
+		t.Fatalf("allow() = %v, want %v", err, want)
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	b, clock := newTestBreaker(3)
+	assertState(t, b, BreakerClosed, 0)
+
+	// A success in between resets the consecutive failures.
+	b.failure()
+	b.failure()
+	b.success()
+	assertState(t, b, BreakerClosed, 0)
+
+	b.failure()
+	b.failure()
+	assertAllow(t, b, nil)
+	assertState(t, b, BreakerClosed, 2)
+
+	// closed -> open
+	b.failure()
+	assertState(t, b, BreakerOpen, 3)
+	assertAllow(t, b, ErrCircuitOpen)
+
+	clock.Advance(30*time.Second - time.Nanosecond)
+	assertState(t, b, BreakerOpen, 3)
+	assertAllow(t, b, ErrCircuitOpen)
+
+	// open -> half-open, with a single probe let through.
+	clock.Advance(time.Nanosecond)
+	assertState(t, b, BreakerHalfOpen, 3)
+	assertAllow(t, b, nil)
+	assertAllow(t, b, ErrCircuitOpen)
+
+	// A failed probe opens the breaker for another timeout.
+	b.failure()
+	assertState(t, b, BreakerOpen, 4)
+	clock.Advance(29 * time.Second)
+	assertAllow(t, b, ErrCircuitOpen)
+	clock.Advance(time.Second)
+	assertAllow(t, b, nil)
+
+	// half-open -> closed
+	b.success()
+	assertState(t, b, BreakerClosed, 0)
+	assertAllow(t, b, nil)
+	assertAllow(t, b, nil)
+}
+
+func TestBreakerReleasedProbe(t *testing.T) {
+	b, clock := newTestBreaker(1)
+
+	b.failure()
+	clock.Advance(30 * time.Second)
+	assertAllow(t, b, nil)
+	assertAllow(t, b, ErrCircuitOpen)
+
+	// A probe whose outcome is unknown lets the next call probe instead,
+	// without opening the breaker again.
+	b.release()
+	assertState(t, b, BreakerHalfOpen, 1)
+	assertAllow(t, b, nil)
+	assertAllow(t, b, ErrCircuitOpen)
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+)
+
+// MaxBodySize limits how much of a downstream response body is read.
+const MaxBodySize = 4 << 20
+
+var ErrBodyTooLarge = errors.New("response body is too large")
+
+type errorBody struct {
+	Error  string                `json:"error"`
+	Fields []apperror.FieldError `json:"fields"`
+}
+
+// Call sends req as the JSON body of a request to url and decodes a successful
+// response into Resp. A nil req sends no body. Failures are returned as
+// *apperror.Error built from the downstream response or the transport error.
+func Call[Req, Resp any](ctx context.Context, client HttpClientI, method string, url string, req *Req) (*Resp, error) {
+	var body any
+	if req != nil {
+		body = req
+	}
+
+	res, err := client.SendRequest(ctx, body, method, url)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	defer res.Body.Close()
+
+	data, err := readBody(res.Body)
+	if err != nil {
+		return nil, apperror.Upstream(apperror.CodeUpstreamError, "can't read downstream response", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, decodeError(data, res.StatusCode)
+	}
+
+	var resp Resp
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, apperror.Upstream(apperror.CodeUpstreamError, "can't decode downstream response", err)
+	}
+	return &resp, nil
+}
+
+func readBody(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	return data, nil
+}
+
+// decodeError turns a downstream error response into the matching domain error.
+func decodeError(data []byte, status int) error {
+	var body errorBody
+	message := ""
+	if err := json.Unmarshal(data, &body); err == nil {
+		message = body.Error
+	} else if err := json.Unmarshal(data, &message); err != nil {
+		message = strings.TrimSpace(string(data))
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	switch {
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return apperror.Validation(message, body.Fields...)
+	case status == http.StatusUnauthorized:
+		return apperror.Unauthorized(message)
+	case status == http.StatusForbidden:
+		return apperror.Forbidden(message)
+	case status == http.StatusNotFound:
+		return apperror.NotFound(message)
+	case status == http.StatusConflict:
+		return apperror.Conflict(message)
+	case status == http.StatusServiceUnavailable:
+		return apperror.Upstream(apperror.CodeUpstreamUnavailable, message, nil)
+	case status == http.StatusGatewayTimeout:
+		return apperror.Upstream(apperror.CodeUpstreamTimeout, message, nil)
+	default:
+		return apperror.Upstream(apperror.CodeUpstreamError, message, fmt.Errorf("downstream status %d", status))
+	}
+}
+
+// transportError maps an error returned while sending a request.
+func transportError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return apperror.Upstream(apperror.CodeUpstreamUnavailable, err.Error(), err)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return apperror.Upstream(apperror.CodeUpstreamTimeout, "downstream service timed out", err)
+	case errors.Is(err, context.Canceled):
+		return apperror.Upstream(apperror.CodeRequestCanceled, "request canceled", err)
+	default:
+		return apperror.Upstream(apperror.CodeUpstreamError, "downstream service request failed", err)
+	}
+}
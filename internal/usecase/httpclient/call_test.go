@@ -0,0 +1,242 @@
+package httpclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
+)
+
+type item struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// trackedBody reports whether the response body was closed.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (body *trackedBody) Close() error {
+	body.closed = true
+	return nil
+}
+
+// fakeClient answers every request with status and body, or fails with err.
+type fakeClient struct {
+	status int
+	body   string
+	err    error
+
+	request  any
+	response *trackedBody
+}
+
+func (client *fakeClient) SendRequest(ctx context.Context, request any, method string, url string) (*http.Response, error) {
+	client.request = request
+	if client.err != nil {
+		return nil, client.err
+	}
+	client.response = &trackedBody{Reader: strings.NewReader(client.body)}
+	return &http.Response{StatusCode: client.status, Body: client.response}, nil
+}
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCallDecodesResponse(t *testing.T) {
+	client := &fakeClient{status: http.StatusOK, body: `{"id":7,"name":"soup"}`}
+
+	resp, err := httpclient.Call[item, item](context.Background(), client, http.MethodPost, "/items", &item{Name: "soup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *resp != (item{ID: 7, Name: "soup"}) {
+		t.Errorf("Call returned %+v", resp)
+	}
+	if sent, ok := client.request.(*item); !ok || sent.Name != "soup" {
+		t.Errorf("Call sent %#v, want the request", client.request)
+	}
+	if !client.response.closed {
+		t.Error("Call didn't close the response body")
+	}
+
+	_, err = httpclient.Call[item, item](context.Background(), client, http.MethodGet, "/items/7", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.request != nil {
+		t.Errorf("Call without a request sent %#v, want no body", client.request)
+	}
+}
+
+func TestCallErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   apperror.Kind
+		// code is left empty for errors that keep the kind's own code.
+		code    string
+		message string
+		fields  []apperror.FieldError
+	}{
+		{
+			name:    "bad request with fields",
+			status:  http.StatusBadRequest,
+			body:    `{"error":"invalid shop","fields":[{"field":"name","message":"is required"}]}`,
+			kind:    apperror.KindValidation,
+			message: "invalid shop",
+			fields:  []apperror.FieldError{{Field: "name", Message: "is required"}},
+		},
+		{name: "unprocessable", status: http.StatusUnprocessableEntity, body: `{"error":"invalid"}`, kind: apperror.KindValidation, message: "invalid"},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"error":"wrong password"}`, kind: apperror.KindUnauthorized, message: "wrong password"},
+		{name: "forbidden", status: http.StatusForbidden, body: `{"error":"not yours"}`, kind: apperror.KindForbidden, message: "not yours"},
+		{name: "not found", status: http.StatusNotFound, body: `{"error":"shop not found"}`, kind: apperror.KindNotFound, message: "shop not found"},
+		{name: "conflict", status: http.StatusConflict, body: `{"error":"email taken"}`, kind: apperror.KindConflict, message: "email taken"},
+		{name: "unavailable", status: http.StatusServiceUnavailable, body: `{"error":"maintenance"}`, kind: apperror.KindUpstream, code: apperror.CodeUpstreamUnavailable, message: "maintenance"},
+		{name: "gateway timeout", status: http.StatusGatewayTimeout, kind: apperror.KindUpstream, code: apperror.CodeUpstreamTimeout, message: "Gateway Timeout"},
+		{name: "internal error", status: http.StatusInternalServerError, body: `{"error":"boom"}`, kind: apperror.KindUpstream, code: apperror.CodeUpstreamError, message: "boom"},
+		{name: "unexpected status", status: http.StatusTeapot, body: `{"error":"teapot"}`, kind: apperror.KindUpstream, code: apperror.CodeUpstreamError, message: "teapot"},
+		{name: "redirect", status: http.StatusFound, kind: apperror.KindUpstream, code: apperror.CodeUpstreamError, message: "Found"},
+
+		{name: "json string body", status: http.StatusNotFound, body: `"menu item not found"`, kind: apperror.KindNotFound, message: "menu item not found"},
+		{name: "text body", status: http.StatusNotFound, body: " no such route\n", kind: apperror.KindNotFound, message: "no such route"},
+		{name: "empty body", status: http.StatusNotFound, kind: apperror.KindNotFound, message: "Not Found"},
+		{name: "object without error", status: http.StatusConflict, body: `{}`, kind: apperror.KindConflict, message: "Conflict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{status: tt.status, body: tt.body}
+
+			_, err := httpclient.Call[item, item](context.Background(), client, http.MethodGet, "/items/7", nil)
+			e := apperror.As(err)
+			if tt.code == "" {
+				tt.code = string(tt.kind)
+			}
+			if e.Kind != tt.kind || e.Code != tt.code || e.Message != tt.message {
+				t.Errorf("Call returned %s/%s %q, want %s/%s %q", e.Kind, e.Code, e.Message, tt.kind, tt.code, tt.message)
+			}
+			if !reflect.DeepEqual(e.Fields, tt.fields) {
+				t.Errorf("Call returned fields %v, want %v", e.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestCallBodySize(t *testing.T) {
+	// A JSON string of exactly MaxBodySize bytes is read in full.
+	largest := `"` + strings.Repeat("a", httpclient.MaxBodySize-2) + `"`
+	client := &fakeClient{status: http.StatusOK, body: largest}
+	resp, err := httpclient.Call[item, string](context.Background(), client, http.MethodGet, "/large", nil)
+	if err != nil {
+		t.Fatalf("Call with a body of MaxBodySize: %v", err)
+	}
+	if len(*resp) != httpclient.MaxBodySize-2 {
+		t.Errorf("Call decoded %d bytes, want %d", len(*resp), httpclient.MaxBodySize-2)
+	}
+
+	client = &fakeClient{status: http.StatusOK, body: largest + " "}
+	_, err = httpclient.Call[item, string](context.Background(), client, http.MethodGet, "/large", nil)
+	if e := apperror.As(err); e.Kind != apperror.KindUpstream || e.Code != apperror.CodeUpstreamError || !errors.Is(err, httpclient.ErrBodyTooLarge) {
+		t.Errorf("Call with a body over MaxBodySize returned %v, want ErrBodyTooLarge as an upstream error", err)
+	}
+
+	// Error responses are limited the same way.
+	client = &fakeClient{status: http.StatusNotFound, body: largest + " "}
+	_, err = httpclient.Call[item, string](context.Background(), client, http.MethodGet, "/large", nil)
+	if !errors.Is(err, httpclient.ErrBodyTooLarge) {
+		t.Errorf("Call with an error body over MaxBodySize returned %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestCallUndecodableResponse(t *testing.T) {
+	client := &fakeClient{status: http.StatusOK, body: `{"id":"seven"}`}
+
+	_, err := httpclient.Call[item, item](context.Background(), client, http.MethodGet, "/items/7", nil)
+	if e := apperror.As(err); e.Kind != apperror.KindUpstream || e.Code != apperror.CodeUpstreamError {
+		t.Errorf("Call with an undecodable response returned %v, want an upstream error", err)
+	}
+}
+
+func TestCallTransportError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"circuit open", httpclient.ErrCircuitOpen, apperror.CodeUpstreamUnavailable},
+		{"deadline", context.DeadlineExceeded, apperror.CodeUpstreamTimeout},
+		{"wrapped deadline", &wrappedError{context.DeadlineExceeded}, apperror.CodeUpstreamTimeout},
+		{"network timeout", timeoutError{}, apperror.CodeUpstreamTimeout},
+		{"canceled", context.Canceled, apperror.CodeRequestCanceled},
+		{"connection refused", errors.New("connection refused"), apperror.CodeUpstreamError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{err: tt.err}
+
+			_, err := httpclient.Call[item, item](context.Background(), client, http.MethodGet, "/items/7", nil)
+			e := apperror.As(err)
+			if e.Kind != apperror.KindUpstream || e.Code != tt.code {
+				t.Errorf("Call returned %s/%s, want %s/%s", e.Kind, e.Code, apperror.KindUpstream, tt.code)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Call returned %v, which doesn't wrap %v", err, tt.err)
+			}
+		})
+	}
+}
+
+type wrappedError struct{ err error }
+
+func (e *wrappedError) Error() string { return "send: " + e.err.Error() }
+func (e *wrappedError) Unwrap() error { return e.err }
+
+func TestCallThroughHttpClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/items" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+
+		var req item
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req.ID = 7
+		_ = json.NewEncoder(w).Encode(req)
+	}))
+	t.Cleanup(server.Close)
+	client := httpclient.New(httpclient.Address(server.URL), httpclient.Retries(1))
+
+	resp, err := httpclient.Call[item, item](context.Background(), client, http.MethodPost, server.URL+"/items", &item{Name: "soup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *resp != (item{ID: 7, Name: "soup"}) {
+		t.Errorf("Call returned %+v", resp)
+	}
+
+	_, err = httpclient.Call[item, item](context.Background(), client, http.MethodGet, server.URL+"/missing", nil)
+	if !apperror.Is(err, apperror.KindNotFound) {
+		t.Errorf("Call of a missing route returned %v, want not found", err)
+	}
+}
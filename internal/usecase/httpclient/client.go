@@ -1,23 +1,163 @@
 package httpclient
 
-import "net/http"
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	_defaultTimeout     = 5 * time.Second
+	_defaultMaxAttempts = 3
+	_defaultBaseDelay   = 100 * time.Millisecond
+	_defaultMaxDelay    = 2 * time.Second
+)
 
-//go:generate mockery --name=HttpClientI --with-expecter=true
 type HttpClientI interface {
-	SendRequest(request any, method string, url string) (*http.Response, error)
+	SendRequest(ctx context.Context, request any, method string, url string) (*http.Response, error)
+}
+
+// Status describes a downstream service as seen by its client.
+type Status struct {
+	Name     string       `json:"name"`
+	Address  string       `json:"address"`
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
 }
 
+// HttpClient talks to a single downstream service. Idempotent requests are
+// retried with jittered exponential backoff and all requests go through a
+// circuit breaker that fails fast while the service is down.
 type HttpClient struct {
-	Do func(req *http.Request) (*http.Response, error)
-	c  *http.Client
+	c           *http.Client
+	name        string
+	address     string
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	breaker     *breaker
+	jitter      func(n int64) int64
+	wait        func(ctx context.Context, delay time.Duration) error
 }
 
-var (
-	Client HttpClient
-)
+var _ HttpClientI = (*HttpClient)(nil)
+
+func New(opts ...Option) *HttpClient {
+	client := &HttpClient{
+		c:           &http.Client{Timeout: _defaultTimeout},
+		maxAttempts: _defaultMaxAttempts,
+		baseDelay:   _defaultBaseDelay,
+		maxDelay:    _defaultMaxDelay,
+		breaker:     newBreaker(),
+		jitter:      rand.Int63n,
+		wait:        wait,
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+func (client *HttpClient) SendRequest(ctx context.Context, request any, method string, url string) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(method) {
+		attempts = client.maxAttempts
+	}
+
+	var (
+		res *http.Response
+		err error
+	)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := client.wait(ctx, client.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		res, err = client.send(ctx, request, method, url)
+		if !retryable(ctx, res, err) || attempt == attempts-1 {
+			break
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+	}
+	return res, err
+}
+
+func (client *HttpClient) send(ctx context.Context, request any, method string, url string) (*http.Response, error) {
+	if err := client.breaker.allow(); err != nil {
+		return nil, err
+	}
 
-func New() HttpClient {
-	return HttpClient{
-		c: &http.Client{},
+	httpRequest, err := NewHttpRequest(ctx, request, method, url)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.c.Do(httpRequest)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// The caller gave up, that says nothing about the service.
+		client.breaker.release()
+	case err != nil || res.StatusCode >= http.StatusInternalServerError:
+		client.breaker.failure()
+	default:
+		client.breaker.success()
+	}
+	return res, err
+}
+
+// backoff picks a random delay up to base*2^(attempt-1), capped at maxDelay.
+func (client *HttpClient) backoff(attempt int) time.Duration {
+	delay := client.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > client.maxDelay {
+		delay = client.maxDelay
+	}
+	return time.Duration(client.jitter(int64(delay) + 1))
+}
+
+// wait sleeps for delay unless ctx is done first.
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (client *HttpClient) Status() Status {
+	state, failures := client.breaker.snapshot()
+	return Status{
+		Name:     client.name,
+		Address:  client.address,
+		State:    state,
+		Failures: failures,
+	}
+}
+
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func retryable(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil || err == ErrCircuitOpen {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
 	}
+	return false
 }
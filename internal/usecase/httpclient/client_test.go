@@ -0,0 +1,263 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reply is what fakeTransport answers with: a status, or err when set.
+type reply struct {
+	status int
+	err    error
+}
+
+// fakeTransport answers the requests with replies in turn and counts the
+// response bodies closed.
+type fakeTransport struct {
+	replies []reply
+	calls   int
+	closed  int
+	// onCall runs before every reply.
+	onCall func(req *http.Request)
+}
+
+func (transport *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport.onCall != nil {
+		transport.onCall(req)
+	}
+	r := transport.replies[transport.calls]
+	transport.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &http.Response{
+		StatusCode: r.status,
+		Body:       &countedBody{Reader: strings.NewReader(""), closed: &transport.closed},
+		Request:    req,
+	}, nil
+}
+
+type countedBody struct {
+	io.Reader
+	closed *int
+}
+
+func (body *countedBody) Close() error {
+	*body.closed++
+	return nil
+}
+
+// newTestClient sends through transport, waits for no one and always backs
+// off for the longest delay allowed, which it records.
+func newTestClient(transport *fakeTransport, opts ...Option) (*HttpClient, *[]time.Duration) {
+	client := New(append([]Option{Backoff(100*time.Millisecond, 250*time.Millisecond)}, opts...)...)
+	client.c.Transport = transport
+	client.jitter = func(n int64) int64 { return n - 1 }
+
+	delays := &[]time.Duration{}
+	client.wait = func(ctx context.Context, delay time.Duration) error {
+		*delays = append(*delays, delay)
+		return ctx.Err()
+	}
+	return client, delays
+}
+
+func TestSendRequestRetries(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	tests := []struct {
+		name    string
+		method  string
+		replies []reply
+		status  int
+		err     bool
+	}{
+		{
+			name:    "get retried on 5xx",
+			method:  http.MethodGet,
+			replies: []reply{{status: 503}, {status: 502}, {status: 200}},
+			status:  200,
+		},
+		{
+			name:    "get retried on transport errors",
+			method:  http.MethodGet,
+			replies: []reply{{err: errRefused}, {status: 200}},
+			status:  200,
+		},
+		{
+			name:    "head retried",
+			method:  http.MethodHead,
+			replies: []reply{{status: 504}, {status: 200}},
+			status:  200,
+		},
+		{
+			name:    "attempts run out",
+			method:  http.MethodGet,
+			replies: []reply{{status: 503}, {status: 503}, {status: 503}},
+			status:  503,
+		},
+		{
+			name:    "internal error not retried",
+			method:  http.MethodGet,
+			replies: []reply{{status: 500}},
+			status:  500,
+		},
+		{
+			name:    "client error not retried",
+			method:  http.MethodGet,
+			replies: []reply{{status: 404}},
+			status:  404,
+		},
+		{
+			name:    "post not retried on 5xx",
+			method:  http.MethodPost,
+			replies: []reply{{status: 503}},
+			status:  503,
+		},
+		{
+			name:    "post not retried on transport errors",
+			method:  http.MethodPost,
+			replies: []reply{{err: errRefused}},
+			err:     true,
+		},
+		{
+			name:    "patch not retried",
+			method:  http.MethodPatch,
+			replies: []reply{{status: 502}},
+			status:  502,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fakeTransport{replies: tt.replies}
+			client, delays := newTestClient(transport)
+
+			res, err := client.SendRequest(context.Background(), nil, tt.method, "http://shops/shops/1")
+			if tt.err {
+				if err == nil || !errors.Is(err, errRefused) {
+					t.Fatalf("SendRequest returned %v, want %v", err, errRefused)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.StatusCode != tt.status {
+					t.Errorf("SendRequest returned status %d, want %d", res.StatusCode, tt.status)
+				}
+			}
+			if transport.calls != len(tt.replies) {
+				t.Errorf("SendRequest made %d attempts, want %d", transport.calls, len(tt.replies))
+			}
+			// Every response but the one returned is closed.
+			responses := 0
+			for _, r := range tt.replies {
+				if r.err == nil {
+					responses++
+				}
+			}
+			if !tt.err && transport.closed != responses-1 {
+				t.Errorf("%d responses were closed, want %d", transport.closed, responses-1)
+			}
+
+			// base*2^(attempt-1), capped at the max delay.
+			want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}[:transport.calls-1]
+			if !reflect.DeepEqual(*delays, want) {
+				t.Errorf("SendRequest backed off %v, want %v", *delays, want)
+			}
+		})
+	}
+}
+
+func TestSendRequestBackoffCap(t *testing.T) {
+	transport := &fakeTransport{replies: []reply{{status: 503}, {status: 503}, {status: 503}, {status: 503}, {status: 200}}}
+	client, delays := newTestClient(transport, Retries(5))
+
+	_, err := client.SendRequest(context.Background(), nil, http.MethodGet, "http://shops/shops/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond, 250 * time.Millisecond}
+	if !reflect.DeepEqual(*delays, want) {
+		t.Errorf("SendRequest backed off %v, want %v", *delays, want)
+	}
+
+	// The jitter picks anywhere from no delay up to the backoff.
+	var bounds []int64
+	client.jitter = func(n int64) int64 {
+		bounds = append(bounds, n)
+		return 0
+	}
+	if delay := client.backoff(2); delay != 0 || !reflect.DeepEqual(bounds, []int64{int64(200*time.Millisecond) + 1}) {
+		t.Errorf("backoff(2) = %v drawn from %v, want 0 from [0, 200ms]", delay, bounds)
+	}
+}
+
+func TestSendRequestStopsAtOpenBreaker(t *testing.T) {
+	transport := &fakeTransport{replies: []reply{{status: 503}, {status: 503}}}
+	client, delays := newTestClient(transport, BreakerThreshold(2))
+
+	_, err := client.SendRequest(context.Background(), nil, http.MethodGet, "http://shops/shops/1")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("SendRequest returned %v, want ErrCircuitOpen", err)
+	}
+	if transport.calls != 2 || len(*delays) != 2 {
+		t.Errorf("SendRequest made %d attempts and %d waits, want 2 of each", transport.calls, len(*delays))
+	}
+
+	// The open breaker fails fast without retrying.
+	_, err = client.SendRequest(context.Background(), nil, http.MethodGet, "http://shops/shops/1")
+	if !errors.Is(err, ErrCircuitOpen) || transport.calls != 2 || len(*delays) != 2 {
+		t.Errorf("SendRequest through an open breaker returned %v after %d attempts", err, transport.calls)
+	}
+	if status := client.Status(); status.State != BreakerOpen || status.Failures != 2 {
+		t.Errorf("Status() = %+v, want open after 2 failures", status)
+	}
+}
+
+func TestSendRequestCanceled(t *testing.T) {
+	t.Run("while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		transport := &fakeTransport{replies: []reply{{status: 503}, {status: 200}}}
+		client, _ := newTestClient(transport)
+		client.wait = func(ctx context.Context, delay time.Duration) error {
+			cancel()
+			return wait(ctx, time.Hour)
+		}
+
+		_, err := client.SendRequest(ctx, nil, http.MethodGet, "http://shops/shops/1")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("SendRequest returned %v, want context.Canceled", err)
+		}
+		if transport.calls != 1 {
+			t.Errorf("SendRequest made %d attempts after the cancel, want 1", transport.calls)
+		}
+	})
+
+	t.Run("during the request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		transport := &fakeTransport{
+			replies: []reply{{err: context.Canceled}, {status: 200}},
+			onCall:  func(*http.Request) { cancel() },
+		}
+		client, delays := newTestClient(transport, BreakerThreshold(1))
+
+		_, err := client.SendRequest(ctx, nil, http.MethodGet, "http://shops/shops/1")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("SendRequest returned %v, want context.Canceled", err)
+		}
+		if transport.calls != 1 || len(*delays) != 0 {
+			t.Errorf("SendRequest retried a canceled request")
+		}
+		// The caller gave up; that isn't held against the service.
+		if status := client.Status(); status.State != BreakerClosed || status.Failures != 0 {
+			t.Errorf("Status() = %+v, want closed without failures", status)
+		}
+	})
+}
@@ -2,12 +2,13 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 )
 
-func NewHttpRequest(req any, method string, url string) (*http.Request, error) {
+func NewHttpRequest(ctx context.Context, req any, method string, url string) (*http.Request, error) {
 	var body io.Reader
 	if req != nil {
 		data, err := json.Marshal(req)
@@ -19,7 +20,7 @@ func NewHttpRequest(req any, method string, url string) (*http.Request, error) {
 		body = nil
 	}
 
-	request, err := http.NewRequest(method, url, body)
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -28,3 +29,7 @@ func NewHttpRequest(req any, method string, url string) (*http.Request, error) {
 
 	return request, nil
 }
+
+// StatusClientClosedRequest is the non-standard status used when the client
+// went away before the downstream call finished.
+const StatusClientClosedRequest = 499
@@ -0,0 +1,67 @@
+package httpclient
+
+import "time"
+
+type Option func(*HttpClient)
+
+// Name identifies the downstream service on the status endpoint.
+func Name(name string) Option {
+	return func(c *HttpClient) {
+		c.name = name
+	}
+}
+
+// Address is the base address of the downstream service guarded by the breaker.
+func Address(address string) Option {
+	return func(c *HttpClient) {
+		c.address = address
+	}
+}
+
+// Timeout bounds every single attempt, including reading the response body.
+func Timeout(timeout time.Duration) Option {
+	return func(c *HttpClient) {
+		if timeout > 0 {
+			c.c.Timeout = timeout
+		}
+	}
+}
+
+// Retries sets how many times an idempotent request is attempted in total.
+func Retries(attempts int) Option {
+	return func(c *HttpClient) {
+		if attempts > 0 {
+			c.maxAttempts = attempts
+		}
+	}
+}
+
+// Backoff sets the base and maximum delay between retries.
+func Backoff(base time.Duration, max time.Duration) Option {
+	return func(c *HttpClient) {
+		if base > 0 {
+			c.baseDelay = base
+		}
+		if max > 0 {
+			c.maxDelay = max
+		}
+	}
+}
+
+// BreakerThreshold sets how many consecutive failures open the breaker.
+func BreakerThreshold(failures int) Option {
+	return func(c *HttpClient) {
+		if failures > 0 {
+			c.breaker.failureThreshold = failures
+		}
+	}
+}
+
+// BreakerOpenTimeout sets how long the breaker stays open before probing.
+func BreakerOpenTimeout(timeout time.Duration) Option {
+	return func(c *HttpClient) {
+		if timeout > 0 {
+			c.breaker.openTimeout = timeout
+		}
+	}
+}
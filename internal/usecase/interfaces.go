@@ -1,51 +1,330 @@
 package usecase
 
-import "github.com/zura-t/go_delivery_system/internal/entity"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/pkg/hub"
+	"github.com/zura-t/go_delivery_system/pkg/oidc"
+	"github.com/zura-t/go_delivery_system/token"
+)
 
 type User interface {
-	CreateUser(req *entity.UserRegister) (*entity.User, int, error)
-	LoginUser(req *entity.UserLogin) (*entity.UserLoginResponse, int, error)
-	GetMyProfile(id int64) (*entity.User, int, error)
-	AddAdminRole(id int64) (string, int, error)
-	UpdateUser(id int64, req *entity.UserUpdate) (*entity.User, int, error)
-	AddPhone(id int64, req *entity.UserAddPhone) (string, int, error)
-	DeleteUser(id int64) (string, int, error)
+	CreateUser(ctx context.Context, req *entity.UserRegister) (*entity.User, error)
+	// LoginUser returns a challenge instead of a login response when the user
+	// has two-factor authentication; LoginMFA answers it.
+	LoginUser(ctx context.Context, req *entity.UserLogin, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error)
+	LoginMFA(ctx context.Context, challengeToken string, code string, client *entity.SessionClient) (*entity.UserLoginResponse, error)
+	// LoginExternal logs in a user whose identity was proven elsewhere, like
+	// by an identity provider. The second factor still applies.
+	LoginExternal(ctx context.Context, user *entity.User, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error)
+	GetMyProfile(ctx context.Context, id int64) (*entity.User, error)
+	UpdateUser(ctx context.Context, id int64, req *entity.UserUpdate) (*entity.User, error)
+	AddPhone(ctx context.Context, id int64, req *entity.UserAddPhone) (string, error)
+	DeleteUser(ctx context.Context, id int64) (string, error)
 }
 
 type UserWebAPI interface {
-	CreateUser(req *entity.UserRegister) (*entity.User, int, error)
-	LoginUser(req *entity.UserLogin) (*entity.UserLoginResponse, int, error)
-	GetMyProfile(id int64) (*entity.User, int, error)
-	AddAdminRole(id int64) (string, int, error)
-	UpdateUser(id int64, req *entity.UserUpdate) (*entity.User, int, error)
-	AddPhone(id int64, req *entity.UserAddPhone) (string, int, error)
-	DeleteUser(id int64) (string, int, error)
+	CreateUser(ctx context.Context, req *entity.UserRegister) (*entity.User, error)
+	LoginUser(ctx context.Context, req *entity.UserLogin) (*entity.UserLoginResponse, error)
+	GetMyProfile(ctx context.Context, id int64) (*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
+	ResetPassword(ctx context.Context, id int64, req *entity.UserResetPassword) (string, error)
+	VerifyEmail(ctx context.Context, id int64) (string, error)
+	AddAdminRole(ctx context.Context, id int64) (string, error)
+	RemoveAdminRole(ctx context.Context, id int64) (string, error)
+	AddCourierRole(ctx context.Context, id int64) (string, error)
+	UpdateUser(ctx context.Context, id int64, req *entity.UserUpdate) (*entity.User, error)
+	AddPhone(ctx context.Context, id int64, req *entity.UserAddPhone) (string, error)
+	DeleteUser(ctx context.Context, id int64) (string, error)
 }
 
 type Shop interface {
-	CreateShop(req *entity.CreateShop) (*entity.Shop, int, error)
-	GetShops(limit int32, offset int32) ([]*entity.Shop, int, error)
-	GetShopsAdmin(user_id int64) ([]entity.Shop, int, error)
-	GetShop(id int64) (*entity.Shop, int, error)
-	UpdateShop(id int64, req *entity.UpdateShopInfo) (*entity.Shop, int, error)
-	CreateMenu(req *entity.CreateMenuItem) ([]*entity.GetMenuItem, int, error)
-	GetMenu(shopId int64) ([]*entity.GetMenuItem, int, error)
-	UpdateMenuItem(id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, int, error)
-	GetMenuItem(id int64) (*entity.GetMenuItem, int, error)
-	DeleteShop(id int64, user_id int64) (string, int, error)
-	DeleteMenuItem(id int64, user_id int64) (string, int, error)
+	CreateShop(ctx context.Context, req *entity.CreateShop) (*entity.Shop, error)
+	GetShops(ctx context.Context, limit int32, offset int32) ([]*entity.Shop, error)
+	GetShopsAdmin(ctx context.Context, user_id int64) ([]entity.Shop, error)
+	GetShop(ctx context.Context, id int64) (*entity.Shop, error)
+	UpdateShop(ctx context.Context, id int64, req *entity.UpdateShopInfo) (*entity.Shop, error)
+	CreateMenu(ctx context.Context, req *entity.CreateMenuItem) ([]*entity.GetMenuItem, error)
+	GetMenu(ctx context.Context, shopId int64) ([]*entity.GetMenuItem, error)
+	UpdateMenuItem(ctx context.Context, id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, error)
+	GetMenuItem(ctx context.Context, id int64) (*entity.GetMenuItem, error)
+	DeleteShop(ctx context.Context, id int64, user_id int64) (string, error)
+	DeleteMenuItem(ctx context.Context, id int64, user_id int64) (string, error)
+}
+
+type Search interface {
+	Search(ctx context.Context, query *entity.SearchQuery) (*entity.SearchResult, error)
+	NearbyShops(ctx context.Context, query *entity.NearbyQuery) ([]*entity.NearbyShop, error)
+}
+
+// SearchIndex keeps the documents of shops and menu items for full-text
+// search. Indexing a document again replaces it.
+type SearchIndex interface {
+	IndexDocuments(ctx context.Context, docs []*entity.SearchDocument) error
+	DeleteDocument(ctx context.Context, kind string, id int64) error
+	// DeleteShop removes a shop together with its menu items.
+	DeleteShop(ctx context.Context, shopId int64) error
+	Search(ctx context.Context, query *entity.SearchQuery) (*entity.SearchResult, error)
+	// NearbyShops returns up to limit shop documents with a location within
+	// radiusKm of at, nearest first. A non-positive radius doesn't limit them.
+	NearbyShops(ctx context.Context, at entity.Location, radiusKm float64, limit int) ([]*entity.SearchDocument, error)
+}
+
+type Reindex interface {
+	Reindex(ctx context.Context, opts entity.ReindexOptions, progress func(*entity.ReindexCheckpoint)) (*entity.ReindexReport, error)
+}
+
+// SearchVersions builds a new version of the search index beside the one
+// being served and swaps it in under the alias searches use.
+type SearchVersions interface {
+	// CreateVersion marks version as the one being built: until it is
+	// published, changes made through SearchIndex reach it too.
+	CreateVersion(ctx context.Context, version string) error
+	// IndexVersion adds the documents version doesn't have yet. Documents
+	// written by those changes are newer than the copies of a reindex, so
+	// they are kept.
+	IndexVersion(ctx context.Context, version string, docs []*entity.SearchDocument) error
+	// PublishVersion points the alias at version alone in one step and
+	// returns the versions it pointed at before.
+	PublishVersion(ctx context.Context, version string) ([]string, error)
+	DeleteVersion(ctx context.Context, version string) error
+}
+
+// ReindexCheckpointStore keeps the checkpoint of an unfinished reindex.
+// GetCheckpoint returns nil when there is none.
+type ReindexCheckpointStore interface {
+	GetCheckpoint(ctx context.Context) (*entity.ReindexCheckpoint, error)
+	SaveCheckpoint(ctx context.Context, checkpoint *entity.ReindexCheckpoint) error
+	DeleteCheckpoint(ctx context.Context) error
 }
 
 type ShopWebAPI interface {
-	CreateShop(req *entity.CreateShop) (*entity.Shop, int, error)
-	GetShops(limit int32, offset int32) ([]*entity.Shop, int, error)
-	GetShopsAdmin(user_id int64) ([]entity.Shop, int, error)
-	GetShopInfo(id int64) (*entity.Shop, int, error)
-	UpdateShop(id int64, req *entity.UpdateShopInfo) (*entity.Shop, int, error)
-	CreateMenu(req *entity.CreateMenuItem) ([]*entity.GetMenuItem, int, error)
-	GetMenu(shopId int64) ([]*entity.GetMenuItem, int, error)
-	UpdateMenuItem(id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, int, error)
-	GetMenuItem(id int64) (*entity.GetMenuItem, int, error)
-	DeleteShop(id int64, user_id int64) (string, int, error)
-	DeleteMenuItem(id int64, user_id int64) (string, int, error)
+	CreateShop(ctx context.Context, req *entity.CreateShop) (*entity.Shop, error)
+	GetShops(ctx context.Context, limit int32, offset int32) ([]*entity.Shop, error)
+	GetShopsAdmin(ctx context.Context, user_id int64) ([]entity.Shop, error)
+	GetShopInfo(ctx context.Context, id int64) (*entity.Shop, error)
+	UpdateShop(ctx context.Context, id int64, req *entity.UpdateShopInfo) (*entity.Shop, error)
+	CreateMenu(ctx context.Context, req *entity.CreateMenuItem) ([]*entity.GetMenuItem, error)
+	GetMenu(ctx context.Context, shopId int64) ([]*entity.GetMenuItem, error)
+	UpdateMenuItem(ctx context.Context, id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, error)
+	GetMenuItem(ctx context.Context, id int64) (*entity.GetMenuItem, error)
+	DeleteShop(ctx context.Context, id int64, user_id int64) (string, error)
+	DeleteMenuItem(ctx context.Context, id int64, user_id int64) (string, error)
+}
+
+type Order interface {
+	CreateOrder(ctx context.Context, req *entity.CreateOrder) (*entity.Order, error)
+	GetOrders(ctx context.Context, userId int64, limit int32, offset int32) ([]*entity.Order, error)
+	GetOrder(ctx context.Context, id int64, userId int64) (*entity.Order, error)
+	CancelOrder(ctx context.Context, id int64, userId int64) (*entity.Order, error)
+	UpdateOrderStatus(ctx context.Context, id int64, roles []entity.RoleGrant, status entity.OrderStatus) (*entity.Order, error)
+	UpdateDeliveryStatus(ctx context.Context, id int64, status entity.OrderStatus) (*entity.Order, error)
+}
+
+type OrderWebAPI interface {
+	CreateOrder(ctx context.Context, req *entity.CreateOrder) (*entity.Order, error)
+	GetOrders(ctx context.Context, userId int64, limit int32, offset int32) ([]*entity.Order, error)
+	GetOrder(ctx context.Context, id int64) (*entity.Order, error)
+	UpdateOrderStatus(ctx context.Context, id int64, req *entity.UpdateOrderStatus) (*entity.Order, error)
+}
+
+// ErrEventUndeliverable is returned by publishers that have no way to deliver
+// an event, like mail jobs when no message broker is configured.
+var ErrEventUndeliverable = errors.New("event can't be delivered: no message broker is configured")
+
+// EventPublisher delivers domain events to other services. routingKey
+// follows the "<aggregate>.<event>" form, e.g. "order.accepted".
+type EventPublisher interface {
+	Publish(ctx context.Context, routingKey string, event any) error
+}
+
+type Cart interface {
+	GetCart(ctx context.Context, userId int64) (*entity.Cart, error)
+	AddItem(ctx context.Context, userId int64, menuItemId int64, quantity int32) (*entity.Cart, error)
+	UpdateItemQuantity(ctx context.Context, userId int64, menuItemId int64, quantity int32) (*entity.Cart, error)
+	RemoveItem(ctx context.Context, userId int64, menuItemId int64) (*entity.Cart, error)
+	ClearCart(ctx context.Context, userId int64) error
+	Checkout(ctx context.Context, userId int64, deliveryLocation entity.Location) (*entity.Order, error)
+}
+
+// CartStore keeps one cart per user. Get returns an empty cart for users
+// without one.
+type CartStore interface {
+	Get(ctx context.Context, userId int64) (*entity.Cart, error)
+	Save(ctx context.Context, cart *entity.Cart) error
+	Delete(ctx context.Context, userId int64) error
+}
+
+type Tracking interface {
+	Follow(ctx context.Context, orderId int64, userId int64, lastEventID string) (*entity.Order, *hub.Subscription, []hub.Event, error)
+	Unfollow(sub *hub.Subscription)
+	Dispatch(routingKey string, body []byte) error
+}
+
+type Delivery interface {
+	GetCourier(ctx context.Context, userId int64) (*entity.CourierState, error)
+	GoOnline(ctx context.Context, userId int64, location entity.Location) (*entity.Courier, error)
+	GoOffline(ctx context.Context, userId int64) (*entity.Courier, error)
+	UpdateLocation(ctx context.Context, userId int64, location entity.Location) (*entity.Courier, error)
+	AcceptDelivery(ctx context.Context, orderId int64, userId int64) (*entity.Delivery, error)
+	RejectDelivery(ctx context.Context, orderId int64, userId int64) (*entity.Delivery, error)
+	UpdateDeliveryStatus(ctx context.Context, orderId int64, userId int64, status entity.OrderStatus) (*entity.Delivery, error)
+}
+
+type CourierStore interface {
+	// Lock takes the dispatch lock, which every gateway instance sharing the
+	// store respects, and returns the function releasing it. Couriers and
+	// deliveries are only changed under it, so a courier is never offered
+	// two orders at once.
+	Lock(ctx context.Context) (func(), error)
+	GetCourier(ctx context.Context, userId int64) (*entity.Courier, error)
+	SaveCourier(ctx context.Context, courier *entity.Courier) error
+	ListCouriers(ctx context.Context, status entity.CourierStatus) ([]*entity.Courier, error)
+	GetDelivery(ctx context.Context, orderId int64) (*entity.Delivery, error)
+	SaveDelivery(ctx context.Context, delivery *entity.Delivery) error
+	DeleteDelivery(ctx context.Context, orderId int64) error
+	ListDeliveries(ctx context.Context, status entity.DeliveryStatus) ([]*entity.Delivery, error)
+}
+
+type Session interface {
+	StartSession(ctx context.Context, user *entity.User, client *entity.SessionClient) (*entity.SessionTokens, error)
+	RefreshSession(ctx context.Context, refreshToken string, client *entity.SessionClient) (*entity.SessionTokens, error)
+	Authenticate(ctx context.Context, accessToken string) (*token.Payload, error)
+	// RefreshShopRoles replaces the shop owner grants of payload with the
+	// shops the user owns now.
+	RefreshShopRoles(ctx context.Context, payload *token.Payload) error
+	EndSession(ctx context.Context, refreshToken string) error
+	ListSessions(ctx context.Context, userId int64, currentId uuid.UUID) ([]*entity.Session, error)
+	RevokeSession(ctx context.Context, userId int64, id string) (string, error)
+	// RevokeOtherSessions logs the user out everywhere but in currentId.
+	RevokeOtherSessions(ctx context.Context, userId int64, currentId uuid.UUID) (string, error)
+	RevokeAllSessions(ctx context.Context, userId int64) error
+}
+
+// SessionStore keeps the sessions of every user. Expired sessions are left
+// out of lists.
+type SessionStore interface {
+	SaveSession(ctx context.Context, session *entity.Session) error
+	// GetSession returns nil without an error for unknown sessions.
+	GetSession(ctx context.Context, userId int64, id string) (*entity.Session, error)
+	ListSessions(ctx context.Context, userId int64) ([]*entity.Session, error)
+	DeleteSession(ctx context.Context, userId int64, id string) error
+}
+
+// RevocationStore remembers revoked token and session IDs until the tokens
+// would have expired anyway.
+type RevocationStore interface {
+	Revoke(ctx context.Context, id uuid.UUID, until time.Time) error
+	// RevokeOnce revokes id and reports whether it wasn't revoked before.
+	RevokeOnce(ctx context.Context, id uuid.UUID, until time.Time) (bool, error)
+	// Unrevoke forgets id, like a reservation of a token whose use failed.
+	Unrevoke(ctx context.Context, id uuid.UUID) error
+	IsRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error)
+}
+
+type Admin interface {
+	CreateInvite(ctx context.Context, actorId int64, userId int64) (*entity.AdminInvite, error)
+	RedeemInvite(ctx context.Context, userId int64, invite string) (string, error)
+	RequestAdmin(ctx context.Context, userId int64, reason string) (*entity.AdminRequest, error)
+	ListRequests(ctx context.Context, status entity.AdminRequestStatus) ([]*entity.AdminRequest, error)
+	ApproveRequest(ctx context.Context, actorId int64, userId int64) (*entity.AdminRequest, error)
+	RejectRequest(ctx context.Context, actorId int64, userId int64) (*entity.AdminRequest, error)
+	RevokeAdmin(ctx context.Context, actorId int64, userId int64) (string, error)
+	GrantCourier(ctx context.Context, actorId int64, userId int64) (string, error)
+	ListAudit(ctx context.Context, limit int32, offset int32) ([]*entity.AuditEntry, error)
+}
+
+// AdminStore keeps admin requests and the audit log of admin grants, newest
+// entries first.
+type AdminStore interface {
+	// GetRequest returns nil without an error when the user has no request.
+	GetRequest(ctx context.Context, userId int64) (*entity.AdminRequest, error)
+	SaveRequest(ctx context.Context, req *entity.AdminRequest) error
+	ListRequests(ctx context.Context, status entity.AdminRequestStatus) ([]*entity.AdminRequest, error)
+	AddAuditEntry(ctx context.Context, entry *entity.AuditEntry) error
+	ListAuditEntries(ctx context.Context, limit int32, offset int32) ([]*entity.AuditEntry, error)
+}
+
+// LoginAttemptStore counts failed logins per account and remembers lockouts.
+type LoginAttemptStore interface {
+	// AddFailure counts a failed login and returns the failures since the
+	// first one within window.
+	AddFailure(ctx context.Context, email string, window time.Duration) (int, error)
+	Lock(ctx context.Context, email string, until time.Time) error
+	// LockedUntil returns the zero time for accounts that aren't locked.
+	LockedUntil(ctx context.Context, email string) (time.Time, error)
+	Reset(ctx context.Context, email string) error
+}
+
+type Account interface {
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, resetToken string, password string) (string, error)
+	SendVerification(ctx context.Context, userId int64) error
+	VerifyEmail(ctx context.Context, verificationToken string) (string, error)
+}
+
+type MFA interface {
+	Enroll(ctx context.Context, userId int64) (*entity.MFAEnrollment, error)
+	Confirm(ctx context.Context, userId int64, code string) (*entity.MFARecoveryCodes, error)
+	Disable(ctx context.Context, userId int64, code string) (string, error)
+	RegenerateRecoveryCodes(ctx context.Context, userId int64, code string) (*entity.MFARecoveryCodes, error)
+	Enabled(ctx context.Context, userId int64) (bool, error)
+	Verify(ctx context.Context, userId int64, code string) (bool, error)
+}
+
+type OIDC interface {
+	AuthURL(ctx context.Context, provider string) (*entity.OIDCAuthorization, error)
+	Callback(ctx context.Context, provider string, state string, code string, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error)
+}
+
+// IdentityProvider is an OpenID Connect provider users log in at.
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code string, codeVerifier string) (*oidc.IDToken, error)
+}
+
+// OIDCStore keeps pending logins at identity providers and the identities
+// linked to users.
+type OIDCStore interface {
+	SaveState(ctx context.Context, state string, oidcState *entity.OIDCState, ttl time.Duration) error
+	// TakeState returns nil without an error for unknown or expired states.
+	// A state can be taken once.
+	TakeState(ctx context.Context, state string) (*entity.OIDCState, error)
+	// GetLink returns nil without an error for identities not linked yet.
+	GetLink(ctx context.Context, provider string, subject string) (*entity.OIDCLink, error)
+	SaveLink(ctx context.Context, link *entity.OIDCLink) error
+}
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, actorId int64, shopId int64, req *entity.CreateAPIKey) (*entity.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, shopId int64) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, shopId int64, id string) (*entity.APIKey, error)
+	// Authenticate returns the key and the payload requests made with it
+	// act with.
+	Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, *token.Payload, error)
+}
+
+// APIKeyStore keeps API keys next to the hash of their secret.
+type APIKeyStore interface {
+	SaveAPIKey(ctx context.Context, key *entity.APIKey, secretHash string) error
+	// GetAPIKey returns nil without an error for unknown keys.
+	GetAPIKey(ctx context.Context, id string) (*entity.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, shopId int64) ([]*entity.APIKey, error)
+}
+
+// MFAStore keeps the second factors of users.
+type MFAStore interface {
+	// GetMFA returns nil without an error when the user has none.
+	GetMFA(ctx context.Context, userId int64) (*entity.MFA, error)
+	SaveMFA(ctx context.Context, mfa *entity.MFA) error
+	// UpdateMFA runs update on the user's second factor and saves it when
+	// update reports a change, unless it was changed by someone else in the
+	// meantime; update then runs again on the new one. Users without a
+	// second factor aren't updated.
+	UpdateMFA(ctx context.Context, userId int64, update func(mfa *entity.MFA) (bool, error)) (bool, error)
+	DeleteMFA(ctx context.Context, userId int64) error
 }
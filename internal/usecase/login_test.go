@@ -0,0 +1,138 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// recordedAttempts counts failures without ever locking anybody out, so
+// every failed login reaches loginFailed, and records the lockouts asked
+// for.
+type recordedAttempts struct {
+	usecase.LoginAttemptStore
+
+	failures int
+	lockouts []time.Duration
+}
+
+func (attempts *recordedAttempts) AddFailure(ctx context.Context, email string, window time.Duration) (int, error) {
+	attempts.failures++
+	return attempts.failures, nil
+}
+
+func (attempts *recordedAttempts) Lock(ctx context.Context, email string, until time.Time) error {
+	attempts.lockouts = append(attempts.lockouts, time.Until(until).Round(time.Second))
+	return nil
+}
+
+func (attempts *recordedAttempts) LockedUntil(ctx context.Context, email string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func newLoginTest(t *testing.T, attempts usecase.LoginAttemptStore, threshold int) (*usecase.UserUseCase, *memoryUsers) {
+	t.Helper()
+
+	tokenMaker, err := token.NewJwtMaker("12345678912345678912345678912345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AccessTokenDuration:   time.Hour,
+		RefreshTokenDuration:  24 * time.Hour,
+		LoginLockoutThreshold: threshold,
+		LoginFailureWindow:    time.Hour,
+		LoginLockoutBase:      time.Minute,
+		LoginLockoutMax:       10 * time.Minute,
+	}
+	users := newMemoryUsers()
+	revocations := repo.NewRevocationMemoryStore()
+	sessions := usecase.NewSessionUseCase(cfg, tokenMaker, revocations, repo.NewSessionMemoryStore(), users, newMemoryShops())
+	mfa := usecase.NewMFAUseCase(cfg, users, repo.NewMFAMemoryStore())
+	return usecase.NewUserUseCase(cfg, users, sessions, nil, mfa, attempts, tokenMaker, revocations), users
+}
+
+func login(uc *usecase.UserUseCase, email string, password string) error {
+	_, _, err := uc.LoginUser(context.Background(), &entity.UserLogin{Email: email, Password: password}, &entity.SessionClient{})
+	return err
+}
+
+func TestLoginLockoutDoublesUpToMax(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		want      []time.Duration
+	}{
+		{
+			name:      "doubled and capped",
+			threshold: 3,
+			want:      []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+		},
+		{
+			name:      "disabled",
+			threshold: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := &recordedAttempts{}
+			uc, users := newLoginTest(t, attempts, tt.threshold)
+			users.add(&entity.User{Email: "user@example.com"})
+
+			for i := 0; i < 8; i++ {
+				err := login(uc, "user@example.com", "wrong")
+				if !apperror.Is(err, apperror.KindUnauthorized) {
+					t.Fatalf("login %d returned %v, want an unauthorized error", i, err)
+				}
+			}
+			if !reflect.DeepEqual(attempts.lockouts, tt.want) {
+				t.Errorf("lockouts = %v, want %v", attempts.lockouts, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	uc, users := newLoginTest(t, repo.NewLoginAttemptMemoryStore(), 3)
+	users.add(&entity.User{Email: "user@example.com"})
+	users.add(&entity.User{Email: "other@example.com"})
+
+	// A successful login forgives the failures before it.
+	for _, password := range []string{"wrong", "wrong", _testPassword, "wrong", "wrong", _testPassword} {
+		err := login(uc, "user@example.com", password)
+		if (err == nil) != (password == _testPassword) {
+			t.Fatalf("login with %q returned %v", password, err)
+		}
+	}
+
+	// Failures count per email, whatever its case; the users service doesn't
+	// know the second spelling.
+	for _, email := range []string{"user@example.com", "User@Example.com ", "user@example.com"} {
+		err := login(uc, email, "wrong")
+		if !apperror.Is(err, apperror.KindUnauthorized) && !apperror.Is(err, apperror.KindNotFound) {
+			t.Fatalf("login returned %v, want the login refused", err)
+		}
+	}
+
+	err := login(uc, "user@example.com", _testPassword)
+	e := apperror.As(err)
+	if e == nil || e.Kind != apperror.KindTooMany {
+		t.Fatalf("login of a locked account returned %v, want too many requests", err)
+	}
+	if e.RetryAfter <= 59*time.Second || e.RetryAfter > time.Minute {
+		t.Errorf("locked account can retry after %v, want a minute", e.RetryAfter)
+	}
+
+	err = login(uc, "other@example.com", _testPassword)
+	if err != nil {
+		t.Errorf("login of another account: %v", err)
+	}
+}
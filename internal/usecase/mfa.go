@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/pkg/totp"
+)
+
+const (
+	_recoveryCodeCount = 10
+	// _codeSkew accepts codes of the previous and next time step.
+	_codeSkew = 1
+)
+
+var _recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFAUseCase manages the optional TOTP second factor users add to their
+// password. The login itself asks Verify once a password was accepted.
+type MFAUseCase struct {
+	config *config.Config
+	users  UserWebAPI
+	store  MFAStore
+}
+
+func NewMFAUseCase(config *config.Config, users UserWebAPI, store MFAStore) *MFAUseCase {
+	return &MFAUseCase{
+		config: config,
+		users:  users,
+		store:  store,
+	}
+}
+
+// Enroll creates a new secret for the user. It replaces an enrollment that
+// wasn't confirmed yet.
+func (uc *MFAUseCase) Enroll(ctx context.Context, userId int64) (*entity.MFAEnrollment, error) {
+	mfa, err := uc.store.GetMFA(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, apperror.Conflict("two-factor authentication is already enabled")
+	}
+
+	user, err := uc.users.GetMyProfile(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	err = uc.store.SaveMFA(ctx, &entity.MFA{
+		UserId:    userId,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return &entity.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(uc.config.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables the enrolled secret once the user proves their app
+// generates its codes, and returns the recovery codes.
+func (uc *MFAUseCase) Confirm(ctx context.Context, userId int64, code string) (*entity.MFARecoveryCodes, error) {
+	mfa, err := uc.store.GetMFA(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if mfa == nil {
+		return nil, apperror.NotFound("two-factor authentication is not enrolled")
+	}
+	if mfa.Enabled {
+		return nil, apperror.Conflict("two-factor authentication is already enabled")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	var enabled bool
+	ok, err := uc.store.UpdateMFA(ctx, userId, func(mfa *entity.MFA) (bool, error) {
+		enabled = mfa.Enabled
+		if enabled {
+			return false, nil
+		}
+		ok, err := uc.checkCode(mfa, code)
+		if err != nil || !ok {
+			return false, err
+		}
+		mfa.Enabled = true
+		mfa.RecoveryCodes = hashes
+		return true, nil
+	})
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if enabled {
+		return nil, apperror.Conflict("two-factor authentication is already enabled")
+	}
+	if !ok {
+		return nil, invalidCode()
+	}
+	return &entity.MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// Disable removes the second factor; it takes a code, so a stolen access
+// token alone can't turn it off.
+func (uc *MFAUseCase) Disable(ctx context.Context, userId int64, code string) (string, error) {
+	err := uc.verifyEnabled(ctx, userId, code, nil)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.store.DeleteMFA(ctx, userId)
+	if err != nil {
+		return "", apperror.Internal(err)
+	}
+	return "two-factor authentication was disabled", nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user.
+func (uc *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, userId int64, code string) (*entity.MFARecoveryCodes, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	err = uc.verifyEnabled(ctx, userId, code, func(mfa *entity.MFA) {
+		mfa.RecoveryCodes = hashes
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entity.MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (uc *MFAUseCase) Enabled(ctx context.Context, userId int64) (bool, error) {
+	mfa, err := uc.store.GetMFA(ctx, userId)
+	if err != nil {
+		return false, apperror.Internal(err)
+	}
+	return mfa != nil && mfa.Enabled, nil
+}
+
+// Verify reports whether code is a current code of the user's app or one of
+// their recovery codes. Either works only once.
+func (uc *MFAUseCase) Verify(ctx context.Context, userId int64, code string) (bool, error) {
+	return uc.use(ctx, userId, code, nil)
+}
+
+// verifyEnabled uses code like Verify, and returns an error if the user
+// has no second factor or code isn't valid.
+func (uc *MFAUseCase) verifyEnabled(ctx context.Context, userId int64, code string, then func(mfa *entity.MFA)) error {
+	mfa, err := uc.store.GetMFA(ctx, userId)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if mfa == nil || !mfa.Enabled {
+		return apperror.NotFound("two-factor authentication is not enabled")
+	}
+
+	ok, err := uc.use(ctx, userId, code, then)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return invalidCode()
+	}
+	return nil
+}
+
+// use accepts an app or recovery code of an enabled second factor and
+// saves it, so the code can't be used again, along with the changes of
+// then. Of two requests with the same code only one succeeds.
+func (uc *MFAUseCase) use(ctx context.Context, userId int64, code string, then func(mfa *entity.MFA)) (bool, error) {
+	ok, err := uc.store.UpdateMFA(ctx, userId, func(mfa *entity.MFA) (bool, error) {
+		if !mfa.Enabled {
+			return false, nil
+		}
+		ok, err := uc.checkCode(mfa, code)
+		if err != nil {
+			return false, err
+		}
+		if !ok && !useRecoveryCode(mfa, code) {
+			return false, nil
+		}
+		if then != nil {
+			then(mfa)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return false, apperror.Internal(err)
+	}
+	return ok, nil
+}
+
+// checkCode checks code against the app secret and moves LastCounter past
+// it, so saving mfa makes the code single-use.
+func (uc *MFAUseCase) checkCode(mfa *entity.MFA, code string) (bool, error) {
+	counter, ok, err := totp.Validate(mfa.Secret, strings.TrimSpace(code), time.Now(), _codeSkew)
+	if err != nil {
+		return false, err
+	}
+	if !ok || counter <= mfa.LastCounter {
+		return false, nil
+	}
+
+	mfa.LastCounter = counter
+	return true, nil
+}
+
+// newRecoveryCodes returns the recovery codes to show the user and the
+// hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, _recoveryCodeCount)
+	hashes := make([]string, _recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// newRecoveryCode returns 50 random bits written as "xxxxx-xxxxx".
+func newRecoveryCode() (string, error) {
+	data := make([]byte, 7)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(_recoveryCodeEncoding.EncodeToString(data))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func useRecoveryCode(mfa *entity.MFA, code string) bool {
+	hash := hashRecoveryCode(code)
+	for i, recoveryCode := range mfa.RecoveryCodes {
+		if recoveryCode == hash {
+			mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i], mfa.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+func invalidCode() error {
+	return apperror.Validation("invalid code", apperror.FieldError{
+		Field:   "code",
+		Message: "is not a current code or an unused recovery code",
+	})
+}
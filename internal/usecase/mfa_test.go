@@ -0,0 +1,198 @@
+package usecase_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/pkg/totp"
+)
+
+type mfaTest struct {
+	uc     *usecase.MFAUseCase
+	user   *entity.User
+	secret string
+	// counter is the time step of the code Confirm spent.
+	counter int64
+	// recoveryCodes are the codes Confirm returned.
+	recoveryCodes []string
+}
+
+// newMFATest enables two-factor authentication for a user; Confirm spends
+// the code of the current time step.
+func newMFATest(t *testing.T) *mfaTest {
+	t.Helper()
+
+	users := newMemoryUsers()
+	test := &mfaTest{
+		uc:   usecase.NewMFAUseCase(&config.Config{MFAIssuer: "Delivery"}, users, repo.NewMFAMemoryStore()),
+		user: users.add(&entity.User{Email: "user@example.com"}),
+	}
+	ctx := context.Background()
+
+	enrollment, err := test.uc.Enroll(ctx, test.user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.secret = enrollment.Secret
+	test.counter = totp.Counter(time.Now())
+
+	codes, err := test.uc.Confirm(ctx, test.user.Id, test.code(t, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.recoveryCodes = codes.RecoveryCodes
+	return test
+}
+
+// code returns the app code steps time steps after the one Confirm spent.
+func (test *mfaTest) code(t *testing.T, steps int64) string {
+	t.Helper()
+
+	code, err := totp.Code(test.secret, test.counter+steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMFACodesWorkOnce(t *testing.T) {
+	test := newMFATest(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"code spent by Confirm", test.code(t, 0), false},
+		{"code of the next step", test.code(t, 1), true},
+		{"code of the next step again", test.code(t, 1), false},
+		{"recovery code", test.recoveryCodes[0], true},
+		{"recovery code again", test.recoveryCodes[0], false},
+		{"other recovery code", test.recoveryCodes[1], true},
+		{"wrong code", "not-a-code", false},
+	}
+	for _, tt := range tests {
+		ok, err := test.uc.Verify(ctx, test.user.Id, tt.code)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if ok != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestMFACodeUsedConcurrently(t *testing.T) {
+	test := newMFATest(t)
+	ctx := context.Background()
+
+	for _, code := range []string{test.recoveryCodes[0], test.code(t, 1)} {
+		var accepted atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := test.uc.Verify(ctx, test.user.Id, code)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					accepted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := accepted.Load(); n != 1 {
+			t.Errorf("code %s was accepted %d times, want once", code, n)
+		}
+	}
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	test := newMFATest(t)
+	ctx := context.Background()
+
+	_, err := test.uc.RegenerateRecoveryCodes(ctx, test.user.Id, "not-a-code")
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("RegenerateRecoveryCodes with a wrong code returned %v, want a validation error", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		replaced []*entity.MFARecoveryCodes
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes, err := test.uc.RegenerateRecoveryCodes(ctx, test.user.Id, test.recoveryCodes[0])
+			if err != nil {
+				if !apperror.Is(err, apperror.KindValidation) {
+					t.Error(err)
+				}
+				return
+			}
+			mu.Lock()
+			replaced = append(replaced, codes)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(replaced) != 1 {
+		t.Fatalf("one recovery code regenerated the codes %d times, want once", len(replaced))
+	}
+
+	ok, err := test.uc.Verify(ctx, test.user.Id, test.recoveryCodes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("a replaced recovery code was accepted")
+	}
+	ok, err = test.uc.Verify(ctx, test.user.Id, replaced[0].RecoveryCodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("a new recovery code was rejected")
+	}
+}
+
+func TestDisableMFA(t *testing.T) {
+	test := newMFATest(t)
+	ctx := context.Background()
+
+	_, err := test.uc.Disable(ctx, test.user.Id, test.code(t, 0))
+	if !apperror.Is(err, apperror.KindValidation) {
+		t.Fatalf("Disable with a spent code returned %v, want a validation error", err)
+	}
+
+	_, err = test.uc.Disable(ctx, test.user.Id, test.recoveryCodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled, err := test.uc.Enabled(ctx, test.user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled {
+		t.Error("two-factor authentication is still enabled after Disable")
+	}
+
+	_, err = test.uc.Disable(ctx, test.user.Id, test.recoveryCodes[1])
+	if !apperror.Is(err, apperror.KindNotFound) {
+		t.Errorf("Disable without a second factor returned %v, want a not found error", err)
+	}
+}
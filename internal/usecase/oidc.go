@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/pkg/oidc"
+)
+
+// OIDCUseCase logs users in at OpenID Connect providers. The first login of
+// an identity links it to the user with the same email, or registers one;
+// after that the link is used. Either way the user gets gateway tokens as if
+// they logged in with their password.
+type OIDCUseCase struct {
+	config    *config.Config
+	providers map[string]IdentityProvider
+	users     UserWebAPI
+	logins    User
+	store     OIDCStore
+}
+
+func NewOIDCUseCase(config *config.Config, providers map[string]IdentityProvider, users UserWebAPI, logins User, store OIDCStore) *OIDCUseCase {
+	return &OIDCUseCase{
+		config:    config,
+		providers: providers,
+		users:     users,
+		logins:    logins,
+		store:     store,
+	}
+}
+
+// AuthURL starts a login at provider.
+func (uc *OIDCUseCase) AuthURL(ctx context.Context, providerName string) (*entity.OIDCAuthorization, error) {
+	provider, err := uc.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var values [3]string
+	for i := range values {
+		values[i], err = oidc.NewCodeVerifier()
+		if err != nil {
+			return nil, apperror.Internal(err)
+		}
+	}
+	state, nonce, codeVerifier := values[0], values[1], values[2]
+
+	url, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, providerError(err)
+	}
+
+	err = uc.store.SaveState(ctx, state, &entity.OIDCState{
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+	}, uc.config.OIDCStateDuration)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return &entity.OIDCAuthorization{URL: url, State: state}, nil
+}
+
+// Callback finishes the login the provider redirected back from with code.
+func (uc *OIDCUseCase) Callback(ctx context.Context, providerName string, state string, code string, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error) {
+	provider, err := uc.provider(providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, err := uc.store.TakeState(ctx, state)
+	if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+	if pending == nil || pending.Provider != providerName {
+		return nil, nil, apperror.Unauthorized("login expired or was already finished")
+	}
+
+	idToken, err := provider.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		return nil, nil, providerError(err)
+	}
+	if idToken.Nonce != pending.Nonce {
+		return nil, nil, apperror.Unauthorized("invalid id token: nonce doesn't match")
+	}
+
+	user, err := uc.linkedUser(ctx, providerName, idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return uc.logins.LoginExternal(ctx, user, client)
+}
+
+// linkedUser finds the user of an identity. Unlinked identities are linked by
+// email, which both the provider and the user have to have verified; an
+// unverified account could belong to someone who just typed that email.
+func (uc *OIDCUseCase) linkedUser(ctx context.Context, providerName string, idToken *oidc.IDToken) (*entity.User, error) {
+	link, err := uc.store.GetLink(ctx, providerName, idToken.Subject)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if link != nil {
+		return uc.users.GetMyProfile(ctx, link.UserId)
+	}
+
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return nil, apperror.Forbidden("the identity provider hasn't verified the email")
+	}
+
+	user, err := uc.users.GetUserByEmail(ctx, idToken.Email)
+	switch {
+	case apperror.Is(err, apperror.KindNotFound):
+		user, err = uc.register(ctx, idToken)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.EmailVerified:
+		return nil, apperror.Conflict("an account with this email exists but isn't verified, log in with its password and verify it first")
+	}
+
+	err = uc.store.SaveLink(ctx, &entity.OIDCLink{
+		Provider: providerName,
+		Subject:  idToken.Subject,
+		UserId:   user.Id,
+		Email:    idToken.Email,
+		LinkedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return user, nil
+}
+
+// register signs up the owner of an identity with a random password they
+// can replace by resetting it.
+func (uc *OIDCUseCase) register(ctx context.Context, idToken *oidc.IDToken) (*entity.User, error) {
+	password := make([]byte, 32)
+	_, err := rand.Read(password)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	name := idToken.Name
+	if name == "" {
+		name, _, _ = strings.Cut(idToken.Email, "@")
+	}
+
+	user, err := uc.users.CreateUser(ctx, &entity.UserRegister{
+		Email:    idToken.Email,
+		Password: base64.RawURLEncoding.EncodeToString(password),
+		Name:     name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = uc.users.VerifyEmail(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	user.EmailVerified = true
+	return user, nil
+}
+
+func (uc *OIDCUseCase) provider(name string) (IdentityProvider, error) {
+	provider, ok := uc.providers[name]
+	if !ok {
+		return nil, apperror.NotFound(fmt.Sprintf("unknown identity provider %s", name))
+	}
+	return provider, nil
+}
+
+// providerError tells tokens and codes the provider rejected apart from a
+// provider that can't be reached.
+func providerError(err error) error {
+	var refused *oidc.Error
+	if errors.As(err, &refused) {
+		return apperror.Unauthorized(fmt.Sprintf("the identity provider refused the login: %s", refused.Code))
+	}
+	if errors.Is(err, oidc.ErrInvalidIDToken) {
+		return apperror.Unauthorized(err.Error())
+	}
+	return apperror.Upstream(apperror.CodeUpstreamUnavailable, "the identity provider is unavailable", err)
+}
@@ -0,0 +1,283 @@
+package usecase_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/pkg/oidc"
+	"github.com/zura-t/go_delivery_system/pkg/oidc/oidctest"
+)
+
+// oidcTest drives OIDCUseCase against two stub providers, "test" and
+// "other", with the users service kept in memory.
+type oidcTest struct {
+	uc      *usecase.OIDCUseCase
+	servers map[string]*oidctest.Server
+	store   *repo.OIDCMemoryStore
+	users   *memoryUsers
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+
+	test := &oidcTest{
+		servers: make(map[string]*oidctest.Server),
+		store:   repo.NewOIDCMemoryStore(),
+		users:   newMemoryUsers(),
+	}
+	providers := make(map[string]usecase.IdentityProvider)
+	for _, name := range []string{"test", "other"} {
+		server, err := oidctest.NewServer("gateway", "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(server.Close)
+
+		test.servers[name] = server
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       server.URL,
+			ClientID:     "gateway",
+			ClientSecret: "secret",
+			RedirectURL:  "https://gateway.example.com/v1/oauth/" + name + "/callback",
+		}, http.DefaultClient)
+	}
+
+	cfg := &config.Config{OIDCStateDuration: time.Minute}
+	test.uc = usecase.NewOIDCUseCase(cfg, providers, test.users, memoryLogins{}, test.store)
+	return test
+}
+
+// authorize starts a login at provider as identity and returns the state and
+// code the provider redirected back with.
+func (test *oidcTest) authorize(t *testing.T, provider string, identity oidctest.Identity) (string, string) {
+	t.Helper()
+
+	authorization, err := test.uc.AuthURL(context.Background(), provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := test.servers[provider]
+	server.SignIn(identity)
+	code, state, err := server.Authorize(authorization.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != authorization.State {
+		t.Fatalf("provider returned state %q, want %q", state, authorization.State)
+	}
+	return state, code
+}
+
+func (test *oidcTest) login(t *testing.T, provider string, identity oidctest.Identity) (*entity.User, error) {
+	t.Helper()
+
+	state, code := test.authorize(t, provider, identity)
+	resp, _, err := test.uc.Callback(context.Background(), provider, state, code, &entity.SessionClient{})
+	if err != nil {
+		return nil, err
+	}
+	return &resp.User, nil
+}
+
+// tamperState changes the pending login of state the way an attacker who
+// swapped it would.
+func (test *oidcTest) tamperState(t *testing.T, state string, tamper func(*entity.OIDCState)) {
+	t.Helper()
+
+	ctx := context.Background()
+	pending, err := test.store.TakeState(ctx, state)
+	if err != nil || pending == nil {
+		t.Fatalf("TakeState returned %v, %v", pending, err)
+	}
+	tamper(pending)
+	err = test.store.SaveState(ctx, state, pending, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOIDCRegistersNewIdentity(t *testing.T) {
+	test := newOIDCTest(t)
+	identity := oidctest.Identity{Subject: "1", Email: "new@example.com", EmailVerified: true, Name: "New"}
+
+	user, err := test.login(t, "test", identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != identity.Email || user.Name != identity.Name || !user.EmailVerified {
+		t.Fatalf("login registered %+v, want a verified user for %+v", user, identity)
+	}
+
+	// The link outlives a change of email at the provider.
+	identity.Email = "renamed@example.com"
+	again, err := test.login(t, "test", identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Id != user.Id {
+		t.Fatalf("second login returned user %d, want the linked user %d", again.Id, user.Id)
+	}
+	if test.users.count() != 1 {
+		t.Fatalf("users service has %d users, want 1", test.users.count())
+	}
+}
+
+func TestOIDCLinksByVerifiedEmail(t *testing.T) {
+	test := newOIDCTest(t)
+	existing := test.users.add(&entity.User{Email: "known@example.com", EmailVerified: true})
+
+	user, err := test.login(t, "test", oidctest.Identity{Subject: "1", Email: existing.Email, EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != existing.Id {
+		t.Fatalf("login returned user %d, want the user with that email %d", user.Id, existing.Id)
+	}
+
+	link, err := test.store.GetLink(context.Background(), "test", "1")
+	if err != nil || link == nil || link.UserId != existing.Id {
+		t.Fatalf("GetLink returned %+v, %v, want a link to user %d", link, err, existing.Id)
+	}
+}
+
+func TestOIDCRefusesUnverifiedEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity oidctest.Identity
+		existing *entity.User
+		wantKind apperror.Kind
+	}{
+		{
+			name:     "provider hasn't verified the email",
+			identity: oidctest.Identity{Subject: "1", Email: "known@example.com"},
+			existing: &entity.User{Email: "known@example.com", EmailVerified: true},
+			wantKind: apperror.KindForbidden,
+		},
+		{
+			name:     "provider has no email",
+			identity: oidctest.Identity{Subject: "1", EmailVerified: true},
+			wantKind: apperror.KindForbidden,
+		},
+		{
+			name:     "account hasn't verified the email",
+			identity: oidctest.Identity{Subject: "1", Email: "known@example.com", EmailVerified: true},
+			existing: &entity.User{Email: "known@example.com"},
+			wantKind: apperror.KindConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			test := newOIDCTest(t)
+			want := 0
+			if tc.existing != nil {
+				test.users.add(tc.existing)
+				want = 1
+			}
+
+			_, err := test.login(t, "test", tc.identity)
+			if !apperror.Is(err, tc.wantKind) {
+				t.Fatalf("login returned %v, want a %s error", err, tc.wantKind)
+			}
+
+			link, err := test.store.GetLink(context.Background(), "test", tc.identity.Subject)
+			if err != nil || link != nil {
+				t.Fatalf("GetLink returned %+v, %v, want no link", link, err)
+			}
+			if test.users.count() != want {
+				t.Fatalf("users service has %d users, want %d", test.users.count(), want)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackChecks(t *testing.T) {
+	identity := oidctest.Identity{Subject: "1", Email: "new@example.com", EmailVerified: true}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		callback func(t *testing.T, test *oidcTest) error
+	}{
+		{
+			name: "unknown state",
+			callback: func(t *testing.T, test *oidcTest) error {
+				_, code := test.authorize(t, "test", identity)
+				_, _, err := test.uc.Callback(ctx, "test", "forged", code, &entity.SessionClient{})
+				return err
+			},
+		},
+		{
+			name: "state used twice",
+			callback: func(t *testing.T, test *oidcTest) error {
+				state, code := test.authorize(t, "test", identity)
+				_, _, err := test.uc.Callback(ctx, "test", state, code, &entity.SessionClient{})
+				if err != nil {
+					t.Fatalf("first callback: %v", err)
+				}
+				_, _, err = test.uc.Callback(ctx, "test", state, code, &entity.SessionClient{})
+				return err
+			},
+		},
+		{
+			name: "state of another provider",
+			callback: func(t *testing.T, test *oidcTest) error {
+				state, code := test.authorize(t, "other", identity)
+				_, _, err := test.uc.Callback(ctx, "test", state, code, &entity.SessionClient{})
+				return err
+			},
+		},
+		{
+			name: "nonce doesn't match",
+			callback: func(t *testing.T, test *oidcTest) error {
+				state, code := test.authorize(t, "test", identity)
+				test.tamperState(t, state, func(pending *entity.OIDCState) {
+					pending.Nonce = "replayed"
+				})
+				_, _, err := test.uc.Callback(ctx, "test", state, code, &entity.SessionClient{})
+				return err
+			},
+		},
+		{
+			name: "code verifier doesn't match",
+			callback: func(t *testing.T, test *oidcTest) error {
+				state, code := test.authorize(t, "test", identity)
+				test.tamperState(t, state, func(pending *entity.OIDCState) {
+					verifier, err := oidc.NewCodeVerifier()
+					if err != nil {
+						t.Fatal(err)
+					}
+					pending.CodeVerifier = verifier
+				})
+				_, _, err := test.uc.Callback(ctx, "test", state, code, &entity.SessionClient{})
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			test := newOIDCTest(t)
+			err := tc.callback(t, test)
+			if !apperror.Is(err, apperror.KindUnauthorized) {
+				t.Fatalf("Callback returned %v, want an unauthorized error", err)
+			}
+		})
+	}
+}
+
+// memoryLogins logs in whoever it is given.
+type memoryLogins struct {
+	usecase.User
+}
+
+func (memoryLogins) LoginExternal(ctx context.Context, user *entity.User, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error) {
+	return &entity.UserLoginResponse{User: *user}, nil, nil
+}
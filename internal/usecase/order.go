@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+type OrderUseCase struct {
+	config     *config.Config
+	webapi     OrderWebAPI
+	shopWebapi ShopWebAPI
+	publisher  EventPublisher
+}
+
+func NewOrderUseCase(config *config.Config, webapi OrderWebAPI, shopWebapi ShopWebAPI, publisher EventPublisher) *OrderUseCase {
+	return &OrderUseCase{
+		config:     config,
+		webapi:     webapi,
+		shopWebapi: shopWebapi,
+		publisher:  publisher,
+	}
+}
+
+// CreateOrder checks that the shop is open and that every item is on its
+// menu, prices the order from the menu and forwards it to the orders service.
+func (uc *OrderUseCase) CreateOrder(ctx context.Context, req *entity.CreateOrder) (*entity.Order, error) {
+	shop, err := uc.shopWebapi.GetShopInfo(ctx, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+	if !shop.IsOpenAt(time.Now()) {
+		return nil, apperror.Conflict("shop is closed")
+	}
+	if !shop.DeliversTo(req.DeliveryLocation, uc.config.DeliveryRadiusKm) {
+		return nil, apperror.Conflict("shop does not deliver to this location")
+	}
+
+	menu, err := uc.shopWebapi.GetMenu(ctx, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = priceOrder(req, menu)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := uc.webapi.CreateOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publish(ctx, order, "")
+	return order, nil
+}
+
+func (uc *OrderUseCase) GetOrders(ctx context.Context, userId int64, limit int32, offset int32) ([]*entity.Order, error) {
+	return uc.webapi.GetOrders(ctx, userId, limit, offset)
+}
+
+func (uc *OrderUseCase) GetOrder(ctx context.Context, id int64, userId int64) (*entity.Order, error) {
+	order, err := uc.webapi.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, apperror.NotFound("order not found")
+	}
+	return order, nil
+}
+
+func (uc *OrderUseCase) CancelOrder(ctx context.Context, id int64, userId int64) (*entity.Order, error) {
+	order, err := uc.GetOrder(ctx, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	return uc.transition(ctx, order, entity.OrderStatusCancelled)
+}
+
+// UpdateOrderStatus moves an order along its lifecycle on behalf of somebody
+// whose roles let them manage the orders of the order's shop.
+func (uc *OrderUseCase) UpdateOrderStatus(ctx context.Context, id int64, roles []entity.RoleGrant, status entity.OrderStatus) (*entity.Order, error) {
+	if !status.IsValid() {
+		return nil, apperror.Validation("unknown order status", apperror.FieldError{
+			Field:   "status",
+			Message: fmt.Sprintf("%q is not an order status", status),
+		})
+	}
+
+	order, err := uc.webapi.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entity.HasPermission(roles, entity.PermissionOrdersManage, order.ShopId) {
+		return nil, apperror.Forbidden("you don't manage the shop of this order")
+	}
+
+	return uc.transition(ctx, order, status)
+}
+
+// UpdateDeliveryStatus moves an order through the delivery part of its
+// lifecycle. Callers check that the courier owns the delivery.
+func (uc *OrderUseCase) UpdateDeliveryStatus(ctx context.Context, id int64, status entity.OrderStatus) (*entity.Order, error) {
+	if status != entity.OrderStatusPickedUp && status != entity.OrderStatusDelivered {
+		return nil, apperror.Validation("couriers can only pick up or deliver orders", apperror.FieldError{
+			Field:   "status",
+			Message: fmt.Sprintf("must be %s or %s", entity.OrderStatusPickedUp, entity.OrderStatusDelivered),
+		})
+	}
+
+	order, err := uc.webapi.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.transition(ctx, order, status)
+}
+
+// transition applies a status change allowed by the order state machine and
+// announces it to the other services.
+func (uc *OrderUseCase) transition(ctx context.Context, order *entity.Order, status entity.OrderStatus) (*entity.Order, error) {
+	if !order.Status.CanTransitionTo(status) {
+		return nil, apperror.Conflict(fmt.Sprintf("order can't move from %s to %s", order.Status, status))
+	}
+
+	updated, err := uc.webapi.UpdateOrderStatus(ctx, order.ID, &entity.UpdateOrderStatus{Status: status})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publish(ctx, updated, order.Status)
+	return updated, nil
+}
+
+// publish emits the event for the order's current status. The change is
+// already stored at this point, so a failed delivery is left to the publisher
+// to report rather than failing the request.
+func (uc *OrderUseCase) publish(ctx context.Context, order *entity.Order, from entity.OrderStatus) {
+	eventType := entity.OrderEventType(order.Status)
+	_ = uc.publisher.Publish(ctx, eventType, entity.OrderEvent{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OrderId:    order.ID,
+		UserId:     order.UserId,
+		ShopId:     order.ShopId,
+		From:       from,
+		To:         order.Status,
+		OccurredAt: time.Now(),
+	})
+}
+
+// priceOrder fills names and prices of the requested items from menu and sets
+// the order total. Totals that don't fit the order's price are refused.
+func priceOrder(req *entity.CreateOrder, menu []*entity.GetMenuItem) error {
+	menuItems := make(map[int64]*entity.GetMenuItem, len(menu))
+	for _, item := range menu {
+		menuItems[item.ID] = item
+	}
+
+	var fields []apperror.FieldError
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.Quantity < 1 || item.Quantity > entity.MaxItemQuantity {
+			fields = append(fields, apperror.FieldError{
+				Field:   fmt.Sprintf("items[%d].quantity", i),
+				Message: fmt.Sprintf("must be between 1 and %d", entity.MaxItemQuantity),
+			})
+			continue
+		}
+		menuItem, ok := menuItems[item.MenuItemId]
+		if !ok || menuItem.ShopId != req.ShopId {
+			fields = append(fields, apperror.FieldError{
+				Field:   fmt.Sprintf("items[%d].menu_item_id", i),
+				Message: "is not on the shop's menu",
+			})
+			continue
+		}
+		item.Name = menuItem.Name
+		item.Price = menuItem.Price
+	}
+
+	if len(fields) > 0 {
+		return apperror.Validation("invalid order items", fields...)
+	}
+	total, ok := itemsTotal(req.Items)
+	if !ok {
+		return apperror.Validation("order total is too large", apperror.FieldError{
+			Field:   "items",
+			Message: "total price is too large",
+		})
+	}
+	req.TotalPrice = total
+	return nil
+}
+
+// itemsTotal sums the prices of items and reports false when the total
+// doesn't fit a price.
+func itemsTotal(items []entity.OrderItem) (int32, bool) {
+	var total int64
+	for _, item := range items {
+		total += int64(item.Price) * int64(item.Quantity)
+		if total > math.MaxInt32 || total < math.MinInt32 {
+			return 0, false
+		}
+	}
+	return int32(total), true
+}
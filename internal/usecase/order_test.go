@@ -0,0 +1,102 @@
+package usecase_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/webapi"
+)
+
+// nopPublisher drops every event.
+type nopPublisher struct{}
+
+func (nopPublisher) Publish(ctx context.Context, routingKey string, event any) error {
+	return nil
+}
+
+var testShopLocation = entity.Location{Lat: 41.7151, Lng: 44.8271}
+
+// newTestShop adds an always open shop with a menu item for every price and
+// returns the ids of the items.
+func newTestShop(shops *memoryShops, id int64, prices ...int32) []int64 {
+	shops.addShop(&entity.Shop{ID: id, Name: "shop", Location: testShopLocation})
+
+	ids := make([]int64, len(prices))
+	for i, price := range prices {
+		ids[i] = id*100 + int64(i)
+		shops.addMenuItem(&entity.GetMenuItem{ID: ids[i], Name: "item", Price: price, ShopId: id})
+	}
+	return ids
+}
+
+func newOrderUseCase(shops *memoryShops) *usecase.OrderUseCase {
+	cfg := &config.Config{DeliveryRadiusKm: 5}
+	return usecase.NewOrderUseCase(cfg, webapi.NewOrderMemoryAPI(), shops, nopPublisher{})
+}
+
+func TestCreateOrderPricesItems(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, 250, 1000)
+	uc := newOrderUseCase(shops)
+
+	order, err := uc.CreateOrder(context.Background(), &entity.CreateOrder{
+		UserId: 1,
+		ShopId: 1,
+		Items: []entity.OrderItem{
+			{MenuItemId: items[0], Quantity: 2, Price: 1},
+			{MenuItemId: items[1], Quantity: 3},
+		},
+		DeliveryLocation: testShopLocation,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.TotalPrice != 3500 {
+		t.Fatalf("order total is %d, want 3500", order.TotalPrice)
+	}
+	if order.Items[0].Price != 250 {
+		t.Fatalf("item price is %d, want the menu price 250", order.Items[0].Price)
+	}
+}
+
+func TestCreateOrderRefusesItems(t *testing.T) {
+	shops := newMemoryShops()
+	items := newTestShop(shops, 1, 100, math.MaxInt32)
+	other := newTestShop(shops, 2, 100)
+	uc := newOrderUseCase(shops)
+
+	tests := []struct {
+		name      string
+		items     []entity.OrderItem
+		wantField string
+	}{
+		{"zero quantity", []entity.OrderItem{{MenuItemId: items[0], Quantity: 0}}, "items[0].quantity"},
+		{"quantity over the limit", []entity.OrderItem{{MenuItemId: items[0], Quantity: entity.MaxItemQuantity + 1}}, "items[0].quantity"},
+		{"item of another shop", []entity.OrderItem{{MenuItemId: other[0], Quantity: 1}}, "items[0].menu_item_id"},
+		{"total overflows", []entity.OrderItem{{MenuItemId: items[1], Quantity: 2}}, "items"},
+		{
+			"total overflows over lines",
+			[]entity.OrderItem{{MenuItemId: items[1], Quantity: 1}, {MenuItemId: items[0], Quantity: 1}},
+			"items",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := uc.CreateOrder(context.Background(), &entity.CreateOrder{
+				UserId:           1,
+				ShopId:           1,
+				Items:            tc.items,
+				DeliveryLocation: testShopLocation,
+			})
+			e := apperror.As(err)
+			if e == nil || e.Kind != apperror.KindValidation || len(e.Fields) != 1 || e.Fields[0].Field != tc.wantField {
+				t.Fatalf("CreateOrder returned %v, want a validation error of %s", err, tc.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// ReindexUseCase rebuilds the search index from the shops service into a new
+// version and swaps it in once it is complete, so searches never see a half
+// built index. Changes made while it runs, resumed runs included, are written
+// to both the served version and the one being built, so none are lost by
+// the swap.
+type ReindexUseCase struct {
+	config      *config.Config
+	shops       ShopWebAPI
+	versions    SearchVersions
+	checkpoints ReindexCheckpointStore
+}
+
+func NewReindexUseCase(config *config.Config, shops ShopWebAPI, versions SearchVersions, checkpoints ReindexCheckpointStore) *ReindexUseCase {
+	return &ReindexUseCase{
+		config:      config,
+		shops:       shops,
+		versions:    versions,
+		checkpoints: checkpoints,
+	}
+}
+
+// Reindex pages through every shop, indexing up to opts.Concurrency shops at
+// once, and saves a checkpoint after every page. A checkpoint left by an
+// earlier run is resumed. progress, if set, is called after every page.
+func (uc *ReindexUseCase) Reindex(ctx context.Context, opts entity.ReindexOptions, progress func(*entity.ReindexCheckpoint)) (*entity.ReindexReport, error) {
+	if opts.PageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive")
+	}
+	if opts.Concurrency <= 0 {
+		return nil, fmt.Errorf("concurrency must be positive")
+	}
+
+	report, err := uc.start(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &report.ReindexCheckpoint
+
+	for {
+		shops, err := uc.shops.GetShops(ctx, opts.PageSize, checkpoint.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("get shops at offset %d: %w", checkpoint.Offset, err)
+		}
+
+		documents, err := uc.indexShops(ctx, opts, checkpoint.Version, shops)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoint.Offset += int32(len(shops))
+		checkpoint.Shops += len(shops)
+		checkpoint.Documents += documents
+		checkpoint.UpdatedAt = time.Now()
+		if !opts.DryRun {
+			err = uc.checkpoints.SaveCheckpoint(ctx, checkpoint)
+			if err != nil {
+				return nil, fmt.Errorf("save checkpoint: %w", err)
+			}
+		}
+		if progress != nil {
+			progress(checkpoint)
+		}
+
+		if int32(len(shops)) < opts.PageSize {
+			break
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	report.Replaced, err = uc.versions.PublishVersion(ctx, checkpoint.Version)
+	if err != nil {
+		return nil, fmt.Errorf("publish %s: %w", checkpoint.Version, err)
+	}
+	if !opts.KeepPrevious {
+		for _, version := range report.Replaced {
+			err = uc.versions.DeleteVersion(ctx, version)
+			if err != nil {
+				return nil, fmt.Errorf("delete %s: %w", version, err)
+			}
+		}
+	}
+
+	err = uc.checkpoints.DeleteCheckpoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("delete checkpoint: %w", err)
+	}
+	return report, nil
+}
+
+// start resumes the checkpoint of an unfinished run for the same alias or
+// creates a new version.
+func (uc *ReindexUseCase) start(ctx context.Context, opts entity.ReindexOptions) (*entity.ReindexReport, error) {
+	now := time.Now()
+	report := &entity.ReindexReport{
+		ReindexCheckpoint: entity.ReindexCheckpoint{
+			Alias:     uc.config.SearchIndex,
+			Version:   entity.SearchIndexVersion(uc.config.SearchIndex, now),
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+		DryRun: opts.DryRun,
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	checkpoint, err := uc.checkpoints.GetCheckpoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get checkpoint: %w", err)
+	}
+	if checkpoint != nil {
+		if checkpoint.Alias != uc.config.SearchIndex {
+			return nil, fmt.Errorf("checkpoint is of a reindex of %s, not %s", checkpoint.Alias, uc.config.SearchIndex)
+		}
+		report.ReindexCheckpoint = *checkpoint
+		report.Resumed = true
+		return report, nil
+	}
+
+	err = uc.versions.CreateVersion(ctx, report.Version)
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", report.Version, err)
+	}
+	err = uc.checkpoints.SaveCheckpoint(ctx, &report.ReindexCheckpoint)
+	if err != nil {
+		return nil, fmt.Errorf("save checkpoint: %w", err)
+	}
+	return report, nil
+}
+
+// indexShops indexes a page of shops with their menus and returns how many
+// documents they made. A dry run only reads the menus.
+func (uc *ReindexUseCase) indexShops(ctx context.Context, opts entity.ReindexOptions, version string, shops []*entity.Shop) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		documents int
+		firstErr  error
+	)
+	slots := make(chan struct{}, opts.Concurrency)
+	for _, shop := range shops {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(shop *entity.Shop) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			docs, err := shopDocuments(ctx, uc.shops, shop)
+			if err == nil && !opts.DryRun {
+				err = uc.versions.IndexVersion(ctx, version, docs)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("index shop %d: %w", shop.ID, err)
+				}
+				cancel()
+				return
+			}
+			documents += len(docs)
+		}(shop)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return documents, nil
+}
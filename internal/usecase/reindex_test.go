@@ -0,0 +1,191 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+// failingShops fails GetShops once at failAt, like a shops service going
+// away in the middle of a reindex.
+type failingShops struct {
+	*memoryShops
+
+	failAt int32
+	failed bool
+}
+
+func (shops *failingShops) GetShops(ctx context.Context, limit int32, offset int32) ([]*entity.Shop, error) {
+	if offset == shops.failAt && !shops.failed {
+		shops.failed = true
+		return nil, errors.New("shops service is unavailable")
+	}
+	return shops.memoryShops.GetShops(ctx, limit, offset)
+}
+
+type reindexTest struct {
+	shops       *memoryShops
+	index       *repo.SearchMemoryIndex
+	checkpoints *repo.ReindexCheckpointFileStore
+	cfg         *config.Config
+}
+
+// newReindexTest serves an index that still has shop 9, since closed, and
+// lists shops 1 to 5 with one menu item each in the shops service.
+func newReindexTest(t *testing.T) *reindexTest {
+	t.Helper()
+
+	test := &reindexTest{
+		shops:       newMemoryShops(),
+		index:       repo.NewSearchMemoryIndex(),
+		checkpoints: repo.NewReindexCheckpointFileStore(filepath.Join(t.TempDir(), "reindex.json")),
+		cfg:         &config.Config{SearchIndex: "shops", SearchTimeout: time.Second},
+	}
+	for id := int64(1); id <= 5; id++ {
+		test.shops.addShop(&entity.Shop{ID: id, Name: fmt.Sprintf("shop %d", id)})
+		test.shops.addMenuItem(&entity.GetMenuItem{ID: id * 100, Name: "item", Price: 100, ShopId: id})
+	}
+
+	ctx := context.Background()
+	err := test.index.CreateVersion(ctx, "shops-old")
+	if err == nil {
+		err = test.index.IndexVersion(ctx, "shops-old", []*entity.SearchDocument{entity.NewShopDocument(&entity.Shop{ID: 9, Name: "shop 9"})})
+	}
+	if err == nil {
+		_, err = test.index.PublishVersion(ctx, "shops-old")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return test
+}
+
+var reindexOptions = entity.ReindexOptions{PageSize: 2, Concurrency: 2}
+
+// shopNames returns the names of the shops searches find.
+func (test *reindexTest) shopNames(t *testing.T) map[int64]string {
+	t.Helper()
+
+	result, err := test.index.Search(context.Background(), &entity.SearchQuery{Kind: entity.SearchKindShop, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[int64]string)
+	for _, hit := range result.Hits {
+		names[hit.ID] = hit.Name
+	}
+	return names
+}
+
+func TestReindexSwap(t *testing.T) {
+	test := newReindexTest(t)
+	uc := usecase.NewReindexUseCase(test.cfg, test.shops, test.index, test.checkpoints)
+	ctx := context.Background()
+
+	var pages int
+	report, err := uc.Reindex(ctx, reindexOptions, func(*entity.ReindexCheckpoint) { pages++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Shops != 5 || report.Documents != 10 || pages != 3 || report.Resumed {
+		t.Fatalf("Reindex reported %+v after %d pages, want 5 shops and 10 documents in 3 pages", report, pages)
+	}
+	if !reflect.DeepEqual(report.Replaced, []string{"shops-old"}) {
+		t.Fatalf("Reindex replaced %v, want shops-old", report.Replaced)
+	}
+
+	names := test.shopNames(t)
+	if len(names) != 5 || names[9] != "" {
+		t.Fatalf("index has shops %v after the swap, want shops 1 to 5", names)
+	}
+	checkpoint, err := test.checkpoints.GetCheckpoint(ctx)
+	if err != nil || checkpoint != nil {
+		t.Fatalf("GetCheckpoint after the swap returned %+v, %v, want none", checkpoint, err)
+	}
+	// The replaced version was deleted.
+	err = test.index.CreateVersion(ctx, "shops-old")
+	if err != nil {
+		t.Fatalf("CreateVersion of the replaced version: %v", err)
+	}
+}
+
+func TestReindexDryRun(t *testing.T) {
+	test := newReindexTest(t)
+	uc := usecase.NewReindexUseCase(test.cfg, test.shops, test.index, test.checkpoints)
+	ctx := context.Background()
+
+	opts := reindexOptions
+	opts.DryRun = true
+	report, err := uc.Reindex(ctx, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Shops != 5 || report.Documents != 10 || report.Replaced != nil {
+		t.Fatalf("Reindex reported %+v, want a dry run counting 5 shops and 10 documents", report)
+	}
+
+	names := test.shopNames(t)
+	if len(names) != 1 || names[9] == "" {
+		t.Fatalf("index has shops %v after a dry run, want it untouched", names)
+	}
+	checkpoint, err := test.checkpoints.GetCheckpoint(ctx)
+	if err != nil || checkpoint != nil {
+		t.Fatalf("GetCheckpoint after a dry run returned %+v, %v, want none", checkpoint, err)
+	}
+}
+
+// TestReindexResume stops a reindex halfway, renames a shop it has already
+// copied and runs it again: the rename outlives the swap.
+func TestReindexResume(t *testing.T) {
+	test := newReindexTest(t)
+	shops := &failingShops{memoryShops: test.shops, failAt: 2}
+	uc := usecase.NewReindexUseCase(test.cfg, shops, test.index, test.checkpoints)
+	search := usecase.NewSearchUseCase(test.cfg, test.index, shops)
+	ctx := context.Background()
+
+	_, err := uc.Reindex(ctx, reindexOptions, nil)
+	if err == nil {
+		t.Fatal("Reindex succeeded without the shops service")
+	}
+	checkpoint, err := test.checkpoints.GetCheckpoint(ctx)
+	if err != nil || checkpoint == nil || checkpoint.Offset != 2 {
+		t.Fatalf("GetCheckpoint returned %+v, %v, want the first page done", checkpoint, err)
+	}
+
+	renamed := &entity.Shop{ID: 1, Name: "renamed"}
+	test.shops.addShop(renamed)
+	body, err := json.Marshal(entity.ShopEvent{ShopId: 1, Shop: renamed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = search.Dispatch(entity.ShopEventUpdated, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := test.shopNames(t)[1]; name != "renamed" {
+		t.Fatalf("shop 1 is %q before the swap, want the rename served", name)
+	}
+
+	report, err := uc.Reindex(ctx, reindexOptions, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Resumed || report.Version != checkpoint.Version || report.Shops != 5 || report.Documents != 10 {
+		t.Fatalf("Reindex reported %+v, want %s resumed with 5 shops and 10 documents", report, checkpoint.Version)
+	}
+
+	names := test.shopNames(t)
+	if len(names) != 5 || names[1] != "renamed" {
+		t.Fatalf("index has shops %v after the swap, want shop 1 renamed", names)
+	}
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// AdminMemoryStore keeps admin requests and the audit log of this gateway
+// instance.
+type AdminMemoryStore struct {
+	mu       sync.Mutex
+	requests map[int64]entity.AdminRequest
+	audit    []entity.AuditEntry
+}
+
+func NewAdminMemoryStore() *AdminMemoryStore {
+	return &AdminMemoryStore{
+		requests: make(map[int64]entity.AdminRequest),
+	}
+}
+
+func (store *AdminMemoryStore) GetRequest(ctx context.Context, userId int64) (*entity.AdminRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	req, ok := store.requests[userId]
+	if !ok {
+		return nil, nil
+	}
+	return &req, nil
+}
+
+func (store *AdminMemoryStore) SaveRequest(ctx context.Context, req *entity.AdminRequest) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.requests[req.UserId] = *req
+	return nil
+}
+
+func (store *AdminMemoryStore) ListRequests(ctx context.Context, status entity.AdminRequestStatus) ([]*entity.AdminRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	requests := []*entity.AdminRequest{}
+	for _, req := range store.requests {
+		if req.Status == status {
+			req := req
+			requests = append(requests, &req)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+	return requests, nil
+}
+
+func (store *AdminMemoryStore) AddAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.audit = append(store.audit, *entry)
+	return nil
+}
+
+func (store *AdminMemoryStore) ListAuditEntries(ctx context.Context, limit int32, offset int32) ([]*entity.AuditEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entries := []*entity.AuditEntry{}
+	for i := len(store.audit) - 1 - int(offset); i >= 0 && len(entries) < int(limit); i-- {
+		entry := store.audit[i]
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+const (
+	_adminRequestsKey = "admin:requests"
+	_adminAuditKey    = "admin:audit"
+)
+
+// AdminRedisStore keeps admin requests in a hash keyed by user and the audit
+// log in a list, newest entry first. Neither expires.
+type AdminRedisStore struct {
+	client redis.Cmdable
+}
+
+func NewAdminRedisStore(client redis.Cmdable) *AdminRedisStore {
+	return &AdminRedisStore{client: client}
+}
+
+func (store *AdminRedisStore) GetRequest(ctx context.Context, userId int64) (*entity.AdminRequest, error) {
+	data, err := store.client.HGet(ctx, _adminRequestsKey, strconv.FormatInt(userId, 10)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var req entity.AdminRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (store *AdminRedisStore) SaveRequest(ctx context.Context, req *entity.AdminRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return store.client.HSet(ctx, _adminRequestsKey, strconv.FormatInt(req.UserId, 10), data).Err()
+}
+
+func (store *AdminRedisStore) ListRequests(ctx context.Context, status entity.AdminRequestStatus) ([]*entity.AdminRequest, error) {
+	values, err := store.client.HVals(ctx, _adminRequestsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	requests := []*entity.AdminRequest{}
+	for _, value := range values {
+		var req entity.AdminRequest
+		err = json.Unmarshal([]byte(value), &req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Status == status {
+			requests = append(requests, &req)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+	return requests, nil
+}
+
+func (store *AdminRedisStore) AddAuditEntry(ctx context.Context, entry *entity.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return store.client.LPush(ctx, _adminAuditKey, data).Err()
+}
+
+func (store *AdminRedisStore) ListAuditEntries(ctx context.Context, limit int32, offset int32) ([]*entity.AuditEntry, error) {
+	values, err := store.client.LRange(ctx, _adminAuditKey, int64(offset), int64(offset)+int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entity.AuditEntry, 0, len(values))
+	for _, value := range values {
+		var entry entity.AuditEntry
+		err = json.Unmarshal([]byte(value), &entry)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+type storedAPIKey struct {
+	Key        entity.APIKey `json:"key"`
+	SecretHash string        `json:"secret_hash"`
+}
+
+// APIKeyMemoryStore keeps the API keys of this gateway instance.
+type APIKeyMemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]storedAPIKey
+}
+
+func NewAPIKeyMemoryStore() *APIKeyMemoryStore {
+	return &APIKeyMemoryStore{
+		keys: make(map[string]storedAPIKey),
+	}
+}
+
+func (store *APIKeyMemoryStore) SaveAPIKey(ctx context.Context, key *entity.APIKey, secretHash string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	saved := *key
+	saved.Scopes = append([]entity.Permission(nil), key.Scopes...)
+	store.keys[key.ID] = storedAPIKey{Key: saved, SecretHash: secretHash}
+	return nil
+}
+
+func (store *APIKeyMemoryStore) GetAPIKey(ctx context.Context, id string) (*entity.APIKey, string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	stored, ok := store.keys[id]
+	if !ok {
+		return nil, "", nil
+	}
+	key := stored.Key
+	key.Scopes = append([]entity.Permission(nil), key.Scopes...)
+	return &key, stored.SecretHash, nil
+}
+
+func (store *APIKeyMemoryStore) ListAPIKeys(ctx context.Context, shopId int64) ([]*entity.APIKey, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	keys := make([]*entity.APIKey, 0)
+	for _, stored := range store.keys {
+		if stored.Key.ShopId != shopId {
+			continue
+		}
+		key := stored.Key
+		key.Scopes = append([]entity.Permission(nil), key.Scopes...)
+		keys = append(keys, &key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
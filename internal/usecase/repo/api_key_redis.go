@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+const _apiKeysKey = "apikeys"
+
+// APIKeyRedisStore keeps API keys in a hash keyed by id, and the ids of the
+// keys of every shop in a set. Revoked keys are kept to be listed.
+type APIKeyRedisStore struct {
+	client redis.Cmdable
+}
+
+func NewAPIKeyRedisStore(client redis.Cmdable) *APIKeyRedisStore {
+	return &APIKeyRedisStore{client: client}
+}
+
+func (store *APIKeyRedisStore) SaveAPIKey(ctx context.Context, key *entity.APIKey, secretHash string) error {
+	data, err := json.Marshal(storedAPIKey{Key: *key, SecretHash: secretHash})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, _apiKeysKey, key.ID, data)
+		pipe.SAdd(ctx, shopAPIKeysKey(key.ShopId), key.ID)
+		return nil
+	})
+	return err
+}
+
+func (store *APIKeyRedisStore) GetAPIKey(ctx context.Context, id string) (*entity.APIKey, string, error) {
+	data, err := store.client.HGet(ctx, _apiKeysKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var stored storedAPIKey
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return nil, "", err
+	}
+	return &stored.Key, stored.SecretHash, nil
+}
+
+func (store *APIKeyRedisStore) ListAPIKeys(ctx context.Context, shopId int64) ([]*entity.APIKey, error) {
+	ids, err := store.client.SMembers(ctx, shopAPIKeysKey(shopId)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*entity.APIKey, 0, len(ids))
+	if len(ids) == 0 {
+		return keys, nil
+	}
+
+	values, err := store.client.HMGet(ctx, _apiKeysKey, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var stored storedAPIKey
+		err = json.Unmarshal([]byte(data), &stored)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &stored.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func shopAPIKeysKey(shopId int64) string {
+	return "apikeys:shop:" + strconv.FormatInt(shopId, 10)
+}
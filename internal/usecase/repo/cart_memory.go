@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+type CartMemoryStore struct {
+	mu    sync.Mutex
+	carts map[int64]entity.Cart
+}
+
+func NewCartMemoryStore() *CartMemoryStore {
+	return &CartMemoryStore{
+		carts: make(map[int64]entity.Cart),
+	}
+}
+
+func (store *CartMemoryStore) Get(ctx context.Context, userId int64) (*entity.Cart, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	cart, ok := store.carts[userId]
+	if !ok {
+		return &entity.Cart{UserId: userId, Items: []entity.CartItem{}}, nil
+	}
+	cart.Items = append([]entity.CartItem{}, cart.Items...)
+	return &cart, nil
+}
+
+func (store *CartMemoryStore) Save(ctx context.Context, cart *entity.Cart) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	saved := *cart
+	saved.Items = append([]entity.CartItem{}, cart.Items...)
+	store.carts[cart.UserId] = saved
+	return nil
+}
+
+func (store *CartMemoryStore) Delete(ctx context.Context, userId int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.carts, userId)
+	return nil
+}
@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// CartRedisStore keeps carts as JSON values in any Redis-compatible server.
+// Carts expire after ttl without changes.
+type CartRedisStore struct {
+	client redis.Cmdable
+	ttl    time.Duration
+}
+
+func NewCartRedisStore(client redis.Cmdable, ttl time.Duration) *CartRedisStore {
+	return &CartRedisStore{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func cartKey(userId int64) string {
+	return fmt.Sprintf("cart:%d", userId)
+}
+
+func (store *CartRedisStore) Get(ctx context.Context, userId int64) (*entity.Cart, error) {
+	data, err := store.client.Get(ctx, cartKey(userId)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return &entity.Cart{UserId: userId, Items: []entity.CartItem{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cart entity.Cart
+	err = json.Unmarshal(data, &cart)
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (store *CartRedisStore) Save(ctx context.Context, cart *entity.Cart) error {
+	data, err := json.Marshal(cart)
+	if err != nil {
+		return err
+	}
+	return store.client.Set(ctx, cartKey(cart.UserId), data, store.ttl).Err()
+}
+
+func (store *CartRedisStore) Delete(ctx context.Context, userId int64) error {
+	return store.client.Del(ctx, cartKey(userId)).Err()
+}
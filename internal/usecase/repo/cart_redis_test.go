@@ -0,0 +1,138 @@
+package repo_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+func newCartRedisStore(t *testing.T, ttl time.Duration) (*repo.CartRedisStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return repo.NewCartRedisStore(client, ttl), server
+}
+
+func TestCartRedisStore(t *testing.T) {
+	store, server := newCartRedisStore(t, time.Hour)
+	ctx := context.Background()
+
+	cart, err := store.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.UserId != 1 || cart.Items == nil || len(cart.Items) != 0 {
+		t.Fatalf("Get of a missing cart returned %+v, want an empty cart of user 1", cart)
+	}
+
+	saved := &entity.Cart{
+		UserId: 1,
+		ShopId: 7,
+		Items: []entity.CartItem{
+			{OrderItem: entity.OrderItem{MenuItemId: 70, Name: "soup", Price: 450, Quantity: 2}},
+			{OrderItem: entity.OrderItem{MenuItemId: 71, Name: "bread", Price: 100, Quantity: 1}},
+			{OrderItem: entity.OrderItem{MenuItemId: 72, Name: "cake", Price: 300, Quantity: 1}, Unavailable: true},
+		},
+		TotalPrice: 1000,
+		UpdatedAt:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	err = store.Save(ctx, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cart, err = store.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cart, saved) {
+		t.Fatalf("Get returned %+v, want %+v", cart, saved)
+	}
+
+	other, err := store.Get(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(other.Items) != 0 {
+		t.Fatalf("Get of another user returned %+v, want an empty cart", other)
+	}
+
+	err = store.Delete(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart, err = store.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cart.Items) != 0 {
+		t.Fatalf("Get after Delete returned %+v, want an empty cart", cart)
+	}
+	if server.Exists("cart:1") {
+		t.Fatal("Delete left the key behind")
+	}
+
+	// Deleting a missing cart is fine.
+	err = store.Delete(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCartRedisStoreExpires(t *testing.T) {
+	store, server := newCartRedisStore(t, time.Hour)
+	ctx := context.Background()
+
+	err := store.Save(ctx, &entity.Cart{UserId: 1, ShopId: 7, Items: []entity.CartItem{{OrderItem: entity.OrderItem{MenuItemId: 70, Quantity: 1}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL("cart:1"); ttl != time.Hour {
+		t.Fatalf("cart expires in %v, want %v", ttl, time.Hour)
+	}
+
+	// Every change starts the ttl over.
+	server.FastForward(50 * time.Minute)
+	err = store.Save(ctx, &entity.Cart{UserId: 1, ShopId: 7, Items: []entity.CartItem{{OrderItem: entity.OrderItem{MenuItemId: 70, Quantity: 2}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(50 * time.Minute)
+	cart, err := store.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 2 {
+		t.Fatalf("Get returned %+v, want the cart saved 50 minutes ago", cart)
+	}
+
+	server.FastForward(time.Hour)
+	cart, err = store.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cart.Items) != 0 {
+		t.Fatalf("Get of an expired cart returned %+v, want an empty cart", cart)
+	}
+}
+
+func TestCartRedisStoreCorrupt(t *testing.T) {
+	store, server := newCartRedisStore(t, time.Hour)
+
+	err := server.Set("cart:1", "not json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.Get(context.Background(), 1)
+	if err == nil {
+		t.Fatal("Get of a corrupt cart succeeded")
+	}
+}
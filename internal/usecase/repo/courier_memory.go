@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// CourierMemoryStore keeps couriers and deliveries of this gateway instance,
+// so only a single instance can dispatch with it. Lookups return copies, so
+// callers must Save their changes.
+type CourierMemoryStore struct {
+	// lock is the dispatch lock, a channel so waiting for it can be canceled.
+	lock chan struct{}
+
+	mu         sync.Mutex
+	couriers   map[int64]entity.Courier
+	deliveries map[int64]entity.Delivery
+}
+
+func NewCourierMemoryStore() *CourierMemoryStore {
+	return &CourierMemoryStore{
+		lock:       make(chan struct{}, 1),
+		couriers:   make(map[int64]entity.Courier),
+		deliveries: make(map[int64]entity.Delivery),
+	}
+}
+
+func (store *CourierMemoryStore) Lock(ctx context.Context) (func(), error) {
+	select {
+	case store.lock <- struct{}{}:
+		return func() { <-store.lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GetCourier returns an offline courier for users that never went online.
+func (store *CourierMemoryStore) GetCourier(ctx context.Context, userId int64) (*entity.Courier, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	courier, ok := store.couriers[userId]
+	if !ok {
+		return &entity.Courier{UserId: userId, Status: entity.CourierStatusOffline}, nil
+	}
+	return &courier, nil
+}
+
+func (store *CourierMemoryStore) SaveCourier(ctx context.Context, courier *entity.Courier) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.couriers[courier.UserId] = *courier
+	return nil
+}
+
+func (store *CourierMemoryStore) ListCouriers(ctx context.Context, status entity.CourierStatus) ([]*entity.Courier, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	couriers := []*entity.Courier{}
+	for _, courier := range store.couriers {
+		if courier.Status == status {
+			courier := courier
+			couriers = append(couriers, &courier)
+		}
+	}
+	sort.Slice(couriers, func(i, j int) bool {
+		return couriers[i].UpdatedAt.Before(couriers[j].UpdatedAt)
+	})
+	return couriers, nil
+}
+
+// GetDelivery returns nil without an error when the order has no delivery.
+func (store *CourierMemoryStore) GetDelivery(ctx context.Context, orderId int64) (*entity.Delivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.deliveries[orderId]
+	if !ok {
+		return nil, nil
+	}
+	delivery.Declined = append([]int64{}, delivery.Declined...)
+	return &delivery, nil
+}
+
+func (store *CourierMemoryStore) SaveDelivery(ctx context.Context, delivery *entity.Delivery) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	saved := *delivery
+	saved.Declined = append([]int64{}, delivery.Declined...)
+	store.deliveries[delivery.OrderId] = saved
+	return nil
+}
+
+func (store *CourierMemoryStore) DeleteDelivery(ctx context.Context, orderId int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.deliveries, orderId)
+	return nil
+}
+
+// ListDeliveries returns the deliveries in the given status, oldest first.
+func (store *CourierMemoryStore) ListDeliveries(ctx context.Context, status entity.DeliveryStatus) ([]*entity.Delivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	deliveries := []*entity.Delivery{}
+	for _, delivery := range store.deliveries {
+		if delivery.Status == status {
+			delivery := delivery
+			delivery.Declined = append([]int64{}, delivery.Declined...)
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	return deliveries, nil
+}
@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+const (
+	_couriersKey     = "dispatch:couriers"
+	_deliveriesKey   = "dispatch:deliveries"
+	_dispatchLockKey = "dispatch:lock"
+	// _lockRetryInterval is how often a waiting instance tries the lock again.
+	_lockRetryInterval = 20 * time.Millisecond
+	_defaultLockTTL    = 10 * time.Second
+)
+
+// _unlockScript deletes the lock only for its owner. A lease that ran out
+// may have been taken by another instance in the meantime.
+var _unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// CourierRedisStore keeps couriers and deliveries in two hashes of any
+// Redis-compatible server, keyed by user and order, so every gateway instance
+// dispatches from the same state. The dispatch lock is a lease that runs out
+// after lockTTL, ten seconds if it isn't positive, in case its holder dies.
+type CourierRedisStore struct {
+	client  redis.Cmdable
+	lockTTL time.Duration
+}
+
+func NewCourierRedisStore(client redis.Cmdable, lockTTL time.Duration) *CourierRedisStore {
+	if lockTTL <= 0 {
+		lockTTL = _defaultLockTTL
+	}
+	return &CourierRedisStore{
+		client:  client,
+		lockTTL: lockTTL,
+	}
+}
+
+func (store *CourierRedisStore) Lock(ctx context.Context) (func(), error) {
+	owner := uuid.NewString()
+	for {
+		ok, err := store.client.SetNX(ctx, _dispatchLockKey, owner, store.lockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(_lockRetryInterval):
+		}
+	}
+
+	return func() {
+		_ = _unlockScript.Run(context.Background(), store.client, []string{_dispatchLockKey}, owner).Err()
+	}, nil
+}
+
+// GetCourier returns an offline courier for users that never went online.
+func (store *CourierRedisStore) GetCourier(ctx context.Context, userId int64) (*entity.Courier, error) {
+	data, err := store.client.HGet(ctx, _couriersKey, strconv.FormatInt(userId, 10)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return &entity.Courier{UserId: userId, Status: entity.CourierStatusOffline}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var courier entity.Courier
+	err = json.Unmarshal(data, &courier)
+	if err != nil {
+		return nil, err
+	}
+	return &courier, nil
+}
+
+func (store *CourierRedisStore) SaveCourier(ctx context.Context, courier *entity.Courier) error {
+	data, err := json.Marshal(courier)
+	if err != nil {
+		return err
+	}
+	return store.client.HSet(ctx, _couriersKey, strconv.FormatInt(courier.UserId, 10), data).Err()
+}
+
+func (store *CourierRedisStore) ListCouriers(ctx context.Context, status entity.CourierStatus) ([]*entity.Courier, error) {
+	values, err := store.client.HVals(ctx, _couriersKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	couriers := []*entity.Courier{}
+	for _, value := range values {
+		var courier entity.Courier
+		err = json.Unmarshal([]byte(value), &courier)
+		if err != nil {
+			return nil, err
+		}
+		if courier.Status == status {
+			couriers = append(couriers, &courier)
+		}
+	}
+	sort.Slice(couriers, func(i, j int) bool {
+		return couriers[i].UpdatedAt.Before(couriers[j].UpdatedAt)
+	})
+	return couriers, nil
+}
+
+// GetDelivery returns nil without an error when the order has no delivery.
+func (store *CourierRedisStore) GetDelivery(ctx context.Context, orderId int64) (*entity.Delivery, error) {
+	data, err := store.client.HGet(ctx, _deliveriesKey, strconv.FormatInt(orderId, 10)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery entity.Delivery
+	err = json.Unmarshal(data, &delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (store *CourierRedisStore) SaveDelivery(ctx context.Context, delivery *entity.Delivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return store.client.HSet(ctx, _deliveriesKey, strconv.FormatInt(delivery.OrderId, 10), data).Err()
+}
+
+func (store *CourierRedisStore) DeleteDelivery(ctx context.Context, orderId int64) error {
+	return store.client.HDel(ctx, _deliveriesKey, strconv.FormatInt(orderId, 10)).Err()
+}
+
+// ListDeliveries returns the deliveries in the given status, oldest first.
+func (store *CourierRedisStore) ListDeliveries(ctx context.Context, status entity.DeliveryStatus) ([]*entity.Delivery, error) {
+	values, err := store.client.HVals(ctx, _deliveriesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []*entity.Delivery{}
+	for _, value := range values {
+		var delivery entity.Delivery
+		err = json.Unmarshal([]byte(value), &delivery)
+		if err != nil {
+			return nil, err
+		}
+		if delivery.Status == status {
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	return deliveries, nil
+}
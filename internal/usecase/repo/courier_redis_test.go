@@ -0,0 +1,166 @@
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+func newCourierRedisStore(t *testing.T, server *miniredis.Miniredis) *repo.CourierRedisStore {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return repo.NewCourierRedisStore(client, time.Minute)
+}
+
+func TestCourierRedisStore(t *testing.T) {
+	store := newCourierRedisStore(t, miniredis.RunT(t))
+	ctx := context.Background()
+
+	courier, err := store.GetCourier(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if courier.UserId != 1 || courier.Status != entity.CourierStatusOffline {
+		t.Fatalf("GetCourier of a new courier returned %+v, want an offline courier", courier)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for i, status := range []entity.CourierStatus{entity.CourierStatusAvailable, entity.CourierStatusOffered, entity.CourierStatusAvailable} {
+		err = store.SaveCourier(ctx, &entity.Courier{
+			UserId: int64(10 - i),
+			Status: status,
+			// Saved newest first, listed oldest first.
+			UpdatedAt: now.Add(-time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	available, err := store.ListCouriers(ctx, entity.CourierStatusAvailable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(available) != 2 || available[0].UserId != 8 || available[1].UserId != 10 {
+		t.Fatalf("ListCouriers returned %+v, want couriers 8 and 10", available)
+	}
+
+	delivery, err := store.GetDelivery(ctx, 100)
+	if err != nil || delivery != nil {
+		t.Fatalf("GetDelivery of an unknown order returned %+v, %v, want nil", delivery, err)
+	}
+	saved := &entity.Delivery{
+		OrderId:   100,
+		ShopId:    7,
+		Pickup:    entity.Location{Lat: 41.7, Lng: 44.8},
+		Status:    entity.DeliveryStatusPending,
+		Declined:  []int64{8},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = store.SaveDelivery(ctx, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.SaveDelivery(ctx, &entity.Delivery{OrderId: 99, Status: entity.DeliveryStatusPending, CreatedAt: now.Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivery, err = store.GetDelivery(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.ShopId != 7 || delivery.Pickup != saved.Pickup || len(delivery.Declined) != 1 || !delivery.CreatedAt.Equal(now) {
+		t.Fatalf("GetDelivery returned %+v, want %+v", delivery, saved)
+	}
+
+	pending, err := store.ListDeliveries(ctx, entity.DeliveryStatusPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].OrderId != 99 || pending[1].OrderId != 100 {
+		t.Fatalf("ListDeliveries returned %+v, want orders 99 and 100", pending)
+	}
+
+	err = store.DeleteDelivery(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery, err = store.GetDelivery(ctx, 100)
+	if err != nil || delivery != nil {
+		t.Fatalf("GetDelivery after DeleteDelivery returned %+v, %v, want nil", delivery, err)
+	}
+}
+
+// TestCourierRedisStoreLock checks the lock is shared by stores of separate
+// instances on the same server.
+func TestCourierRedisStoreLock(t *testing.T) {
+	server := miniredis.RunT(t)
+	first, second := newCourierRedisStore(t, server), newCourierRedisStore(t, server)
+	ctx := context.Background()
+
+	unlock, err := first.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = second.Lock(waitCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Lock of a held lock returned %v, want to wait until the deadline", err)
+	}
+
+	locked := make(chan func())
+	go func() {
+		unlock, err := second.Lock(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- unlock
+	}()
+	unlock()
+
+	select {
+	case unlock = <-locked:
+		unlock()
+	case <-time.After(time.Second):
+		t.Fatal("Lock didn't return after the lock was released")
+	}
+}
+
+func TestCourierRedisStoreLockLease(t *testing.T) {
+	server := miniredis.RunT(t)
+	first, second := newCourierRedisStore(t, server), newCourierRedisStore(t, server)
+	ctx := context.Background()
+
+	staleUnlock, err := first.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The holder died; its lease runs out.
+	server.FastForward(time.Minute)
+	unlock, err := second.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The late release of the first holder leaves the new lease alone.
+	staleUnlock()
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = first.Lock(waitCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Lock after a stale release returned %v, want the lock to be held", err)
+	}
+	unlock()
+}
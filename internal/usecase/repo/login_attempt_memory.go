@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const _loginAttemptPruneInterval = time.Minute
+
+type loginAttempts struct {
+	failures    int
+	expires     time.Time
+	lockedUntil time.Time
+}
+
+// LoginAttemptMemoryStore counts failed logins of this gateway instance.
+type LoginAttemptMemoryStore struct {
+	mu        sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastPrune time.Time
+}
+
+func NewLoginAttemptMemoryStore() *LoginAttemptMemoryStore {
+	return &LoginAttemptMemoryStore{
+		attempts:  make(map[string]*loginAttempts),
+		lastPrune: time.Now(),
+	}
+}
+
+func (store *LoginAttemptMemoryStore) AddFailure(ctx context.Context, email string, window time.Duration) (int, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.prune()
+	now := time.Now()
+	attempts := store.get(email)
+	if !now.Before(attempts.expires) {
+		attempts.failures = 0
+		attempts.expires = now.Add(window)
+	}
+	attempts.failures++
+	return attempts.failures, nil
+}
+
+func (store *LoginAttemptMemoryStore) Lock(ctx context.Context, email string, until time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.get(email).lockedUntil = until
+	return nil
+}
+
+func (store *LoginAttemptMemoryStore) LockedUntil(ctx context.Context, email string) (time.Time, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	attempts, ok := store.attempts[email]
+	if !ok || !time.Now().Before(attempts.lockedUntil) {
+		return time.Time{}, nil
+	}
+	return attempts.lockedUntil, nil
+}
+
+func (store *LoginAttemptMemoryStore) Reset(ctx context.Context, email string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.attempts, email)
+	return nil
+}
+
+func (store *LoginAttemptMemoryStore) get(email string) *loginAttempts {
+	attempts, ok := store.attempts[email]
+	if !ok {
+		attempts = &loginAttempts{}
+		store.attempts[email] = attempts
+	}
+	return attempts
+}
+
+func (store *LoginAttemptMemoryStore) prune() {
+	now := time.Now()
+	if now.Sub(store.lastPrune) < _loginAttemptPruneInterval {
+		return
+	}
+	store.lastPrune = now
+
+	for email, attempts := range store.attempts {
+		if !now.Before(attempts.expires) && !now.Before(attempts.lockedUntil) {
+			delete(store.attempts, email)
+		}
+	}
+}
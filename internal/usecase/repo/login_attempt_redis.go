@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginAttemptRedisStore shares failed login counters and lockouts between
+// gateway instances. Both expire on their own.
+type LoginAttemptRedisStore struct {
+	client redis.Cmdable
+}
+
+func NewLoginAttemptRedisStore(client redis.Cmdable) *LoginAttemptRedisStore {
+	return &LoginAttemptRedisStore{
+		client: client,
+	}
+}
+
+func loginFailuresKey(email string) string {
+	return "login:failures:" + email
+}
+
+func loginLockedKey(email string) string {
+	return "login:locked:" + email
+}
+
+func (store *LoginAttemptRedisStore) AddFailure(ctx context.Context, email string, window time.Duration) (int, error) {
+	key := loginFailuresKey(email)
+	failures, err := store.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if failures == 1 {
+		err = store.client.Expire(ctx, key, window).Err()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return int(failures), nil
+}
+
+func (store *LoginAttemptRedisStore) Lock(ctx context.Context, email string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return store.client.Set(ctx, loginLockedKey(email), until.UnixMilli(), ttl).Err()
+}
+
+func (store *LoginAttemptRedisStore) LockedUntil(ctx context.Context, email string) (time.Time, error) {
+	value, err := store.client.Get(ctx, loginLockedKey(email)).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(until), nil
+}
+
+func (store *LoginAttemptRedisStore) Reset(ctx context.Context, email string) error {
+	return store.client.Del(ctx, loginFailuresKey(email), loginLockedKey(email)).Err()
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// MFAMemoryStore keeps the second factors of users of this gateway instance.
+type MFAMemoryStore struct {
+	mu      sync.Mutex
+	factors map[int64]entity.MFA
+}
+
+func NewMFAMemoryStore() *MFAMemoryStore {
+	return &MFAMemoryStore{
+		factors: make(map[int64]entity.MFA),
+	}
+}
+
+func (store *MFAMemoryStore) GetMFA(ctx context.Context, userId int64) (*entity.MFA, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	mfa, ok := store.factors[userId]
+	if !ok {
+		return nil, nil
+	}
+	mfa.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	return &mfa, nil
+}
+
+func (store *MFAMemoryStore) SaveMFA(ctx context.Context, mfa *entity.MFA) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	saved := *mfa
+	saved.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	store.factors[mfa.UserId] = saved
+	return nil
+}
+
+func (store *MFAMemoryStore) UpdateMFA(ctx context.Context, userId int64, update func(mfa *entity.MFA) (bool, error)) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	mfa, ok := store.factors[userId]
+	if !ok {
+		return false, nil
+	}
+	mfa.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	changed, err := update(&mfa)
+	if err != nil || !changed {
+		return false, err
+	}
+	store.factors[userId] = mfa
+	return true, nil
+}
+
+func (store *MFAMemoryStore) DeleteMFA(ctx context.Context, userId int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.factors, userId)
+	return nil
+}
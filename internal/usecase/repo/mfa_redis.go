@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+const (
+	_mfaKey = "mfa"
+	// _mfaUpdateAttempts is how often UpdateMFA reads a second factor again
+	// after it changed under it.
+	_mfaUpdateAttempts = 5
+)
+
+// _mfaSwapScript sets the field ARGV[1] of the hash to ARGV[3] if it still
+// holds ARGV[2].
+var _mfaSwapScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+// MFARedisStore keeps second factors in a hash keyed by user. They don't
+// expire.
+type MFARedisStore struct {
+	client redis.Cmdable
+}
+
+func NewMFARedisStore(client redis.Cmdable) *MFARedisStore {
+	return &MFARedisStore{client: client}
+}
+
+func (store *MFARedisStore) GetMFA(ctx context.Context, userId int64) (*entity.MFA, error) {
+	mfa, _, err := store.getMFA(ctx, userId)
+	return mfa, err
+}
+
+// getMFA returns the second factor together with how it is stored.
+func (store *MFARedisStore) getMFA(ctx context.Context, userId int64) (*entity.MFA, []byte, error) {
+	data, err := store.client.HGet(ctx, _mfaKey, strconv.FormatInt(userId, 10)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mfa entity.MFA
+	err = json.Unmarshal(data, &mfa)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &mfa, data, nil
+}
+
+func (store *MFARedisStore) SaveMFA(ctx context.Context, mfa *entity.MFA) error {
+	data, err := json.Marshal(mfa)
+	if err != nil {
+		return err
+	}
+	return store.client.HSet(ctx, _mfaKey, strconv.FormatInt(mfa.UserId, 10), data).Err()
+}
+
+// UpdateMFA saves the updated second factor only if it is still stored the
+// way it was read.
+func (store *MFARedisStore) UpdateMFA(ctx context.Context, userId int64, update func(mfa *entity.MFA) (bool, error)) (bool, error) {
+	field := strconv.FormatInt(userId, 10)
+	for attempt := 0; attempt < _mfaUpdateAttempts; attempt++ {
+		mfa, read, err := store.getMFA(ctx, userId)
+		if err != nil || mfa == nil {
+			return false, err
+		}
+		changed, err := update(mfa)
+		if err != nil || !changed {
+			return false, err
+		}
+
+		data, err := json.Marshal(mfa)
+		if err != nil {
+			return false, err
+		}
+		swapped, err := _mfaSwapScript.Run(ctx, store.client, []string{_mfaKey}, field, read, data).Int()
+		if err != nil {
+			return false, err
+		}
+		if swapped == 1 {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("mfa of user %d kept changing during %d updates", userId, _mfaUpdateAttempts)
+}
+
+func (store *MFARedisStore) DeleteMFA(ctx context.Context, userId int64) error {
+	return store.client.HDel(ctx, _mfaKey, strconv.FormatInt(userId, 10)).Err()
+}
@@ -0,0 +1,99 @@
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+func newMFARedisStore(t *testing.T) *repo.MFARedisStore {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return repo.NewMFARedisStore(client)
+}
+
+func TestMFARedisStoreUpdateMFA(t *testing.T) {
+	store := newMFARedisStore(t)
+	ctx := context.Background()
+
+	updated, err := store.UpdateMFA(ctx, 1, func(mfa *entity.MFA) (bool, error) {
+		t.Error("update ran for a user without a second factor")
+		return true, nil
+	})
+	if err != nil || updated {
+		t.Fatalf("UpdateMFA of a user without a second factor = %v, %v, want false, nil", updated, err)
+	}
+
+	err = store.SaveMFA(ctx, &entity.MFA{UserId: 1, Secret: "secret", Enabled: true, RecoveryCodes: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every update spends one recovery code; run concurrently, each code
+	// goes to exactly one of them.
+	var (
+		mu    sync.Mutex
+		spent []string
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var code string
+			updated, err := store.UpdateMFA(ctx, 1, func(mfa *entity.MFA) (bool, error) {
+				if len(mfa.RecoveryCodes) == 0 {
+					return false, nil
+				}
+				code = mfa.RecoveryCodes[0]
+				mfa.RecoveryCodes = mfa.RecoveryCodes[1:]
+				return true, nil
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if updated {
+				mu.Lock()
+				spent = append(spent, code)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(spent) != 2 || spent[0] == spent[1] {
+		t.Errorf("concurrent updates spent %v, want a and b once each", spent)
+	}
+	mfa, err := store.GetMFA(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mfa.RecoveryCodes) != 0 || mfa.Secret != "secret" {
+		t.Errorf("GetMFA after the updates = %+v, want no recovery codes left", mfa)
+	}
+
+	failure := errors.New("update failed")
+	_, err = store.UpdateMFA(ctx, 1, func(mfa *entity.MFA) (bool, error) {
+		mfa.Enabled = false
+		return true, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("UpdateMFA returned %v, want the error of update", err)
+	}
+	mfa, err = store.GetMFA(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mfa.Enabled {
+		t.Error("a failed update was saved")
+	}
+}
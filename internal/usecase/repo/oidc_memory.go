@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+const _oidcStatePruneInterval = time.Minute
+
+type oidcPendingState struct {
+	state     entity.OIDCState
+	expiresAt time.Time
+}
+
+// OIDCMemoryStore keeps pending logins and linked identities of this gateway
+// instance. Pending logins are forgotten once they expire.
+type OIDCMemoryStore struct {
+	mu        sync.Mutex
+	states    map[string]oidcPendingState
+	links     map[string]entity.OIDCLink
+	lastPrune time.Time
+}
+
+func NewOIDCMemoryStore() *OIDCMemoryStore {
+	return &OIDCMemoryStore{
+		states:    make(map[string]oidcPendingState),
+		links:     make(map[string]entity.OIDCLink),
+		lastPrune: time.Now(),
+	}
+}
+
+func (store *OIDCMemoryStore) SaveState(ctx context.Context, state string, oidcState *entity.OIDCState, ttl time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.prune()
+	store.states[state] = oidcPendingState{
+		state:     *oidcState,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (store *OIDCMemoryStore) TakeState(ctx context.Context, state string) (*entity.OIDCState, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	pending, ok := store.states[state]
+	delete(store.states, state)
+	if !ok || !time.Now().Before(pending.expiresAt) {
+		return nil, nil
+	}
+	return &pending.state, nil
+}
+
+func (store *OIDCMemoryStore) GetLink(ctx context.Context, provider string, subject string) (*entity.OIDCLink, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	link, ok := store.links[oidcLinkField(provider, subject)]
+	if !ok {
+		return nil, nil
+	}
+	return &link, nil
+}
+
+func (store *OIDCMemoryStore) SaveLink(ctx context.Context, link *entity.OIDCLink) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.links[oidcLinkField(link.Provider, link.Subject)] = *link
+	return nil
+}
+
+func (store *OIDCMemoryStore) prune() {
+	now := time.Now()
+	if now.Sub(store.lastPrune) < _oidcStatePruneInterval {
+		return
+	}
+	store.lastPrune = now
+
+	for state, pending := range store.states {
+		if !now.Before(pending.expiresAt) {
+			delete(store.states, state)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+const (
+	_oidcStateKeyPrefix = "oidc:state:"
+	_oidcLinksKey       = "oidc:links"
+)
+
+// OIDCRedisStore keeps every pending login under its own key expiring with
+// it and the linked identities in a hash, which doesn't expire.
+type OIDCRedisStore struct {
+	client redis.Cmdable
+}
+
+func NewOIDCRedisStore(client redis.Cmdable) *OIDCRedisStore {
+	return &OIDCRedisStore{client: client}
+}
+
+func (store *OIDCRedisStore) SaveState(ctx context.Context, state string, oidcState *entity.OIDCState, ttl time.Duration) error {
+	data, err := json.Marshal(oidcState)
+	if err != nil {
+		return err
+	}
+	return store.client.Set(ctx, _oidcStateKeyPrefix+state, data, ttl).Err()
+}
+
+func (store *OIDCRedisStore) TakeState(ctx context.Context, state string) (*entity.OIDCState, error) {
+	data, err := store.client.GetDel(ctx, _oidcStateKeyPrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var oidcState entity.OIDCState
+	err = json.Unmarshal(data, &oidcState)
+	if err != nil {
+		return nil, err
+	}
+	return &oidcState, nil
+}
+
+func (store *OIDCRedisStore) GetLink(ctx context.Context, provider string, subject string) (*entity.OIDCLink, error) {
+	data, err := store.client.HGet(ctx, _oidcLinksKey, oidcLinkField(provider, subject)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var link entity.OIDCLink
+	err = json.Unmarshal(data, &link)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (store *OIDCRedisStore) SaveLink(ctx context.Context, link *entity.OIDCLink) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	return store.client.HSet(ctx, _oidcLinksKey, oidcLinkField(link.Provider, link.Subject), data).Err()
+}
+
+func oidcLinkField(provider string, subject string) string {
+	return provider + ":" + subject
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// ReindexCheckpointFileStore keeps the reindex checkpoint in a JSON file, so
+// a reindex run from the command line can be resumed by running it again.
+type ReindexCheckpointFileStore struct {
+	path string
+}
+
+func NewReindexCheckpointFileStore(path string) *ReindexCheckpointFileStore {
+	return &ReindexCheckpointFileStore{path: path}
+}
+
+func (store *ReindexCheckpointFileStore) GetCheckpoint(ctx context.Context) (*entity.ReindexCheckpoint, error) {
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint entity.ReindexCheckpoint
+	err = json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// SaveCheckpoint writes a temporary file and renames it over the checkpoint,
+// so a run killed mid-write leaves the previous checkpoint intact.
+func (store *ReindexCheckpointFileStore) SaveCheckpoint(ctx context.Context, checkpoint *entity.ReindexCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(store.path), filepath.Base(store.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), store.path)
+}
+
+func (store *ReindexCheckpointFileStore) DeleteCheckpoint(ctx context.Context) error {
+	err := os.Remove(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const _revocationPruneInterval = time.Minute
+
+// RevocationMemoryStore keeps revoked IDs of this gateway instance and forgets
+// them once they expire.
+type RevocationMemoryStore struct {
+	mu        sync.Mutex
+	revoked   map[uuid.UUID]time.Time
+	lastPrune time.Time
+}
+
+func NewRevocationMemoryStore() *RevocationMemoryStore {
+	return &RevocationMemoryStore{
+		revoked:   make(map[uuid.UUID]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+func (store *RevocationMemoryStore) Revoke(ctx context.Context, id uuid.UUID, until time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.prune()
+	if current, ok := store.revoked[id]; !ok || until.After(current) {
+		store.revoked[id] = until
+	}
+	return nil
+}
+
+func (store *RevocationMemoryStore) RevokeOnce(ctx context.Context, id uuid.UUID, until time.Time) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.prune()
+	if store.isRevoked(id) {
+		return false, nil
+	}
+	store.revoked[id] = until
+	return true, nil
+}
+
+func (store *RevocationMemoryStore) Unrevoke(ctx context.Context, id uuid.UUID) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.revoked, id)
+	return nil
+}
+
+func (store *RevocationMemoryStore) IsRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, id := range ids {
+		if store.isRevoked(id) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (store *RevocationMemoryStore) isRevoked(id uuid.UUID) bool {
+	until, ok := store.revoked[id]
+	return ok && time.Now().Before(until)
+}
+
+func (store *RevocationMemoryStore) prune() {
+	now := time.Now()
+	if now.Sub(store.lastPrune) < _revocationPruneInterval {
+		return
+	}
+	store.lastPrune = now
+
+	for id, until := range store.revoked {
+		if !now.Before(until) {
+			delete(store.revoked, id)
+		}
+	}
+}
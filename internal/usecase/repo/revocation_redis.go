@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationRedisStore shares revoked IDs between gateway instances. Keys
+// expire together with the tokens they revoke.
+type RevocationRedisStore struct {
+	client redis.Cmdable
+}
+
+func NewRevocationRedisStore(client redis.Cmdable) *RevocationRedisStore {
+	return &RevocationRedisStore{
+		client: client,
+	}
+}
+
+func revokedKey(id uuid.UUID) string {
+	return "revoked:" + id.String()
+}
+
+func (store *RevocationRedisStore) Revoke(ctx context.Context, id uuid.UUID, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return store.client.Set(ctx, revokedKey(id), 1, ttl).Err()
+}
+
+func (store *RevocationRedisStore) RevokeOnce(ctx context.Context, id uuid.UUID, until time.Time) (bool, error) {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return false, nil
+	}
+	return store.client.SetNX(ctx, revokedKey(id), 1, ttl).Result()
+}
+
+func (store *RevocationRedisStore) Unrevoke(ctx context.Context, id uuid.UUID) error {
+	return store.client.Del(ctx, revokedKey(id)).Err()
+}
+
+func (store *RevocationRedisStore) IsRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error) {
+	if len(ids) == 0 {
+		return false, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = revokedKey(id)
+	}
+	n, err := store.client.Exists(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,70 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+func TestRevocationRedisStoreRevokeOnce(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := repo.NewRevocationRedisStore(client)
+	ctx := context.Background()
+
+	id, other := uuid.New(), uuid.New()
+	revokeOnce := func(id uuid.UUID, until time.Time, want bool) {
+		t.Helper()
+
+		first, err := store.RevokeOnce(ctx, id, until)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != want {
+			t.Errorf("RevokeOnce = %v, want %v", first, want)
+		}
+	}
+	isRevoked := func(want bool, ids ...uuid.UUID) {
+		t.Helper()
+
+		revoked, err := store.IsRevoked(ctx, ids...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revoked != want {
+			t.Errorf("IsRevoked(%v) = %v, want %v", ids, revoked, want)
+		}
+	}
+
+	revokeOnce(id, time.Now().Add(time.Minute), true)
+	revokeOnce(id, time.Now().Add(time.Hour), false)
+	isRevoked(true, id)
+	isRevoked(true, other, id)
+	isRevoked(false, other)
+	isRevoked(false)
+
+	if ttl := server.TTL("revoked:" + id.String()); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("revoked id expires in %v, want the minute of the first RevokeOnce", ttl)
+	}
+
+	// Already expired tokens need no revocation.
+	revokeOnce(other, time.Now().Add(-time.Second), false)
+	isRevoked(false, other)
+
+	server.FastForward(time.Minute)
+	isRevoked(false, id)
+	revokeOnce(id, time.Now().Add(time.Minute), true)
+
+	err := store.Unrevoke(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isRevoked(false, id)
+	revokeOnce(id, time.Now().Add(time.Minute), true)
+}
@@ -0,0 +1,671 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/elastic/go-elasticsearch"
+	"github.com/elastic/go-elasticsearch/esapi"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// _searchMapping indexes names and descriptions as text and everything the
+// filters and facets use as exact values.
+const _searchMapping = `{
+	"mappings": {
+		"properties": {
+			"kind":        {"type": "keyword"},
+			"id":          {"type": "long"},
+			"shop_id":     {"type": "long"},
+			"name":        {"type": "text"},
+			"description": {"type": "text"},
+			"shop_name":   {"type": "text", "fields": {"raw": {"type": "keyword"}}},
+			"photo":       {"type": "keyword", "index": false},
+			"price":       {"type": "integer"},
+			"hours": {
+				"properties": {
+					"closed":      {"type": "boolean"},
+					"always_open": {"type": "boolean"},
+					"overnight":   {"type": "boolean"},
+					"opens":       {"type": "integer"},
+					"closes":      {"type": "integer"}
+				}
+			},
+			"location":      {"type": "geo_point"},
+			"delivery_zone": {"type": "object", "enabled": false},
+			"created_at":    {"type": "date"}
+		}
+	}
+}`
+
+// SearchElasticIndex keeps shops and menu items in one Elasticsearch index,
+// told apart by kind. The index is a version served under the alias named
+// index, so it can be rebuilt beside the live one and swapped in. The version
+// being rebuilt carries the alias index-building, and changes are written to
+// it as well.
+type SearchElasticIndex struct {
+	client *elasticsearch.Client
+	index  string
+}
+
+func NewSearchElasticIndex(client *elasticsearch.Client, index string) *SearchElasticIndex {
+	return &SearchElasticIndex{
+		client: client,
+		index:  index,
+	}
+}
+
+// EnsureIndex creates a first version under the alias unless the alias, or
+// an index of its name, exists, in which case fields added to the mapping
+// since it was created are put into it.
+func (index *SearchElasticIndex) EnsureIndex(ctx context.Context) error {
+	resp, err := index.client.Indices.Exists([]string{index.index}, index.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return index.createIndex(ctx, entity.SearchIndexVersion(index.index, time.Now()), index.index)
+	}
+
+	var body struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	err = json.Unmarshal([]byte(_searchMapping), &body)
+	if err != nil {
+		return err
+	}
+	resp, err = index.client.Indices.PutMapping(bytes.NewReader(body.Mappings),
+		index.client.Indices.PutMapping.WithContext(ctx),
+		index.client.Indices.PutMapping.WithIndex(index.index),
+	)
+	if err != nil {
+		return err
+	}
+	return responseError(resp, "put mapping")
+}
+
+// CreateVersion takes the building alias off a version left behind by an
+// abandoned reindex and gives it to the new version.
+func (index *SearchElasticIndex) CreateVersion(ctx context.Context, version string) error {
+	resp, err := index.client.Indices.DeleteAlias([]string{"_all"}, []string{index.buildingAlias()},
+		index.client.Indices.DeleteAlias.WithContext(ctx),
+	)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+	} else if err = responseError(resp, "delete alias"); err != nil {
+		return err
+	}
+
+	return index.createIndex(ctx, version, index.buildingAlias())
+}
+
+func (index *SearchElasticIndex) buildingAlias() string {
+	return index.index + "-building"
+}
+
+func (index *SearchElasticIndex) createIndex(ctx context.Context, name string, alias string) error {
+	var body map[string]any
+	err := json.Unmarshal([]byte(_searchMapping), &body)
+	if err != nil {
+		return err
+	}
+	body["aliases"] = map[string]any{alias: map[string]any{}}
+	mapping, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := index.client.Indices.Create(name,
+		index.client.Indices.Create.WithContext(ctx),
+		index.client.Indices.Create.WithBody(bytes.NewReader(mapping)),
+	)
+	if err != nil {
+		return err
+	}
+	return responseError(resp, "create index")
+}
+
+// IndexVersion creates the documents, so the ones already written by changes
+// are left alone.
+func (index *SearchElasticIndex) IndexVersion(ctx context.Context, version string, docs []*entity.SearchDocument) error {
+	return index.bulk(ctx, version, "create", docs)
+}
+
+// PublishVersion swaps the alias over and takes the building alias off
+// version in a single aliases request. An index that has the alias's own
+// name, as left by a gateway that predates versions, is deleted in the same
+// request.
+func (index *SearchElasticIndex) PublishVersion(ctx context.Context, version string) ([]string, error) {
+	previous, concrete, err := index.aliasIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []any{
+		map[string]any{"add": map[string]string{"index": version, "alias": index.index}},
+		map[string]any{"remove": map[string]string{"index": version, "alias": index.buildingAlias()}},
+	}
+	var replaced []string
+	for _, name := range previous {
+		if name == version {
+			continue
+		}
+		actions = append(actions, map[string]any{"remove": map[string]string{"index": name, "alias": index.index}})
+		replaced = append(replaced, name)
+	}
+	if concrete {
+		actions = append(actions, map[string]any{"remove_index": map[string]string{"index": index.index}})
+	}
+
+	body, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := index.client.Indices.UpdateAliases(bytes.NewReader(body), index.client.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	err = responseError(resp, "update aliases")
+	if err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+// aliasIndices returns the indices behind the alias, or whether the name is
+// an index of its own instead.
+func (index *SearchElasticIndex) aliasIndices(ctx context.Context) ([]string, bool, error) {
+	resp, err := index.client.Indices.GetAlias(
+		index.client.Indices.GetAlias.WithContext(ctx),
+		index.client.Indices.GetAlias.WithName(index.index),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		exists, err := index.client.Indices.Exists([]string{index.index}, index.client.Indices.Exists.WithContext(ctx))
+		if err != nil {
+			return nil, false, err
+		}
+		exists.Body.Close()
+		return nil, exists.StatusCode == http.StatusOK, nil
+	}
+	if resp.IsError() {
+		return nil, false, responseError(resp, "get alias")
+	}
+
+	var aliases map[string]json.RawMessage
+	err = json.NewDecoder(resp.Body).Decode(&aliases)
+	if err != nil {
+		return nil, false, err
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, false, nil
+}
+
+func (index *SearchElasticIndex) DeleteVersion(ctx context.Context, version string) error {
+	resp, err := index.client.Indices.Delete([]string{version}, index.client.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil
+	}
+	return responseError(resp, "delete index")
+}
+
+// targets returns the names changes go to: the alias and, while a version
+// is being built, the building alias.
+func (index *SearchElasticIndex) targets(ctx context.Context) ([]string, error) {
+	resp, err := index.client.Indices.ExistsAlias([]string{index.buildingAlias()}, index.client.Indices.ExistsAlias.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return []string{index.index, index.buildingAlias()}, nil
+	}
+	return []string{index.index}, nil
+}
+
+func (index *SearchElasticIndex) IndexDocuments(ctx context.Context, docs []*entity.SearchDocument) error {
+	targets, err := index.targets(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range targets {
+		err = index.bulk(ctx, name, "index", docs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bulk runs op, index or create, for docs. Creating a document that exists
+// isn't an error.
+func (index *SearchElasticIndex) bulk(ctx context.Context, name string, op string, docs []*entity.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]any{op: map[string]string{"_index": name, "_id": doc.Key()}}
+		err := encoder.Encode(action)
+		if err != nil {
+			return err
+		}
+		err = encoder.Encode(newElasticDocument(doc))
+		if err != nil {
+			return err
+		}
+	}
+
+	resp, err := index.client.Bulk(&body, index.client.Bulk.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return responseError(resp, "bulk")
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return err
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, op := range item {
+				if len(op.Error) > 0 && op.Status != http.StatusConflict {
+					return fmt.Errorf("elasticsearch - bulk - %s: %s", op.ID, op.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (index *SearchElasticIndex) DeleteDocument(ctx context.Context, kind string, id int64) error {
+	targets, err := index.targets(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range targets {
+		resp, err := index.client.Delete(name, entity.SearchDocumentKey(kind, id), index.client.Delete.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			continue
+		}
+		err = responseError(resp, "delete")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (index *SearchElasticIndex) DeleteShop(ctx context.Context, shopId int64) error {
+	body, err := json.Marshal(map[string]any{
+		"query": map[string]any{"term": map[string]any{"shop_id": shopId}},
+	})
+	if err != nil {
+		return err
+	}
+
+	targets, err := index.targets(ctx)
+	if err != nil {
+		return err
+	}
+	resp, err := index.client.DeleteByQuery(targets, bytes.NewReader(body),
+		index.client.DeleteByQuery.WithContext(ctx),
+		index.client.DeleteByQuery.WithConflicts("proceed"),
+	)
+	if err != nil {
+		return err
+	}
+	return responseError(resp, "delete by query")
+}
+
+func (index *SearchElasticIndex) Search(ctx context.Context, query *entity.SearchQuery) (*entity.SearchResult, error) {
+	body, err := json.Marshal(searchRequest(query))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := index.client.Search(
+		index.client.Search.WithContext(ctx),
+		index.client.Search.WithIndex(index.index),
+		index.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return nil, responseError(resp, "search")
+	}
+
+	var found searchResponse
+	err = json.NewDecoder(resp.Body).Decode(&found)
+	if err != nil {
+		return nil, err
+	}
+	return found.result(), nil
+}
+
+func (index *SearchElasticIndex) NearbyShops(ctx context.Context, at entity.Location, radiusKm float64, limit int) ([]*entity.SearchDocument, error) {
+	point := elasticGeoPoint{Lat: at.Lat, Lon: at.Lng}
+	filters := []any{
+		map[string]any{"term": map[string]any{"kind": entity.SearchKindShop}},
+		map[string]any{"exists": map[string]any{"field": "location"}},
+	}
+	if radiusKm > 0 {
+		filters = append(filters, map[string]any{
+			"geo_distance": map[string]any{"distance": fmt.Sprintf("%gkm", radiusKm), "location": point},
+		})
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"size":  limit,
+		"query": map[string]any{"bool": map[string]any{"filter": filters}},
+		"sort": []any{
+			map[string]any{"_geo_distance": map[string]any{"location": point, "order": "asc", "unit": "km"}},
+			map[string]any{"id": "asc"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := index.client.Search(
+		index.client.Search.WithContext(ctx),
+		index.client.Search.WithIndex(index.index),
+		index.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return nil, responseError(resp, "nearby shops")
+	}
+
+	var found searchResponse
+	err = json.NewDecoder(resp.Body).Decode(&found)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*entity.SearchDocument, 0, len(found.Hits.Hits))
+	for _, hit := range found.Hits.Hits {
+		docs = append(docs, hit.Source.document())
+	}
+	return docs, nil
+}
+
+// elasticDocument stores the location the way geo_point takes it, which
+// names longitude lon.
+type elasticDocument struct {
+	entity.SearchDocument
+	Location *elasticGeoPoint `json:"location,omitempty"`
+}
+
+type elasticGeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func newElasticDocument(doc *entity.SearchDocument) *elasticDocument {
+	stored := &elasticDocument{SearchDocument: *doc}
+	if doc.Location != nil {
+		stored.Location = &elasticGeoPoint{Lat: doc.Location.Lat, Lon: doc.Location.Lng}
+	}
+	return stored
+}
+
+func (stored *elasticDocument) document() *entity.SearchDocument {
+	doc := stored.SearchDocument
+	doc.Location = nil
+	if stored.Location != nil {
+		doc.Location = &entity.Location{Lat: stored.Location.Lat, Lng: stored.Location.Lon}
+	}
+	return &doc
+}
+
+// searchRequest builds the query DSL: a fuzzy multi_match ranked by field,
+// the filters, highlighting and the facet aggregations.
+func searchRequest(query *entity.SearchQuery) map[string]any {
+	var must any = map[string]any{"match_all": map[string]any{}}
+	if query.Text != "" {
+		must = map[string]any{
+			"multi_match": map[string]any{
+				"query":     query.Text,
+				"fields":    []string{"name^3", "shop_name^2", "description"},
+				"fuzziness": "AUTO",
+			},
+		}
+	}
+
+	filters := []any{}
+	if query.Kind != "" {
+		filters = append(filters, map[string]any{"term": map[string]any{"kind": query.Kind}})
+	}
+	if query.ShopId != 0 {
+		filters = append(filters, map[string]any{"term": map[string]any{"shop_id": query.ShopId}})
+	}
+	if query.MinPrice != nil || query.MaxPrice != nil {
+		price := map[string]any{}
+		if query.MinPrice != nil {
+			price["gte"] = *query.MinPrice
+		}
+		if query.MaxPrice != nil {
+			price["lte"] = *query.MaxPrice
+		}
+		filters = append(filters, map[string]any{"range": map[string]any{"price": price}})
+	}
+	if query.OpenNow {
+		filters = append(filters, openAt(query))
+	}
+
+	priceRanges := make([]any, 0, len(entity.SearchPriceRanges))
+	for _, r := range entity.SearchPriceRanges {
+		bucket := map[string]any{"key": r.Key(), "from": r.From}
+		if r.To != 0 {
+			bucket["to"] = r.To
+		}
+		priceRanges = append(priceRanges, bucket)
+	}
+
+	return map[string]any{
+		"from":             query.Offset,
+		"size":             query.Limit,
+		"track_total_hits": true,
+		"query": map[string]any{
+			"bool": map[string]any{"must": must, "filter": filters},
+		},
+		"highlight": map[string]any{
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]any{
+				"name":        map[string]any{},
+				"shop_name":   map[string]any{},
+				"description": map[string]any{},
+			},
+		},
+		"aggs": map[string]any{
+			"kinds": map[string]any{"terms": map[string]any{"field": "kind"}},
+			"shops": map[string]any{
+				"terms": map[string]any{"field": "shop_id", "size": entity.SearchShopFacetSize},
+				"aggs": map[string]any{
+					"name": map[string]any{"terms": map[string]any{"field": "shop_name.raw", "size": 1}},
+				},
+			},
+			"prices": map[string]any{"range": map[string]any{"field": "price", "ranges": priceRanges}},
+		},
+	}
+}
+
+// openAt matches documents whose shop is open at query.At, following
+// entity.OpenHours.IsOpenAt.
+func openAt(query *entity.SearchQuery) map[string]any {
+	at := query.At.UTC()
+	now := at.Hour()*60 + at.Minute()
+	return map[string]any{
+		"bool": map[string]any{
+			"must_not": []any{map[string]any{"term": map[string]any{"hours.closed": true}}},
+			"should": []any{
+				map[string]any{"term": map[string]any{"hours.always_open": true}},
+				map[string]any{"bool": map[string]any{"filter": []any{
+					map[string]any{"term": map[string]any{"hours.overnight": false}},
+					map[string]any{"range": map[string]any{"hours.opens": map[string]any{"lte": now}}},
+					map[string]any{"range": map[string]any{"hours.closes": map[string]any{"gt": now}}},
+				}}},
+				map[string]any{"bool": map[string]any{
+					"filter": []any{map[string]any{"term": map[string]any{"hours.overnight": true}}},
+					"should": []any{
+						map[string]any{"range": map[string]any{"hours.opens": map[string]any{"lte": now}}},
+						map[string]any{"range": map[string]any{"hours.closes": map[string]any{"gt": now}}},
+					},
+					"minimum_should_match": 1,
+				}},
+			},
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Score      float64             `json:"_score"`
+			Source     elasticDocument     `json:"_source"`
+			Highlights map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Kinds  termsAggregation `json:"kinds"`
+		Shops  termsAggregation `json:"shops"`
+		Prices struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount int    `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"prices"`
+	} `json:"aggregations"`
+}
+
+type termsAggregation struct {
+	Buckets []struct {
+		Key      bucketKey `json:"key"`
+		DocCount int       `json:"doc_count"`
+		Name     struct {
+			Buckets []struct {
+				Key string `json:"key"`
+			} `json:"buckets"`
+		} `json:"name"`
+	} `json:"buckets"`
+}
+
+// bucketKey is the key of a terms bucket, a string for keyword fields and a
+// number for numeric ones.
+type bucketKey string
+
+func (key *bucketKey) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*key = bucketKey(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*key = bucketKey(number)
+	return nil
+}
+
+func (found *searchResponse) result() *entity.SearchResult {
+	result := &entity.SearchResult{
+		Total: found.Hits.Total.Value,
+		Hits:  make([]entity.SearchHit, 0, len(found.Hits.Hits)),
+		Facets: entity.SearchFacets{
+			Kinds:  make([]entity.FacetBucket, 0),
+			Shops:  make([]entity.FacetBucket, 0),
+			Prices: make([]entity.FacetBucket, 0, len(entity.SearchPriceRanges)),
+		},
+	}
+	for _, hit := range found.Hits.Hits {
+		result.Hits = append(result.Hits, entity.SearchHit{
+			SearchDocument: *hit.Source.document(),
+			Score:          hit.Score,
+			Highlights:     hit.Highlights,
+		})
+	}
+
+	for _, bucket := range found.Aggregations.Kinds.Buckets {
+		result.Facets.Kinds = append(result.Facets.Kinds, entity.FacetBucket{
+			Key:   string(bucket.Key),
+			Count: bucket.DocCount,
+		})
+	}
+	for _, bucket := range found.Aggregations.Shops.Buckets {
+		facet := entity.FacetBucket{Key: string(bucket.Key), Count: bucket.DocCount}
+		if len(bucket.Name.Buckets) > 0 {
+			facet.Label = bucket.Name.Buckets[0].Key
+		}
+		result.Facets.Shops = append(result.Facets.Shops, facet)
+	}
+	for _, bucket := range found.Aggregations.Prices.Buckets {
+		result.Facets.Prices = append(result.Facets.Prices, entity.FacetBucket{
+			Key:   bucket.Key,
+			Count: bucket.DocCount,
+		})
+	}
+	return result
+}
+
+// responseError closes resp and turns an error status into an error with
+// what Elasticsearch said.
+func responseError(resp *esapi.Response, operation string) error {
+	defer resp.Body.Close()
+	if !resp.IsError() {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("elasticsearch - %s: status %s: %s", operation, strconv.Itoa(resp.StatusCode), body)
+}
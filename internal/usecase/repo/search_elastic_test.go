@@ -0,0 +1,199 @@
+package repo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// recordingTransport answers every request with response and keeps the
+// last request and its body.
+type recordingTransport struct {
+	response string
+
+	request *http.Request
+	body    []byte
+}
+
+func (transport *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport.request = req
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		transport.body = body
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(transport.response)),
+		Request:    req,
+	}, nil
+}
+
+func newRecordedIndex(t *testing.T, response string) (*repo.SearchElasticIndex, *recordingTransport) {
+	t.Helper()
+
+	transport := &recordingTransport{response: response}
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo.NewSearchElasticIndex(client, "catalog"), transport
+}
+
+// assertGolden compares body with the JSON in testdata/name, or rewrites the
+// file with -update.
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	var indented bytes.Buffer
+	err := json.Indent(&indented, body, "", "  ")
+	if err != nil {
+		t.Fatalf("request body isn't JSON: %v", err)
+	}
+	indented.WriteString("\n")
+
+	if *update {
+		err := os.WriteFile(path, indented.Bytes(), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(golden, &want); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("request body differs from %s, run the tests with -update if that's intended:\n%s", path, indented.String())
+	}
+}
+
+const emptySearchResponse = `{"hits":{"total":{"value":0},"hits":[]}}`
+
+func TestSearchElasticIndexRequest(t *testing.T) {
+	tests := []struct {
+		golden string
+		query  entity.SearchQuery
+	}{
+		{
+			golden: "search_match_all.json",
+			query:  entity.SearchQuery{Limit: 20},
+		},
+		{
+			golden: "search_text_and_filters.json",
+			query: entity.SearchQuery{
+				Text:     "margherita pizza",
+				Kind:     entity.SearchKindMenuItem,
+				ShopId:   7,
+				MinPrice: price(500),
+				MaxPrice: price(1500),
+				Limit:    10,
+				Offset:   30,
+			},
+		},
+		{
+			golden: "search_min_price.json",
+			query:  entity.SearchQuery{MinPrice: price(1000), Limit: 20},
+		},
+		{
+			// 21:45 in Tbilisi is 17:45 UTC, minute 1065 of the day.
+			golden: "search_open_now.json",
+			query: entity.SearchQuery{
+				OpenNow: true,
+				At:      time.Date(2024, 1, 1, 21, 45, 0, 0, time.FixedZone("UTC+4", 4*60*60)),
+				Limit:   20,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			index, transport := newRecordedIndex(t, emptySearchResponse)
+
+			_, err := index.Search(context.Background(), &tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path := transport.request.URL.Path; path != "/catalog/_search" {
+				t.Errorf("searched %s, want /catalog/_search", path)
+			}
+			assertGolden(t, tt.golden, transport.body)
+		})
+	}
+}
+
+func TestSearchElasticIndexResult(t *testing.T) {
+	index, _ := newRecordedIndex(t, `{
+		"hits": {
+			"total": {"value": 42},
+			"hits": [{
+				"_score": 5.5,
+				"_source": {"kind": "shop", "id": 7, "shop_id": 7, "name": "Pizza Palace", "shop_name": "Pizza Palace", "location": {"lat": 41.7, "lon": 44.8}},
+				"highlight": {"name": ["<em>Pizza</em> Palace"]}
+			}]
+		},
+		"aggregations": {
+			"kinds": {"buckets": [{"key": "menu_item", "doc_count": 40}, {"key": "shop", "doc_count": 2}]},
+			"shops": {"buckets": [{"key": 7, "doc_count": 30, "name": {"buckets": [{"key": "Pizza Palace"}]}}]},
+			"prices": {"buckets": [{"key": "0-500", "doc_count": 12}, {"key": "2000-", "doc_count": 1}]}
+		}
+	}`)
+
+	result, err := index.Search(context.Background(), &entity.SearchQuery{Text: "pizza", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &entity.SearchResult{
+		Total: 42,
+		Hits: []entity.SearchHit{{
+			SearchDocument: entity.SearchDocument{
+				Kind:     entity.SearchKindShop,
+				ID:       7,
+				ShopId:   7,
+				Name:     "Pizza Palace",
+				ShopName: "Pizza Palace",
+				Location: &entity.Location{Lat: 41.7, Lng: 44.8},
+			},
+			Score:      5.5,
+			Highlights: map[string][]string{"name": {"<em>Pizza</em> Palace"}},
+		}},
+		Facets: entity.SearchFacets{
+			Kinds:  []entity.FacetBucket{{Key: "menu_item", Count: 40}, {Key: "shop", Count: 2}},
+			Shops:  []entity.FacetBucket{{Key: "7", Label: "Pizza Palace", Count: 30}},
+			Prices: []entity.FacetBucket{{Key: "0-500", Count: 12}, {Key: "2000-", Count: 1}},
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Search = %+v, want %+v", result, want)
+	}
+}
+
+func price(p int32) *int32 {
+	return &p
+}
@@ -0,0 +1,422 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// searchFields are the fields text is matched against and how much a match
+// in each counts, as in the Elasticsearch index.
+var searchFields = []struct {
+	name  string
+	boost float64
+	value func(doc *entity.SearchDocument) string
+}{
+	{"name", 3, func(doc *entity.SearchDocument) string { return doc.Name }},
+	{"shop_name", 2, func(doc *entity.SearchDocument) string { return doc.ShopName }},
+	{"description", 1, func(doc *entity.SearchDocument) string { return doc.Description }},
+}
+
+// SearchMemoryIndex searches the documents of this gateway instance. It
+// mimics the Elasticsearch index closely enough for tests and local runs:
+// words match within the same edit distance as fuzziness AUTO, scores are
+// simpler. Versions can be built and swapped in like in Elasticsearch.
+type SearchMemoryIndex struct {
+	mu   sync.RWMutex
+	docs map[string]entity.SearchDocument
+	// versions holds the documents of every version by name. docs is the
+	// one named served, or an unnamed one before a version is published.
+	versions map[string]map[string]entity.SearchDocument
+	served   string
+	building string
+}
+
+func NewSearchMemoryIndex() *SearchMemoryIndex {
+	return &SearchMemoryIndex{
+		docs:     make(map[string]entity.SearchDocument),
+		versions: make(map[string]map[string]entity.SearchDocument),
+	}
+}
+
+// targets returns the documents changes go to: the served version and the
+// one being built.
+func (index *SearchMemoryIndex) targets() []map[string]entity.SearchDocument {
+	targets := []map[string]entity.SearchDocument{index.docs}
+	if index.building != "" {
+		targets = append(targets, index.versions[index.building])
+	}
+	return targets
+}
+
+func (index *SearchMemoryIndex) IndexDocuments(ctx context.Context, docs []*entity.SearchDocument) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	for _, target := range index.targets() {
+		for _, doc := range docs {
+			target[doc.Key()] = *doc
+		}
+	}
+	return nil
+}
+
+func (index *SearchMemoryIndex) DeleteDocument(ctx context.Context, kind string, id int64) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	for _, target := range index.targets() {
+		delete(target, entity.SearchDocumentKey(kind, id))
+	}
+	return nil
+}
+
+func (index *SearchMemoryIndex) DeleteShop(ctx context.Context, shopId int64) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	for _, target := range index.targets() {
+		for key, doc := range target {
+			if doc.ShopId == shopId {
+				delete(target, key)
+			}
+		}
+	}
+	return nil
+}
+
+func (index *SearchMemoryIndex) CreateVersion(ctx context.Context, version string) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if _, ok := index.versions[version]; ok {
+		return fmt.Errorf("version %s already exists", version)
+	}
+	index.versions[version] = make(map[string]entity.SearchDocument)
+	index.building = version
+	return nil
+}
+
+func (index *SearchMemoryIndex) IndexVersion(ctx context.Context, version string, docs []*entity.SearchDocument) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	target, ok := index.versions[version]
+	if !ok {
+		return fmt.Errorf("version %s doesn't exist", version)
+	}
+	for _, doc := range docs {
+		if _, ok := target[doc.Key()]; !ok {
+			target[doc.Key()] = *doc
+		}
+	}
+	return nil
+}
+
+func (index *SearchMemoryIndex) PublishVersion(ctx context.Context, version string) ([]string, error) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	docs, ok := index.versions[version]
+	if !ok {
+		return nil, fmt.Errorf("version %s doesn't exist", version)
+	}
+	var replaced []string
+	if index.served != "" && index.served != version {
+		replaced = append(replaced, index.served)
+	}
+	index.docs = docs
+	index.served = version
+	if index.building == version {
+		index.building = ""
+	}
+	return replaced, nil
+}
+
+func (index *SearchMemoryIndex) DeleteVersion(ctx context.Context, version string) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	delete(index.versions, version)
+	if index.building == version {
+		index.building = ""
+	}
+	if index.served == version {
+		index.docs = make(map[string]entity.SearchDocument)
+		index.served = ""
+	}
+	return nil
+}
+
+func (index *SearchMemoryIndex) Search(ctx context.Context, query *entity.SearchQuery) (*entity.SearchResult, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	terms := searchTerms(query.Text)
+	hits := make([]entity.SearchHit, 0)
+	for _, doc := range index.docs {
+		if !matchesFilters(&doc, query) {
+			continue
+		}
+		hit := entity.SearchHit{SearchDocument: doc, Score: 1}
+		if len(terms) > 0 {
+			hit.Score, hit.Highlights = scoreDocument(&doc, terms)
+			if hit.Score == 0 {
+				continue
+			}
+		}
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key() < hits[j].Key()
+	})
+
+	result := &entity.SearchResult{
+		Total:  len(hits),
+		Facets: facets(hits),
+	}
+	from := query.Offset
+	if from > len(hits) {
+		from = len(hits)
+	}
+	to := from + query.Limit
+	if to > len(hits) {
+		to = len(hits)
+	}
+	result.Hits = hits[from:to]
+	return result, nil
+}
+
+func (index *SearchMemoryIndex) NearbyShops(ctx context.Context, at entity.Location, radiusKm float64, limit int) ([]*entity.SearchDocument, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	type nearby struct {
+		doc      entity.SearchDocument
+		distance float64
+	}
+	var found []nearby
+	for _, doc := range index.docs {
+		if doc.Kind != entity.SearchKindShop || doc.Location == nil {
+			continue
+		}
+		distance := doc.Location.DistanceTo(at)
+		if radiusKm > 0 && distance > radiusKm {
+			continue
+		}
+		found = append(found, nearby{doc, distance})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].doc.ID < found[j].doc.ID
+	})
+
+	docs := make([]*entity.SearchDocument, 0, minInt(limit, len(found)))
+	for i := 0; i < len(found) && i < limit; i++ {
+		doc := found[i].doc
+		docs = append(docs, &doc)
+	}
+	return docs, nil
+}
+
+func matchesFilters(doc *entity.SearchDocument, query *entity.SearchQuery) bool {
+	if query.Kind != "" && doc.Kind != query.Kind {
+		return false
+	}
+	if query.ShopId != 0 && doc.ShopId != query.ShopId {
+		return false
+	}
+	if query.MinPrice != nil || query.MaxPrice != nil {
+		if doc.Price == nil {
+			return false
+		}
+		if query.MinPrice != nil && *doc.Price < *query.MinPrice {
+			return false
+		}
+		if query.MaxPrice != nil && *doc.Price > *query.MaxPrice {
+			return false
+		}
+	}
+	if query.OpenNow && !doc.Hours.IsOpenAt(query.At) {
+		return false
+	}
+	return true
+}
+
+// scoreDocument scores the fields in which any of terms matches a word and
+// highlights the matching words.
+func scoreDocument(doc *entity.SearchDocument, terms []string) (float64, map[string][]string) {
+	var score float64
+	highlights := make(map[string][]string)
+	for _, field := range searchFields {
+		text := field.value(doc)
+		var highlighted strings.Builder
+		matched := false
+		last := 0
+		for _, word := range wordSpans(text) {
+			weight := matchWord(strings.ToLower(text[word[0]:word[1]]), terms)
+			if weight == 0 {
+				continue
+			}
+			matched = true
+			score += field.boost * weight
+			highlighted.WriteString(text[last:word[0]])
+			highlighted.WriteString("<em>" + text[word[0]:word[1]] + "</em>")
+			last = word[1]
+		}
+		if matched {
+			highlighted.WriteString(text[last:])
+			highlights[field.name] = []string{highlighted.String()}
+		}
+	}
+	return score, highlights
+}
+
+// matchWord weighs the best match of word among terms: exact matches count
+// fully, fuzzy ones half.
+func matchWord(word string, terms []string) float64 {
+	var best float64
+	for _, term := range terms {
+		distance := editDistance(word, term)
+		switch {
+		case distance == 0:
+			return 1
+		case distance <= fuzziness(term):
+			best = 0.5
+		}
+	}
+	return best
+}
+
+// fuzziness is the number of edits fuzziness AUTO allows for a term.
+func fuzziness(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func searchTerms(text string) []string {
+	var terms []string
+	for _, word := range wordSpans(text) {
+		terms = append(terms, strings.ToLower(text[word[0]:word[1]]))
+	}
+	return terms
+}
+
+// wordSpans returns the byte offsets of the words of text.
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+func facets(hits []entity.SearchHit) entity.SearchFacets {
+	kinds := make(map[string]int)
+	shops := make(map[int64]*entity.FacetBucket)
+	prices := make([]int, len(entity.SearchPriceRanges))
+	for _, hit := range hits {
+		kinds[hit.Kind]++
+
+		bucket, ok := shops[hit.ShopId]
+		if !ok {
+			bucket = &entity.FacetBucket{Key: strconv.FormatInt(hit.ShopId, 10), Label: hit.ShopName}
+			shops[hit.ShopId] = bucket
+		}
+		bucket.Count++
+
+		if hit.Price != nil {
+			for i, r := range entity.SearchPriceRanges {
+				if r.Contains(*hit.Price) {
+					prices[i]++
+				}
+			}
+		}
+	}
+
+	result := entity.SearchFacets{
+		Kinds:  make([]entity.FacetBucket, 0),
+		Shops:  make([]entity.FacetBucket, 0),
+		Prices: make([]entity.FacetBucket, 0, len(entity.SearchPriceRanges)),
+	}
+	for _, kind := range []string{entity.SearchKindShop, entity.SearchKindMenuItem} {
+		if kinds[kind] > 0 {
+			result.Kinds = append(result.Kinds, entity.FacetBucket{Key: kind, Count: kinds[kind]})
+		}
+	}
+	for _, bucket := range shops {
+		result.Shops = append(result.Shops, *bucket)
+	}
+	sort.Slice(result.Shops, func(i, j int) bool {
+		if result.Shops[i].Count != result.Shops[j].Count {
+			return result.Shops[i].Count > result.Shops[j].Count
+		}
+		return result.Shops[i].Key < result.Shops[j].Key
+	})
+	if len(result.Shops) > entity.SearchShopFacetSize {
+		result.Shops = result.Shops[:entity.SearchShopFacetSize]
+	}
+	for i, r := range entity.SearchPriceRanges {
+		result.Prices = append(result.Prices, entity.FacetBucket{Key: r.Key(), Count: prices[i]})
+	}
+	return result
+}
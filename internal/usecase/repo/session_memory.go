@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// SessionMemoryStore keeps the sessions of this gateway instance.
+type SessionMemoryStore struct {
+	mu       sync.Mutex
+	sessions map[int64]map[string]entity.Session
+}
+
+func NewSessionMemoryStore() *SessionMemoryStore {
+	return &SessionMemoryStore{
+		sessions: make(map[int64]map[string]entity.Session),
+	}
+}
+
+func (store *SessionMemoryStore) SaveSession(ctx context.Context, session *entity.Session) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	sessions, ok := store.sessions[session.UserId]
+	if !ok {
+		sessions = make(map[string]entity.Session)
+		store.sessions[session.UserId] = sessions
+	}
+	sessions[session.ID] = *session
+	return nil
+}
+
+func (store *SessionMemoryStore) GetSession(ctx context.Context, userId int64, id string) (*entity.Session, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	session, ok := store.sessions[userId][id]
+	if !ok || !time.Now().Before(session.ExpiresAt) {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (store *SessionMemoryStore) ListSessions(ctx context.Context, userId int64) ([]*entity.Session, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]*entity.Session, 0, len(store.sessions[userId]))
+	for id, session := range store.sessions[userId] {
+		if !now.Before(session.ExpiresAt) {
+			delete(store.sessions[userId], id)
+			continue
+		}
+		session := session
+		sessions = append(sessions, &session)
+	}
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+func (store *SessionMemoryStore) DeleteSession(ctx context.Context, userId int64, id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.sessions[userId], id)
+	if len(store.sessions[userId]) == 0 {
+		delete(store.sessions, userId)
+	}
+	return nil
+}
+
+// sortSessions puts the most recently used sessions first.
+func sortSessions(sessions []*entity.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// SessionRedisStore keeps the sessions of a user in a hash keyed by session.
+// Every save extends a session by the refresh token lifetime, so the hash
+// expires with the session saved last; expired sessions within it are
+// dropped when listing.
+type SessionRedisStore struct {
+	client redis.Cmdable
+}
+
+func NewSessionRedisStore(client redis.Cmdable) *SessionRedisStore {
+	return &SessionRedisStore{client: client}
+}
+
+func sessionsKey(userId int64) string {
+	return "sessions:" + strconv.FormatInt(userId, 10)
+}
+
+func (store *SessionRedisStore) SaveSession(ctx context.Context, session *entity.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	key := sessionsKey(session.UserId)
+	_, err = store.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, session.ID, data)
+		pipe.Expire(ctx, key, time.Until(session.ExpiresAt))
+		return nil
+	})
+	return err
+}
+
+func (store *SessionRedisStore) GetSession(ctx context.Context, userId int64, id string) (*entity.Session, error) {
+	data, err := store.client.HGet(ctx, sessionsKey(userId), id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session entity.Session
+	err = json.Unmarshal(data, &session)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (store *SessionRedisStore) ListSessions(ctx context.Context, userId int64) ([]*entity.Session, error) {
+	key := sessionsKey(userId)
+	values, err := store.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := make([]*entity.Session, 0, len(values))
+	var expired []string
+	for id, data := range values {
+		var session entity.Session
+		err = json.Unmarshal([]byte(data), &session)
+		if err != nil {
+			return nil, err
+		}
+		if !now.Before(session.ExpiresAt) {
+			expired = append(expired, id)
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	if len(expired) > 0 {
+		err = store.client.HDel(ctx, key, expired...).Err()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+func (store *SessionRedisStore) DeleteSession(ctx context.Context, userId int64, id string) error {
+	return store.client.HDel(ctx, sessionsKey(userId), id).Err()
+}
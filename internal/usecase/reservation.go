@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// _reservationTTL is how long a single-use token stays reserved by a request
+// that never finishes, like when the gateway dies halfway.
+const _reservationTTL = time.Minute
+
+// tokenReservation holds a single-use token for one request while the change
+// it authorizes is made.
+type tokenReservation struct {
+	store   RevocationStore
+	payload *token.Payload
+	id      uuid.UUID
+}
+
+// reserveToken reserves the token of payload in store, refusing it with a
+// conflict saying usedMessage once it was used. Of concurrent requests with
+// the same token only one gets it; the others are refused as well.
+func reserveToken(ctx context.Context, store RevocationStore, payload *token.Payload, usedMessage string) (*tokenReservation, error) {
+	used, err := store.IsRevoked(ctx, payload.ID)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if used {
+		return nil, apperror.Conflict(usedMessage)
+	}
+
+	reservation := &tokenReservation{
+		store:   store,
+		payload: payload,
+		id:      uuid.NewSHA1(payload.ID, []byte("reserved")),
+	}
+	until := time.Now().Add(_reservationTTL)
+	if payload.ExpiredAt.Before(until) {
+		until = payload.ExpiredAt
+	}
+	first, err := store.RevokeOnce(ctx, reservation.id, until)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if !first {
+		return nil, apperror.Conflict("token is being used by another request")
+	}
+	return reservation, nil
+}
+
+// use marks the token used once its change went through.
+func (reservation *tokenReservation) use(ctx context.Context) error {
+	defer reservation.release()
+
+	err := reservation.store.Revoke(ctx, reservation.payload.ID, reservation.payload.ExpiredAt)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// release gives the token back for another try. A reservation that can't be
+// released runs out by itself.
+func (reservation *tokenReservation) release() {
+	_ = reservation.store.Unrevoke(context.Background(), reservation.id)
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// SearchTopics are the routing keys of the catalog changes the indexer
+// follows.
+var SearchTopics = []string{"shop.*", "menu_item.*"}
+
+// _maxSearchWindow is as deep as results can be paged, Elasticsearch's
+// default max_result_window.
+const _maxSearchWindow = 10000
+
+// _nearbyCandidates is how many of the closest shops are checked against
+// their delivery zones when looking for shops nearby.
+const _nearbyCandidates = 500
+
+// SearchUseCase keeps the search index in step with the shops service and
+// answers searches from it.
+type SearchUseCase struct {
+	config *config.Config
+	index  SearchIndex
+	shops  ShopWebAPI
+}
+
+func NewSearchUseCase(config *config.Config, index SearchIndex, shops ShopWebAPI) *SearchUseCase {
+	return &SearchUseCase{
+		config: config,
+		index:  index,
+		shops:  shops,
+	}
+}
+
+func (uc *SearchUseCase) Search(ctx context.Context, query *entity.SearchQuery) (*entity.SearchResult, error) {
+	query.Text = strings.TrimSpace(query.Text)
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return nil, apperror.Validation("invalid search", apperror.FieldError{
+			Field:   "max_price",
+			Message: "must not be less than min_price",
+		})
+	}
+	if query.Offset+query.Limit > _maxSearchWindow {
+		return nil, apperror.Validation("invalid search", apperror.FieldError{
+			Field:   "offset",
+			Message: "results can't be paged this deep, narrow the search down",
+		})
+	}
+	if query.At.IsZero() {
+		query.At = time.Now()
+	}
+
+	result, err := uc.index.Search(ctx, query)
+	if err != nil {
+		return nil, apperror.Upstream(apperror.CodeUpstreamUnavailable, "search is unavailable", err)
+	}
+	return result, nil
+}
+
+// NearbyShops returns the shops within the radius, nearest first, whose
+// delivery zone covers the location. The radius defaults to the default
+// delivery radius.
+func (uc *SearchUseCase) NearbyShops(ctx context.Context, query *entity.NearbyQuery) ([]*entity.NearbyShop, error) {
+	radius := query.RadiusKm
+	if radius == 0 {
+		radius = uc.config.DeliveryRadiusKm
+	}
+
+	docs, err := uc.index.NearbyShops(ctx, query.Location, radius, _nearbyCandidates)
+	if err != nil {
+		return nil, apperror.Upstream(apperror.CodeUpstreamUnavailable, "search is unavailable", err)
+	}
+
+	now := time.Now()
+	shops := make([]*entity.NearbyShop, 0)
+	for _, doc := range docs {
+		var zone entity.DeliveryZone
+		if doc.DeliveryZone != nil {
+			zone = *doc.DeliveryZone
+		}
+		if doc.Location == nil || !zone.Covers(*doc.Location, query.Location, uc.config.DeliveryRadiusKm) {
+			continue
+		}
+
+		shops = append(shops, &entity.NearbyShop{
+			ID:           doc.ID,
+			Name:         doc.Name,
+			Description:  doc.Description,
+			Location:     *doc.Location,
+			DeliveryZone: zone,
+			OpenNow:      doc.Hours.IsOpenAt(now),
+			DistanceKm:   math.Round(doc.Location.DistanceTo(query.Location)*100) / 100,
+		})
+		if len(shops) == query.Limit {
+			break
+		}
+	}
+	return shops, nil
+}
+
+// IndexShop indexes a shop with its whole menu, whose documents carry the
+// shop's name and hours.
+func (uc *SearchUseCase) IndexShop(ctx context.Context, shop *entity.Shop) error {
+	docs, err := shopDocuments(ctx, uc.shops, shop)
+	if err != nil {
+		return err
+	}
+	return uc.index.IndexDocuments(ctx, docs)
+}
+
+func shopDocuments(ctx context.Context, shops ShopWebAPI, shop *entity.Shop) ([]*entity.SearchDocument, error) {
+	menu, err := shops.GetMenu(ctx, shop.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*entity.SearchDocument, 0, len(menu)+1)
+	docs = append(docs, entity.NewShopDocument(shop))
+	for _, item := range menu {
+		docs = append(docs, entity.NewMenuItemDocument(item, shop))
+	}
+	return docs, nil
+}
+
+// Dispatch applies a shop or menu item change to the index.
+func (uc *SearchUseCase) Dispatch(routingKey string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), uc.config.SearchTimeout)
+	defer cancel()
+
+	switch routingKey {
+	case entity.ShopEventCreated, entity.ShopEventUpdated, entity.ShopEventDeleted:
+		var event entity.ShopEvent
+		err := json.Unmarshal(body, &event)
+		if err != nil {
+			return err
+		}
+		if routingKey == entity.ShopEventDeleted {
+			return uc.index.DeleteShop(ctx, event.ShopId)
+		}
+		shop := event.Shop
+		if shop == nil {
+			shop, err = uc.shops.GetShopInfo(ctx, event.ShopId)
+			if err != nil {
+				return err
+			}
+		}
+		return uc.IndexShop(ctx, shop)
+
+	case entity.MenuItemEventCreated, entity.MenuItemEventUpdated, entity.MenuItemEventDeleted:
+		var event entity.MenuItemEvent
+		err := json.Unmarshal(body, &event)
+		if err != nil {
+			return err
+		}
+		if routingKey == entity.MenuItemEventDeleted {
+			return uc.index.DeleteDocument(ctx, entity.SearchKindMenuItem, event.MenuItemId)
+		}
+		item := event.MenuItem
+		if item == nil {
+			item, err = uc.shops.GetMenuItem(ctx, event.MenuItemId)
+			if err != nil {
+				return err
+			}
+		}
+		shop, err := uc.shops.GetShopInfo(ctx, item.ShopId)
+		if err != nil {
+			return err
+		}
+		return uc.index.IndexDocuments(ctx, []*entity.SearchDocument{entity.NewMenuItemDocument(item, shop)})
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package usecase_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+)
+
+func clock(hour int, minute int) time.Time {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+// newSearchTest indexes three shops with their menus:
+//   - shop 1, Pizza Palace, open 09:00 to 21:00 UTC,
+//   - shop 2, Night Owl Burgers, open overnight from 20:00 to 04:00 UTC,
+//   - shop 3, Sushi Bar, closed.
+func newSearchTest(t *testing.T) *usecase.SearchUseCase {
+	t.Helper()
+
+	shops := newMemoryShops()
+	catalog := []struct {
+		shop  entity.Shop
+		items []entity.GetMenuItem
+	}{
+		{
+			shop: entity.Shop{ID: 1, Name: "Pizza Palace", Description: "Wood fired pizza", OpenTime: clock(9, 0), CloseTime: clock(21, 0)},
+			items: []entity.GetMenuItem{
+				{ID: 101, Name: "Margherita Pizza", Description: "Tomato and mozzarella", Price: 800},
+				{ID: 102, Name: "Pepperoni Pizza", Price: 1200},
+				{ID: 103, Name: "Tiramisu", Price: 450},
+			},
+		},
+		{
+			shop: entity.Shop{ID: 2, Name: "Night Owl Burgers", OpenTime: clock(20, 0), CloseTime: clock(4, 0)},
+			items: []entity.GetMenuItem{
+				{ID: 201, Name: "Cheeseburger", Price: 900},
+				{ID: 202, Name: "Fries", Price: 300},
+			},
+		},
+		{
+			shop: entity.Shop{ID: 3, Name: "Sushi Bar", IsClosed: true},
+			items: []entity.GetMenuItem{
+				{ID: 301, Name: "Salmon Roll", Price: 2500},
+			},
+		},
+	}
+
+	uc := usecase.NewSearchUseCase(&config.Config{SearchTimeout: time.Second}, repo.NewSearchMemoryIndex(), shops)
+	for _, entry := range catalog {
+		shop := entry.shop
+		shops.addShop(&shop)
+		for _, item := range entry.items {
+			item := item
+			item.ShopId = shop.ID
+			shops.addMenuItem(&item)
+		}
+		err := uc.IndexShop(context.Background(), &shop)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return uc
+}
+
+func search(t *testing.T, uc *usecase.SearchUseCase, query entity.SearchQuery) *entity.SearchResult {
+	t.Helper()
+
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+	result, err := uc.Search(context.Background(), &query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func hitKeys(result *entity.SearchResult) []string {
+	keys := []string{}
+	for _, hit := range result.Hits {
+		keys = append(keys, hit.Key())
+	}
+	return keys
+}
+
+func price(p int32) *int32 {
+	return &p
+}
+
+func TestSearchMatchesFuzzily(t *testing.T) {
+	uc := newSearchTest(t)
+	pizzaPalace := []string{"shop-1", "menu_item-101", "menu_item-102", "menu_item-103"}
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		// Ranked by where the word is found: the shop's own name,
+		// description and shop name beat the items named after it, which beat
+		// an item only found through its shop's name.
+		{"exact", "pizza", pizzaPalace},
+		{"any case and spacing", "  PIZZA ", pizzaPalace},
+		{"one edit", "piza", pizzaPalace},
+		{"two edits in a long word", "chesebuger", []string{"menu_item-201"}},
+		{"too many edits", "burgr", []string{}},
+		{"short words match exactly", "ro", []string{}},
+		{"any of the words", "salmon fries", []string{"menu_item-202", "menu_item-301"}},
+		{"shop name", "owl", []string{"shop-2", "menu_item-201", "menu_item-202"}},
+		{"description", "mozzarella", []string{"menu_item-101"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := search(t, uc, entity.SearchQuery{Text: tt.text})
+			if got := hitKeys(result); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) found %v, want %v", tt.text, got, tt.want)
+			}
+			if result.Total != len(tt.want) {
+				t.Errorf("Search(%q) counted %d matches, want %d", tt.text, result.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	uc := newSearchTest(t)
+
+	tests := []struct {
+		text string
+		key  string
+		want map[string][]string
+	}{
+		{
+			text: "pizza",
+			key:  "shop-1",
+			want: map[string][]string{
+				"name":        {"<em>Pizza</em> Palace"},
+				"shop_name":   {"<em>Pizza</em> Palace"},
+				"description": {"Wood fired <em>pizza</em>"},
+			},
+		},
+		{
+			text: "piza margherita",
+			key:  "menu_item-101",
+			want: map[string][]string{
+				"name":      {"<em>Margherita</em> <em>Pizza</em>"},
+				"shop_name": {"<em>Pizza</em> Palace"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		result := search(t, uc, entity.SearchQuery{Text: tt.text})
+		var highlights map[string][]string
+		for _, hit := range result.Hits {
+			if hit.Key() == tt.key {
+				highlights = hit.Highlights
+			}
+		}
+		if !reflect.DeepEqual(highlights, tt.want) {
+			t.Errorf("Search(%q) highlighted %s as %v, want %v", tt.text, tt.key, highlights, tt.want)
+		}
+	}
+
+	// Nothing is highlighted without a text.
+	for _, hit := range search(t, uc, entity.SearchQuery{}).Hits {
+		if len(hit.Highlights) != 0 {
+			t.Errorf("Search without a text highlighted %s: %v", hit.Key(), hit.Highlights)
+		}
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	uc := newSearchTest(t)
+	pizzaPalace := []string{"menu_item-101", "menu_item-102", "menu_item-103", "shop-1"}
+	nightOwl := []string{"menu_item-201", "menu_item-202", "shop-2"}
+
+	tests := []struct {
+		name  string
+		query entity.SearchQuery
+		want  []string
+	}{
+		{"open in the day", entity.SearchQuery{OpenNow: true, At: clock(10, 0)}, pizzaPalace},
+		{"open at opening", entity.SearchQuery{OpenNow: true, At: clock(9, 0)}, pizzaPalace},
+		{"closing", entity.SearchQuery{OpenNow: true, At: clock(21, 0)}, nightOwl},
+		{"open overnight before midnight", entity.SearchQuery{OpenNow: true, At: clock(23, 30)}, nightOwl},
+		{"open overnight after midnight", entity.SearchQuery{OpenNow: true, At: clock(3, 59)}, nightOwl},
+		{"all closed", entity.SearchQuery{OpenNow: true, At: clock(4, 0)}, []string{}},
+		{"open in another time zone", entity.SearchQuery{OpenNow: true, At: clock(10, 0).In(time.FixedZone("UTC+4", 4*60*60))}, pizzaPalace},
+		{"closed shops too", entity.SearchQuery{At: clock(4, 0), ShopId: 3}, []string{"menu_item-301", "shop-3"}},
+
+		{"price range", entity.SearchQuery{MinPrice: price(450), MaxPrice: price(900)}, []string{"menu_item-101", "menu_item-103", "menu_item-201"}},
+		{"min price", entity.SearchQuery{MinPrice: price(1000)}, []string{"menu_item-102", "menu_item-301"}},
+		{"max price", entity.SearchQuery{MaxPrice: price(300)}, []string{"menu_item-202"}},
+		{"one price", entity.SearchQuery{MinPrice: price(800), MaxPrice: price(800)}, []string{"menu_item-101"}},
+
+		{"shop", entity.SearchQuery{ShopId: 2}, nightOwl},
+		{"shops only", entity.SearchQuery{Kind: entity.SearchKindShop}, []string{"shop-1", "shop-2", "shop-3"}},
+		{
+			"text and filters",
+			entity.SearchQuery{Text: "pizza", Kind: entity.SearchKindMenuItem, MaxPrice: price(1000), OpenNow: true, At: clock(12, 0)},
+			[]string{"menu_item-101", "menu_item-103"},
+		},
+	}
+	// Without a text every hit scores the same and they come by key.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hitKeys(search(t, uc, tt.query)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search found %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchValidation(t *testing.T) {
+	uc := newSearchTest(t)
+
+	tests := []struct {
+		name  string
+		query entity.SearchQuery
+		field string
+	}{
+		{"min price above max price", entity.SearchQuery{MinPrice: price(1000), MaxPrice: price(999), Limit: 20}, "max_price"},
+		{"paged too deep", entity.SearchQuery{Offset: 9990, Limit: 20}, "offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.Search(context.Background(), &tt.query)
+			e := apperror.As(err)
+			if e == nil || e.Kind != apperror.KindValidation || len(e.Fields) != 1 || e.Fields[0].Field != tt.field {
+				t.Errorf("Search returned %v, want a validation error of %s", err, tt.field)
+			}
+		})
+	}
+
+	// The deepest page allowed still answers.
+	search(t, uc, entity.SearchQuery{Offset: 9980, Limit: 20})
+}
+
+func TestSearchFacets(t *testing.T) {
+	uc := newSearchTest(t)
+
+	// The facets count every match, not only the page.
+	result := search(t, uc, entity.SearchQuery{Limit: 2})
+	if result.Total != 9 || len(result.Hits) != 2 {
+		t.Fatalf("Search returned %d hits of %d, want 2 of 9", len(result.Hits), result.Total)
+	}
+	want := entity.SearchFacets{
+		Kinds: []entity.FacetBucket{
+			{Key: entity.SearchKindShop, Count: 3},
+			{Key: entity.SearchKindMenuItem, Count: 6},
+		},
+		Shops: []entity.FacetBucket{
+			{Key: "1", Label: "Pizza Palace", Count: 4},
+			{Key: "2", Label: "Night Owl Burgers", Count: 3},
+			{Key: "3", Label: "Sushi Bar", Count: 2},
+		},
+		Prices: []entity.FacetBucket{
+			{Key: "0-500", Count: 2},
+			{Key: "500-1000", Count: 2},
+			{Key: "1000-2000", Count: 1},
+			{Key: "2000-", Count: 1},
+		},
+	}
+	if !reflect.DeepEqual(result.Facets, want) {
+		t.Errorf("Search facets = %+v, want %+v", result.Facets, want)
+	}
+
+	// They follow the filters.
+	result = search(t, uc, entity.SearchQuery{Text: "pizza", Kind: entity.SearchKindMenuItem})
+	want = entity.SearchFacets{
+		Kinds:  []entity.FacetBucket{{Key: entity.SearchKindMenuItem, Count: 3}},
+		Shops:  []entity.FacetBucket{{Key: "1", Label: "Pizza Palace", Count: 3}},
+		Prices: []entity.FacetBucket{{Key: "0-500", Count: 1}, {Key: "500-1000", Count: 1}, {Key: "1000-2000", Count: 1}, {Key: "2000-", Count: 0}},
+	}
+	if !reflect.DeepEqual(result.Facets, want) {
+		t.Errorf("Search facets = %+v, want %+v", result.Facets, want)
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	uc := newSearchTest(t)
+	all := hitKeys(search(t, uc, entity.SearchQuery{}))
+	if len(all) != 9 {
+		t.Fatalf("Search found %v, want 9 documents", all)
+	}
+
+	var paged []string
+	for offset := 0; offset < 12; offset += 4 {
+		result := search(t, uc, entity.SearchQuery{Limit: 4, Offset: offset})
+		if result.Total != 9 {
+			t.Errorf("page at %d counted %d matches, want 9", offset, result.Total)
+		}
+		paged = append(paged, hitKeys(result)...)
+	}
+	if !reflect.DeepEqual(paged, all) {
+		t.Errorf("pages found %v, want %v", paged, all)
+	}
+
+	result := search(t, uc, entity.SearchQuery{Limit: 4, Offset: 20})
+	if len(result.Hits) != 0 || result.Total != 9 {
+		t.Errorf("page past the end returned %d hits of %d, want none of 9", len(result.Hits), result.Total)
+	}
+}
@@ -0,0 +1,443 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// SessionUseCase issues the gateway's tokens. Every login starts a session
+// family; its refresh tokens are single-use and replaced on every renewal,
+// and presenting one twice revokes the whole family. The roles in the tokens
+// are worked out again on every renewal, and shop ownership again whenever a
+// shop scoped permission is checked.
+//
+// Sessions are also kept in a store with the device they were used from, so
+// users can see where they are logged in and log devices out. A session is
+// used when its refresh token is renewed.
+type SessionUseCase struct {
+	config     *config.Config
+	tokenMaker token.Maker
+	revoked    RevocationStore
+	sessions   SessionStore
+	users      UserWebAPI
+	shops      ShopWebAPI
+}
+
+func NewSessionUseCase(config *config.Config, tokenMaker token.Maker, revoked RevocationStore, sessions SessionStore, users UserWebAPI, shops ShopWebAPI) *SessionUseCase {
+	return &SessionUseCase{
+		config:     config,
+		tokenMaker: tokenMaker,
+		revoked:    revoked,
+		sessions:   sessions,
+		users:      users,
+		shops:      shops,
+	}
+}
+
+func (uc *SessionUseCase) StartSession(ctx context.Context, user *entity.User, client *entity.SessionClient) (*entity.SessionTokens, error) {
+	sessionID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	tokens, err := uc.issueFor(ctx, user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &entity.Session{
+		ID:        sessionID.String(),
+		UserId:    user.Id,
+		CreatedAt: now,
+	}
+	err = uc.saveSession(ctx, session, client, now, tokens)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RefreshSession exchanges a refresh token for a new pair in the same
+// session.
+func (uc *SessionUseCase) RefreshSession(ctx context.Context, refreshToken string, client *entity.SessionClient) (*entity.SessionTokens, error) {
+	payload, err := uc.verify(refreshToken, token.TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.checkRevoked(ctx, payload.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := uc.sessions.GetSession(ctx, payload.UserId, payload.SessionID.String())
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if session == nil {
+		return nil, apperror.Unauthorized("session has ended")
+	}
+
+	// Load the user before the token is spent, so a failing accounts service
+	// doesn't leave the client without a usable refresh token.
+	user, err := uc.users.GetMyProfile(ctx, payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := uc.revoked.RevokeOnce(ctx, payload.ID, payload.ExpiredAt)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if !first {
+		// Somebody holds a copy of a token that was already exchanged; neither
+		// copy can be trusted any more.
+		err = uc.endSession(ctx, payload.UserId, payload.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, apperror.Unauthorized("refresh token was already used, the session has been revoked")
+	}
+
+	tokens, err := uc.issueFor(ctx, user, payload.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.saveSession(ctx, session, client, time.Now(), tokens)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Authenticate verifies an access token and checks it wasn't revoked.
+func (uc *SessionUseCase) Authenticate(ctx context.Context, accessToken string) (*token.Payload, error) {
+	payload, err := uc.verify(accessToken, token.TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.checkRevoked(ctx, payload.ID, payload.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// EndSession revokes the session the refresh token belongs to, including the
+// access tokens issued in it.
+func (uc *SessionUseCase) EndSession(ctx context.Context, refreshToken string) error {
+	payload, err := uc.verify(refreshToken, token.TokenTypeRefresh)
+	if err != nil {
+		return err
+	}
+	return uc.endSession(ctx, payload.UserId, payload.SessionID)
+}
+
+// ListSessions returns the live sessions of the user, most recently used
+// first, marking currentId as the current one.
+func (uc *SessionUseCase) ListSessions(ctx context.Context, userId int64, currentId uuid.UUID) ([]*entity.Session, error) {
+	sessions, err := uc.sessions.ListSessions(ctx, userId)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentId.String()
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs one device of the user out; its access tokens stop
+// working right away.
+func (uc *SessionUseCase) RevokeSession(ctx context.Context, userId int64, id string) (string, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return "", apperror.NotFound("session not found")
+	}
+
+	session, err := uc.sessions.GetSession(ctx, userId, id)
+	if err != nil {
+		return "", apperror.Internal(err)
+	}
+	if session == nil {
+		return "", apperror.NotFound("session not found")
+	}
+
+	err = uc.endSession(ctx, userId, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return "session was revoked", nil
+}
+
+func (uc *SessionUseCase) RevokeOtherSessions(ctx context.Context, userId int64, currentId uuid.UUID) (string, error) {
+	revoked, err := uc.revokeSessions(ctx, userId, currentId)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("other sessions revoked: %d", revoked), nil
+}
+
+// RevokeAllSessions logs the user out on every device, like after their
+// password was reset.
+func (uc *SessionUseCase) RevokeAllSessions(ctx context.Context, userId int64) error {
+	_, err := uc.revokeSessions(ctx, userId, uuid.Nil)
+	return err
+}
+
+// revokeSessions ends every session of the user but keep and returns how
+// many it ended.
+func (uc *SessionUseCase) revokeSessions(ctx context.Context, userId int64, keep uuid.UUID) (int, error) {
+	sessions, err := uc.sessions.ListSessions(ctx, userId)
+	if err != nil {
+		return 0, apperror.Internal(err)
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		sessionID, err := uuid.Parse(session.ID)
+		if err != nil || sessionID == keep {
+			continue
+		}
+		err = uc.endSession(ctx, userId, sessionID)
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+func (uc *SessionUseCase) verify(tokenString string, tokenType token.TokenType) (*token.Payload, error) {
+	payload, err := uc.tokenMaker.VerifyToken(tokenString)
+	if err != nil {
+		return nil, apperror.Unauthorized(err.Error())
+	}
+	if payload.Type != tokenType {
+		return nil, apperror.Unauthorized(fmt.Sprintf("token type must be %s", tokenType))
+	}
+	return payload, nil
+}
+
+func (uc *SessionUseCase) checkRevoked(ctx context.Context, ids ...uuid.UUID) error {
+	revoked, err := uc.revoked.IsRevoked(ctx, ids...)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if revoked {
+		return apperror.Unauthorized("token has been revoked")
+	}
+	return nil
+}
+
+func (uc *SessionUseCase) issueFor(ctx context.Context, user *entity.User, sessionID uuid.UUID) (*entity.SessionTokens, error) {
+	roles, err := uc.roles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.issue(token.Claims{
+		UserId:        user.Id,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		IsAdmin:       user.IsAdmin,
+		Roles:         roles,
+		SessionID:     sessionID,
+	})
+}
+
+// roles collects the user's grants: everybody is a customer, the account
+// flags and grants come from the accounts service and shop ownership from the
+// shops service.
+func (uc *SessionUseCase) roles(ctx context.Context, user *entity.User) ([]entity.RoleGrant, error) {
+	roles := []entity.RoleGrant{{Role: entity.RoleCustomer}}
+	if user.IsCourier {
+		roles = append(roles, entity.RoleGrant{Role: entity.RoleCourier})
+	}
+	if user.IsAdmin {
+		roles = append(roles, entity.RoleGrant{Role: entity.RolePlatformAdmin})
+	}
+	roles = append(roles, user.Roles...)
+
+	owned, err := uc.ownedShops(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, shopId := range owned {
+		roles = append(roles, entity.RoleGrant{Role: entity.RoleShopOwner, ShopId: shopId})
+	}
+	return roles, nil
+}
+
+// RefreshShopRoles swaps the shop owner grants payload was issued with for
+// the shops the user owns now, so a shop opened since counts right away and
+// one handed over stops counting. API keys keep acting for their own shop
+// only, as long as it is still owned.
+func (uc *SessionUseCase) RefreshShopRoles(ctx context.Context, payload *token.Payload) error {
+	owned, err := uc.ownedShops(ctx, payload.UserId)
+	if err != nil {
+		return err
+	}
+	isOwned := make(map[int64]bool, len(owned))
+	for _, shopId := range owned {
+		isOwned[shopId] = true
+	}
+
+	roles := make([]entity.RoleGrant, 0, len(payload.Roles)+len(owned))
+	for _, grant := range payload.Roles {
+		if grant.Role != entity.RoleShopOwner || (payload.Type == token.TokenTypeAPIKey && isOwned[grant.ShopId]) {
+			roles = append(roles, grant)
+		}
+	}
+	if payload.Type != token.TokenTypeAPIKey {
+		for _, shopId := range owned {
+			roles = append(roles, entity.RoleGrant{Role: entity.RoleShopOwner, ShopId: shopId})
+		}
+	}
+	payload.Roles = roles
+	return nil
+}
+
+// ownedShops asks the shops service which shops the user owns.
+func (uc *SessionUseCase) ownedShops(ctx context.Context, userId int64) ([]int64, error) {
+	shops, err := uc.shops.GetShopsAdmin(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]int64, 0, len(shops))
+	for _, shop := range shops {
+		owned = append(owned, shop.ID)
+	}
+	return owned, nil
+}
+
+func (uc *SessionUseCase) issue(claims token.Claims) (*entity.SessionTokens, error) {
+	claims.Type = token.TokenTypeAccess
+	accessToken, accessPayload, err := uc.tokenMaker.CreateToken(claims, uc.config.AccessTokenDuration)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	claims.Type = token.TokenTypeRefresh
+	refreshToken, refreshPayload, err := uc.tokenMaker.CreateToken(claims, uc.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return &entity.SessionTokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+	}, nil
+}
+
+// saveSession records a use of session from client; it lasts as long as the
+// refresh token just issued.
+func (uc *SessionUseCase) saveSession(ctx context.Context, session *entity.Session, client *entity.SessionClient, usedAt time.Time, tokens *entity.SessionTokens) error {
+	if client != nil {
+		session.UserAgent = client.UserAgent
+		session.Device = describeDevice(client.UserAgent)
+		session.IP = client.IP
+	}
+	session.Current = false
+	session.LastUsedAt = usedAt
+	session.ExpiresAt = tokens.RefreshTokenExpiresAt
+
+	err := uc.sessions.SaveSession(ctx, session)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// endSession revokes a session and forgets it.
+func (uc *SessionUseCase) endSession(ctx context.Context, userId int64, sessionID uuid.UUID) error {
+	err := uc.revokeSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.sessions.DeleteSession(ctx, userId, sessionID.String())
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// revokeSession keeps the session revoked until every token issued in it has
+// expired.
+func (uc *SessionUseCase) revokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	lifetime := uc.config.RefreshTokenDuration
+	if uc.config.AccessTokenDuration > lifetime {
+		lifetime = uc.config.AccessTokenDuration
+	}
+	until := time.Now().Add(lifetime)
+	err := uc.revoked.Revoke(ctx, sessionID, until)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// _browsers and _systems are checked in order, since user agents name the
+// browsers they are built on too: Edge claims to be Chrome and Safari.
+var (
+	_browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp/", "Android app"},
+		{"CFNetwork/", "iOS app"},
+		{"curl/", "curl"},
+	}
+	_systems = []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// describeDevice names the browser and system of a user agent for people
+// looking at their sessions, like "Firefox on Windows".
+func describeDevice(userAgent string) string {
+	browser, system := "", ""
+	for _, b := range _browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range _systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
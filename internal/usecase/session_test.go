@@ -0,0 +1,238 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+	"github.com/zura-t/go_delivery_system/internal/usecase/repo"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+type sessionTest struct {
+	uc    *usecase.SessionUseCase
+	user  *entity.User
+	shops *memoryShops
+}
+
+func newSessionTest(t *testing.T) *sessionTest {
+	t.Helper()
+
+	tokenMaker, err := token.NewJwtMaker("12345678912345678912345678912345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		AccessTokenDuration:  time.Hour,
+		RefreshTokenDuration: 24 * time.Hour,
+	}
+	users := newMemoryUsers()
+	shops := newMemoryShops()
+
+	return &sessionTest{
+		uc:    usecase.NewSessionUseCase(cfg, tokenMaker, repo.NewRevocationMemoryStore(), repo.NewSessionMemoryStore(), users, shops),
+		user:  users.add(&entity.User{Email: "user@example.com"}),
+		shops: shops,
+	}
+}
+
+func (test *sessionTest) start(t *testing.T) *entity.SessionTokens {
+	t.Helper()
+
+	tokens, err := test.uc.StartSession(context.Background(), test.user, &entity.SessionClient{UserAgent: "curl/8.0", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func (test *sessionTest) refresh(t *testing.T, refreshToken string) *entity.SessionTokens {
+	t.Helper()
+
+	tokens, err := test.uc.RefreshSession(context.Background(), refreshToken, &entity.SessionClient{UserAgent: "curl/8.0", IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+// expectAuthenticated checks whether the access token is still accepted.
+func (test *sessionTest) expectAuthenticated(t *testing.T, accessToken string, want bool) {
+	t.Helper()
+
+	_, err := test.uc.Authenticate(context.Background(), accessToken)
+	switch {
+	case want && err != nil:
+		t.Errorf("Authenticate returned %v, want the token accepted", err)
+	case !want && !apperror.Is(err, apperror.KindUnauthorized):
+		t.Errorf("Authenticate returned %v, want an unauthorized error", err)
+	}
+}
+
+func (test *sessionTest) expectRefreshRefused(t *testing.T, refreshToken string) {
+	t.Helper()
+
+	_, err := test.uc.RefreshSession(context.Background(), refreshToken, nil)
+	if !apperror.Is(err, apperror.KindUnauthorized) {
+		t.Errorf("RefreshSession returned %v, want an unauthorized error", err)
+	}
+}
+
+func (test *sessionTest) sessionCount(t *testing.T) int {
+	t.Helper()
+
+	sessions, err := test.uc.ListSessions(context.Background(), test.user.Id, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(sessions)
+}
+
+func TestRefreshSessionRotatesTokens(t *testing.T) {
+	test := newSessionTest(t)
+	first := test.start(t)
+
+	second := test.refresh(t, first.RefreshToken)
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("RefreshSession returned the tokens it was given")
+	}
+	test.expectAuthenticated(t, second.AccessToken, true)
+
+	third := test.refresh(t, second.RefreshToken)
+	test.expectAuthenticated(t, third.AccessToken, true)
+
+	sessions, err := test.uc.ListSessions(context.Background(), test.user.Id, uuid.Nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].IP != "10.0.0.2" {
+		t.Errorf("ListSessions after renewals returned %+v, want the one session last used from 10.0.0.2", sessions)
+	}
+}
+
+func TestRefreshSessionRefusesAccessToken(t *testing.T) {
+	test := newSessionTest(t)
+	tokens := test.start(t)
+
+	test.expectRefreshRefused(t, tokens.AccessToken)
+	test.expectRefreshRefused(t, "not a token")
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	test := newSessionTest(t)
+	other := test.start(t)
+	first := test.start(t)
+	second := test.refresh(t, first.RefreshToken)
+
+	test.expectRefreshRefused(t, first.RefreshToken)
+
+	// The replay ends the session: the tokens already rotated to are
+	// refused as well.
+	test.expectRefreshRefused(t, second.RefreshToken)
+	test.expectAuthenticated(t, second.AccessToken, false)
+	test.expectAuthenticated(t, first.AccessToken, false)
+
+	test.expectAuthenticated(t, other.AccessToken, true)
+	if n := test.sessionCount(t); n != 1 {
+		t.Errorf("the user has %d sessions after the replay, want only the other one", n)
+	}
+	test.refresh(t, other.RefreshToken)
+}
+
+func TestEndSessionRevokesTokens(t *testing.T) {
+	test := newSessionTest(t)
+	other := test.start(t)
+	tokens := test.start(t)
+
+	err := test.uc.EndSession(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.expectAuthenticated(t, tokens.AccessToken, false)
+	test.expectRefreshRefused(t, tokens.RefreshToken)
+
+	test.expectAuthenticated(t, other.AccessToken, true)
+	if n := test.sessionCount(t); n != 1 {
+		t.Errorf("the user has %d sessions after logging out, want only the other one", n)
+	}
+
+	err = test.uc.EndSession(context.Background(), tokens.AccessToken)
+	if !apperror.Is(err, apperror.KindUnauthorized) {
+		t.Errorf("EndSession with an access token returned %v, want an unauthorized error", err)
+	}
+}
+
+func TestRefreshShopRoles(t *testing.T) {
+	test := newSessionTest(t)
+	ctx := context.Background()
+	test.shops.setOwner(1, test.user.Id)
+	test.shops.setOwner(2, test.user.Id)
+
+	tokens := test.start(t)
+	payload, err := test.uc.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !payload.HasPermission(entity.PermissionShopWrite, 2) {
+		t.Fatal("token wasn't issued with the shops the user owns")
+	}
+
+	// Shop 2 was handed over and shop 3 opened after the token was issued.
+	test.shops.setOwner(2, test.user.Id+1)
+	test.shops.setOwner(3, test.user.Id)
+	payload.Roles = append(payload.Roles, entity.RoleGrant{Role: entity.RoleShopStaff, ShopId: 4})
+
+	err = test.uc.RefreshShopRoles(ctx, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int64]bool{1: true, 2: false, 3: true}
+	for shopId, owner := range want {
+		if got := payload.HasPermission(entity.PermissionShopWrite, shopId); got != owner {
+			t.Errorf("HasPermission(shop:write, %d) = %v after the refresh, want %v", shopId, got, owner)
+		}
+	}
+	// Grants other than ownership stay as issued.
+	if !payload.HasPermission(entity.PermissionMenuWrite, 4) || !payload.HasPermission(entity.PermissionShopCreate, 0) {
+		t.Errorf("the refresh dropped grants besides ownership: %v", payload.Roles)
+	}
+}
+
+func TestRefreshShopRolesOfAnAPIKey(t *testing.T) {
+	test := newSessionTest(t)
+	ctx := context.Background()
+	test.shops.setOwner(1, test.user.Id)
+	test.shops.setOwner(2, test.user.Id)
+
+	payload := &token.Payload{
+		UserId: test.user.Id,
+		Type:   token.TokenTypeAPIKey,
+		Roles:  []entity.RoleGrant{{Role: entity.RoleShopOwner, ShopId: 1}},
+		Scopes: []entity.Permission{entity.PermissionMenuWrite},
+	}
+
+	// The key doesn't pick up the other shops of its owner.
+	err := test.uc.RefreshShopRoles(ctx, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !payload.HasPermission(entity.PermissionMenuWrite, 1) || payload.HasPermission(entity.PermissionMenuWrite, 2) {
+		t.Errorf("api key grants %v after the refresh, want its own shop only", payload.Roles)
+	}
+
+	// Nor keeps working for a shop its owner handed over.
+	test.shops.setOwner(1, 0)
+	err = test.uc.RefreshShopRoles(ctx, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.HasPermission(entity.PermissionMenuWrite, 0) {
+		t.Errorf("api key grants %v after its shop was handed over, want nothing", payload.Roles)
+	}
+}
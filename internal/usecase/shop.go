@@ -1,62 +1,131 @@
 package usecase
 
 import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/zura-t/go_delivery_system/config"
 	"github.com/zura-t/go_delivery_system/internal/entity"
 )
 
+// ShopUseCase passes shop and menu changes to the shops service and
+// publishes an event for each, which keeps the search index up to date.
 type ShopUseCase struct {
-	config *config.Config
-	webapi ShopWebAPI
+	config    *config.Config
+	webapi    ShopWebAPI
+	publisher EventPublisher
 }
 
-func NewShopUseCase(config *config.Config, webapi ShopWebAPI) *ShopUseCase {
+func NewShopUseCase(config *config.Config, webapi ShopWebAPI, publisher EventPublisher) *ShopUseCase {
 	return &ShopUseCase{
-		config: config,
-		webapi: webapi,
+		config:    config,
+		webapi:    webapi,
+		publisher: publisher,
 	}
 }
 
-func (uc *ShopUseCase) CreateShop(req *entity.CreateShop) (*entity.Shop, int, error) {
-	return uc.webapi.CreateShop(req)
+func (uc *ShopUseCase) CreateShop(ctx context.Context, req *entity.CreateShop) (*entity.Shop, error) {
+	shop, err := uc.webapi.CreateShop(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publishShop(ctx, entity.ShopEventCreated, shop.ID, shop)
+	return shop, nil
+}
+
+func (uc *ShopUseCase) GetShop(ctx context.Context, id int64) (*entity.Shop, error) {
+	return uc.webapi.GetShopInfo(ctx, id)
 }
 
-func (uc *ShopUseCase) GetShop(id int64) (*entity.Shop, int, error) {
-	return uc.webapi.GetShopInfo(id)
+func (uc *ShopUseCase) GetShops(ctx context.Context, limit int32, offset int32) ([]*entity.Shop, error) {
+	return uc.webapi.GetShops(ctx, limit, offset)
 }
 
-func (uc *ShopUseCase) GetShops(limit int32, offset int32) ([]*entity.Shop, int, error) {
-	return uc.webapi.GetShops(limit, offset)
+func (uc *ShopUseCase) GetShopsAdmin(ctx context.Context, user_id int64) ([]entity.Shop, error) {
+	return uc.webapi.GetShopsAdmin(ctx, user_id)
+}
+
+func (uc *ShopUseCase) UpdateShop(ctx context.Context, id int64, req *entity.UpdateShopInfo) (*entity.Shop, error) {
+	shop, err := uc.webapi.UpdateShop(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publishShop(ctx, entity.ShopEventUpdated, shop.ID, shop)
+	return shop, nil
 }
 
-func (uc *ShopUseCase) GetShopsAdmin(user_id int64) ([]entity.Shop, int, error) {
-	return uc.webapi.GetShopsAdmin(user_id)
+func (uc *ShopUseCase) CreateMenu(ctx context.Context, req *entity.CreateMenuItem) ([]*entity.GetMenuItem, error) {
+	items, err := uc.webapi.CreateMenu(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		uc.publishMenuItem(ctx, entity.MenuItemEventCreated, item.ID, item.ShopId, item)
+	}
+	return items, nil
 }
 
-func (uc *ShopUseCase) UpdateShop(id int64, req *entity.UpdateShopInfo) (*entity.Shop, int, error) {
-	return uc.webapi.UpdateShop(id, req)
+func (uc *ShopUseCase) GetMenu(ctx context.Context, shopId int64) ([]*entity.GetMenuItem, error) {
+	return uc.webapi.GetMenu(ctx, shopId)
 }
 
-func (uc *ShopUseCase) CreateMenu(req *entity.CreateMenuItem) ([]*entity.GetMenuItem, int, error) {
-	return uc.webapi.CreateMenu(req)
+func (uc *ShopUseCase) UpdateMenuItem(ctx context.Context, id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, error) {
+	item, err := uc.webapi.UpdateMenuItem(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.publishMenuItem(ctx, entity.MenuItemEventUpdated, item.ID, item.ShopId, item)
+	return item, nil
 }
 
-func (uc *ShopUseCase) GetMenu(shopId int64) ([]*entity.GetMenuItem, int, error) {
-	return uc.webapi.GetMenu(shopId)
+func (uc *ShopUseCase) GetMenuItem(ctx context.Context, id int64) (*entity.GetMenuItem, error) {
+	return uc.webapi.GetMenuItem(ctx, id)
 }
 
-func (uc *ShopUseCase) UpdateMenuItem(id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, int, error) {
-	return uc.webapi.UpdateMenuItem(id, req)
+func (uc *ShopUseCase) DeleteShop(ctx context.Context, id int64, user_id int64) (string, error) {
+	res, err := uc.webapi.DeleteShop(ctx, id, user_id)
+	if err != nil {
+		return "", err
+	}
+
+	uc.publishShop(ctx, entity.ShopEventDeleted, id, nil)
+	return res, nil
 }
 
-func (uc *ShopUseCase) GetMenuItem(id int64) (*entity.GetMenuItem, int, error) {
-	return uc.webapi.GetMenuItem(id)
+func (uc *ShopUseCase) DeleteMenuItem(ctx context.Context, id int64, user_id int64) (string, error) {
+	res, err := uc.webapi.DeleteMenuItem(ctx, id, user_id)
+	if err != nil {
+		return "", err
+	}
+
+	uc.publishMenuItem(ctx, entity.MenuItemEventDeleted, id, 0, nil)
+	return res, nil
 }
 
-func (uc *ShopUseCase) DeleteShop(id int64, user_id int64) (string, int, error) {
-	return uc.webapi.DeleteShop(id, user_id)
+// publishShop emits a shop change. Like order events, a failed delivery is
+// reported by the publisher rather than failing the stored change.
+func (uc *ShopUseCase) publishShop(ctx context.Context, eventType string, shopId int64, shop *entity.Shop) {
+	_ = uc.publisher.Publish(ctx, eventType, entity.ShopEvent{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		ShopId:     shopId,
+		Shop:       shop,
+		OccurredAt: time.Now(),
+	})
 }
 
-func (uc *ShopUseCase) DeleteMenuItem(id int64, user_id int64) (string, int, error) {
-	return uc.webapi.DeleteMenuItem(id, user_id)
+func (uc *ShopUseCase) publishMenuItem(ctx context.Context, eventType string, itemId int64, shopId int64, item *entity.GetMenuItem) {
+	_ = uc.publisher.Publish(ctx, eventType, entity.MenuItemEvent{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		MenuItemId: itemId,
+		ShopId:     shopId,
+		MenuItem:   item,
+		OccurredAt: time.Now(),
+	})
 }
@@ -0,0 +1,129 @@
+package usecase_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+)
+
+// memoryShops stands in for the shops service with the shops and menu items
+// added to it. Shops are owned by whoever setOwner names. Methods the tests
+// don't need panic through the nil embedded interface.
+type memoryShops struct {
+	usecase.ShopWebAPI
+
+	mu    sync.Mutex
+	shops map[int64]*entity.Shop
+	menu  map[int64]*entity.GetMenuItem
+	// owners maps shops to the users owning them.
+	owners map[int64]int64
+	// menuReads counts GetMenu and GetMenuItem calls.
+	menuReads int
+}
+
+func newMemoryShops() *memoryShops {
+	return &memoryShops{
+		shops:  make(map[int64]*entity.Shop),
+		menu:   make(map[int64]*entity.GetMenuItem),
+		owners: make(map[int64]int64),
+	}
+}
+
+func (shops *memoryShops) addShop(shop *entity.Shop) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+	shops.shops[shop.ID] = shop
+}
+
+// setOwner hands the shop over to the user, or to nobody for a zero userId.
+func (shops *memoryShops) setOwner(shopId int64, userId int64) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+	shops.owners[shopId] = userId
+}
+
+func (shops *memoryShops) addMenuItem(item *entity.GetMenuItem) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+	shops.menu[item.ID] = item
+}
+
+// GetShops pages through the shops by id.
+func (shops *memoryShops) GetShops(ctx context.Context, limit int32, offset int32) ([]*entity.Shop, error) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+
+	all := make([]*entity.Shop, 0, len(shops.shops))
+	for _, shop := range shops.shops {
+		copied := *shop
+		all = append(all, &copied)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	if int(offset) > len(all) {
+		offset = int32(len(all))
+	}
+	end := int(offset) + int(limit)
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[offset:end], nil
+}
+
+func (shops *memoryShops) GetShopsAdmin(ctx context.Context, userId int64) ([]entity.Shop, error) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+
+	owned := []entity.Shop{}
+	for shopId, owner := range shops.owners {
+		if owner == userId {
+			owned = append(owned, entity.Shop{ID: shopId})
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].ID < owned[j].ID })
+	return owned, nil
+}
+
+func (shops *memoryShops) GetShopInfo(ctx context.Context, id int64) (*entity.Shop, error) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+
+	shop, ok := shops.shops[id]
+	if !ok {
+		return nil, apperror.NotFound("shop not found")
+	}
+	copied := *shop
+	return &copied, nil
+}
+
+func (shops *memoryShops) GetMenu(ctx context.Context, shopId int64) ([]*entity.GetMenuItem, error) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+
+	shops.menuReads++
+	var menu []*entity.GetMenuItem
+	for _, item := range shops.menu {
+		if item.ShopId == shopId {
+			copied := *item
+			menu = append(menu, &copied)
+		}
+	}
+	return menu, nil
+}
+
+func (shops *memoryShops) GetMenuItem(ctx context.Context, id int64) (*entity.GetMenuItem, error) {
+	shops.mu.Lock()
+	defer shops.mu.Unlock()
+
+	shops.menuReads++
+	item, ok := shops.menu[id]
+	if !ok {
+		return nil, apperror.NotFound("menu item not found")
+	}
+	copied := *item
+	return &copied, nil
+}
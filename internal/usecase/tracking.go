@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/pkg/hub"
+)
+
+// TrackingTopics are the routing keys of the events delivered to clients
+// following an order.
+var TrackingTopics = []string{"order.*", entity.DeliveryEventAccepted, entity.CourierLocationEventType}
+
+type TrackingUseCase struct {
+	hub    *hub.Hub
+	orders Order
+}
+
+func NewTrackingUseCase(hub *hub.Hub, orders Order) *TrackingUseCase {
+	return &TrackingUseCase{
+		hub:    hub,
+		orders: orders,
+	}
+}
+
+func orderTopic(orderId int64) string {
+	return fmt.Sprintf("order:%d", orderId)
+}
+
+// Follow subscribes the owner of an order to its events. It returns the order
+// as it is now and the buffered events after lastEventID.
+func (uc *TrackingUseCase) Follow(ctx context.Context, orderId int64, userId int64, lastEventID string) (*entity.Order, *hub.Subscription, []hub.Event, error) {
+	order, err := uc.orders.GetOrder(ctx, orderId, userId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sub, replay := uc.hub.Subscribe(orderTopic(orderId), lastEventID)
+	return order, sub, replay, nil
+}
+
+func (uc *TrackingUseCase) Unfollow(sub *hub.Subscription) {
+	uc.hub.Unsubscribe(sub)
+}
+
+// Dispatch hands an event received from the broker to the clients following
+// the order it belongs to.
+func (uc *TrackingUseCase) Dispatch(routingKey string, body []byte) error {
+	var event struct {
+		OrderId int64 `json:"order_id"`
+	}
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return err
+	}
+	if event.OrderId == 0 {
+		return nil
+	}
+
+	uc.hub.Publish(orderTopic(event.OrderId), routingKey, body)
+	return nil
+}
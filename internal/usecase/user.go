@@ -1,48 +1,248 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/apperror"
 	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
 )
 
 type UserUseCase struct {
-	config *config.Config
-	webapi UserWebAPI
+	config     *config.Config
+	webapi     UserWebAPI
+	sessions   Session
+	accounts   Account
+	mfa        MFA
+	attempts   LoginAttemptStore
+	tokenMaker token.Maker
+	answered   RevocationStore
 }
 
-func NewUserUseCase(config *config.Config, webapi UserWebAPI) *UserUseCase {
+func NewUserUseCase(config *config.Config, webapi UserWebAPI, sessions Session, accounts Account, mfa MFA, attempts LoginAttemptStore, tokenMaker token.Maker, answered RevocationStore) *UserUseCase {
 	return &UserUseCase{
-		config: config,
-		webapi: webapi,
+		config:     config,
+		webapi:     webapi,
+		sessions:   sessions,
+		accounts:   accounts,
+		mfa:        mfa,
+		attempts:   attempts,
+		tokenMaker: tokenMaker,
+		answered:   answered,
 	}
 }
 
-func (uc *UserUseCase) CreateUser(req *entity.UserRegister) (*entity.User, int, error) {
-	return uc.webapi.CreateUser(req)
+// CreateUser registers the user and mails them a verification link.
+func (uc *UserUseCase) CreateUser(ctx context.Context, req *entity.UserRegister) (*entity.User, error) {
+	user, err := uc.webapi.CreateUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user exists at this point; if the mail fails they can ask for
+	// another one.
+	_ = uc.accounts.SendVerification(ctx, user.Id)
+	return user, nil
+}
+
+// LoginUser checks the credentials with the accounts service and starts a
+// gateway session for the user. Users with two-factor authentication get a
+// challenge instead, answered by LoginMFA. Accounts with too many failed
+// logins are locked for a while, even for the right password.
+func (uc *UserUseCase) LoginUser(ctx context.Context, req *entity.UserLogin, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	err := uc.checkLockout(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := uc.webapi.LoginUser(ctx, req)
+	if apperror.Is(err, apperror.KindUnauthorized) || apperror.Is(err, apperror.KindNotFound) {
+		lockErr := uc.loginFailed(ctx, email)
+		if lockErr != nil {
+			return nil, nil, lockErr
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Failures are only forgiven once the second factor is in too, so
+	// guessing codes counts towards the lockout.
+	enabled, err := uc.mfa.Enabled(ctx, resp.User.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enabled {
+		challenge, err := uc.challenge(&resp.User, email)
+		return nil, challenge, err
+	}
+
+	resp, err = uc.startSession(ctx, email, resp, client)
+	return resp, nil, err
 }
 
-func (uc *UserUseCase) LoginUser(req *entity.UserLogin) (*entity.UserLoginResponse, int, error) {
-	return uc.webapi.LoginUser(req)
+// LoginExternal logs in a user whose identity was proven elsewhere, asking
+// for the second factor like a password login does.
+func (uc *UserUseCase) LoginExternal(ctx context.Context, user *entity.User, client *entity.SessionClient) (*entity.UserLoginResponse, *entity.MFAChallenge, error) {
+	email := strings.ToLower(user.Email)
+	err := uc.checkLockout(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enabled, err := uc.mfa.Enabled(ctx, user.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if enabled {
+		challenge, err := uc.challenge(user, email)
+		return nil, challenge, err
+	}
+
+	resp, err := uc.startSession(ctx, email, &entity.UserLoginResponse{User: *user}, client)
+	return resp, nil, err
 }
 
-func (uc *UserUseCase) GetMyProfile(id int64) (*entity.User, int, error) {
-	return uc.webapi.GetMyProfile(id)
+// LoginMFA finishes a login with the challenge token LoginUser returned and a
+// code of the user's authenticator app or a recovery code.
+func (uc *UserUseCase) LoginMFA(ctx context.Context, challengeToken string, code string, client *entity.SessionClient) (*entity.UserLoginResponse, error) {
+	payload, err := uc.tokenMaker.VerifyToken(challengeToken)
+	if err != nil {
+		return nil, apperror.Unauthorized(fmt.Sprintf("invalid challenge: %s", err))
+	}
+	if payload.Type != token.TokenTypeMFAChallenge {
+		return nil, apperror.Unauthorized("invalid challenge: not an mfa challenge")
+	}
+
+	err = uc.checkLockout(ctx, payload.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := uc.mfa.Verify(ctx, payload.UserId, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		err = uc.loginFailed(ctx, payload.Email)
+		if err != nil {
+			return nil, err
+		}
+		return nil, apperror.Unauthorized("invalid code")
+	}
+
+	first, err := uc.answered.RevokeOnce(ctx, payload.ID, payload.ExpiredAt)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	if !first {
+		return nil, apperror.Unauthorized("challenge was already answered")
+	}
+
+	user, err := uc.webapi.GetMyProfile(ctx, payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return uc.startSession(ctx, payload.Email, &entity.UserLoginResponse{User: *user}, client)
+}
+
+func (uc *UserUseCase) checkLockout(ctx context.Context, email string) error {
+	lockedUntil, err := uc.attempts.LockedUntil(ctx, email)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if wait := time.Until(lockedUntil); wait > 0 {
+		return apperror.TooMany("too many failed logins, try again later", wait)
+	}
+	return nil
+}
+
+// challenge signs a token standing for the password login of user. Its email
+// is the one logged in with, so failures count towards the same lockout.
+func (uc *UserUseCase) challenge(user *entity.User, email string) (*entity.MFAChallenge, error) {
+	challenge, payload, err := uc.tokenMaker.CreateToken(token.Claims{
+		UserId: user.Id,
+		Email:  email,
+		Type:   token.TokenTypeMFAChallenge,
+	}, uc.config.MFAChallengeDuration)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return &entity.MFAChallenge{
+		MFARequired:    true,
+		ChallengeToken: challenge,
+		ExpiresAt:      payload.ExpiredAt,
+	}, nil
+}
+
+func (uc *UserUseCase) startSession(ctx context.Context, email string, resp *entity.UserLoginResponse, client *entity.SessionClient) (*entity.UserLoginResponse, error) {
+	err := uc.attempts.Reset(ctx, email)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	tokens, err := uc.sessions.StartSession(ctx, &resp.User, client)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.AccessToken = tokens.AccessToken
+	resp.AccessTokenExpiresAt = tokens.AccessTokenExpiresAt
+	resp.RefreshToken = tokens.RefreshToken
+	resp.RefreshTokenExpiresAt = tokens.RefreshTokenExpiresAt
+	return resp, nil
+}
+
+// loginFailed counts a failed login and locks the account once there were
+// LoginLockoutThreshold of them, twice as long for every further failure.
+func (uc *UserUseCase) loginFailed(ctx context.Context, email string) error {
+	if uc.config.LoginLockoutThreshold <= 0 {
+		return nil
+	}
+
+	failures, err := uc.attempts.AddFailure(ctx, email, uc.config.LoginFailureWindow)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if failures < uc.config.LoginLockoutThreshold {
+		return nil
+	}
+
+	lockout := uc.config.LoginLockoutBase
+	for i := uc.config.LoginLockoutThreshold; i < failures && lockout < uc.config.LoginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > uc.config.LoginLockoutMax {
+		lockout = uc.config.LoginLockoutMax
+	}
+
+	err = uc.attempts.Lock(ctx, email, time.Now().Add(lockout))
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
 }
 
-func (uc *UserUseCase) AddAdminRole(id int64) (string, int, error) {
-	return uc.webapi.AddAdminRole(id)
+func (uc *UserUseCase) GetMyProfile(ctx context.Context, id int64) (*entity.User, error) {
+	return uc.webapi.GetMyProfile(ctx, id)
 }
 
-func (uc *UserUseCase) UpdateUser(id int64, req *entity.UserUpdate) (*entity.User, int, error) {
-	return uc.webapi.UpdateUser(id, req)
+func (uc *UserUseCase) UpdateUser(ctx context.Context, id int64, req *entity.UserUpdate) (*entity.User, error) {
+	return uc.webapi.UpdateUser(ctx, id, req)
 }
 
-func (uc *UserUseCase) AddPhone(id int64, req *entity.UserAddPhone) (string, int, error) {
-	return uc.webapi.AddPhone(id, req)
+func (uc *UserUseCase) AddPhone(ctx context.Context, id int64, req *entity.UserAddPhone) (string, error) {
+	return uc.webapi.AddPhone(ctx, id, req)
 }
 
-func (uc *UserUseCase) DeleteUser(id int64) (string, int, error) {
-	return uc.webapi.DeleteUser(id)
+func (uc *UserUseCase) DeleteUser(ctx context.Context, id int64) (string, error) {
+	return uc.webapi.DeleteUser(ctx, id)
 }
 
 func IsAdmin(id int64) (bool, error) {
@@ -0,0 +1,162 @@
+package usecase_test
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase"
+)
+
+// memoryUsers stands in for the users service. Methods the tests don't need
+// panic through the nil embedded interface.
+type memoryUsers struct {
+	usecase.UserWebAPI
+
+	mu    sync.Mutex
+	users map[int64]*entity.User
+	// failResets fails that many ResetPassword calls before the next one
+	// succeeds, failRoles that many AddAdminRole calls.
+	failResets int
+	failRoles  int
+	// resets counts the passwords that were reset.
+	resets int
+}
+
+func newMemoryUsers() *memoryUsers {
+	return &memoryUsers{users: make(map[int64]*entity.User)}
+}
+
+func (users *memoryUsers) add(user *entity.User) *entity.User {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	user.Id = int64(len(users.users) + 1)
+	users.users[user.Id] = user
+	copied := *user
+	return &copied
+}
+
+func (users *memoryUsers) count() int {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+	return len(users.users)
+}
+
+func (users *memoryUsers) CreateUser(ctx context.Context, req *entity.UserRegister) (*entity.User, error) {
+	return users.add(&entity.User{Email: req.Email, Name: req.Name}), nil
+}
+
+func (users *memoryUsers) GetMyProfile(ctx context.Context, id int64) (*entity.User, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	user, ok := users.users[id]
+	if !ok {
+		return nil, apperror.NotFound("user not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (users *memoryUsers) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	for _, user := range users.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, apperror.NotFound("user not found")
+}
+
+// _testPassword is the password of every user of memoryUsers.
+const _testPassword = "password"
+
+func (users *memoryUsers) LoginUser(ctx context.Context, req *entity.UserLogin) (*entity.UserLoginResponse, error) {
+	user, err := users.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if req.Password != _testPassword {
+		return nil, apperror.Unauthorized("wrong password")
+	}
+	return &entity.UserLoginResponse{User: *user}, nil
+}
+
+func (users *memoryUsers) VerifyEmail(ctx context.Context, id int64) (string, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	user, ok := users.users[id]
+	if !ok {
+		return "", apperror.NotFound("user not found")
+	}
+	user.EmailVerified = true
+	return "email verified", nil
+}
+
+func (users *memoryUsers) ResetPassword(ctx context.Context, id int64, req *entity.UserResetPassword) (string, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	if users.failResets > 0 {
+		users.failResets--
+		return "", apperror.Upstream(apperror.CodeUpstreamUnavailable, "users service is unavailable", nil)
+	}
+	if _, ok := users.users[id]; !ok {
+		return "", apperror.NotFound("user not found")
+	}
+	users.resets++
+	return "password was reset", nil
+}
+
+func (users *memoryUsers) AddCourierRole(ctx context.Context, id int64) (string, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	user, ok := users.users[id]
+	if !ok {
+		return "", apperror.NotFound("user not found")
+	}
+	user.IsCourier = true
+	return "courier role added", nil
+}
+
+func (users *memoryUsers) AddAdminRole(ctx context.Context, id int64) (string, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	if users.failRoles > 0 {
+		users.failRoles--
+		return "", apperror.Upstream(apperror.CodeUpstreamUnavailable, "users service is unavailable", nil)
+	}
+	user, ok := users.users[id]
+	if !ok {
+		return "", apperror.NotFound("user not found")
+	}
+	user.IsAdmin = true
+	return "admin role added", nil
+}
+
+func (users *memoryUsers) RemoveAdminRole(ctx context.Context, id int64) (string, error) {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+
+	user, ok := users.users[id]
+	if !ok {
+		return "", apperror.NotFound("user not found")
+	}
+	user.IsAdmin = false
+	return "admin role removed", nil
+}
+
+// isAdmin reports whether the users service has id as an admin.
+func (users *memoryUsers) isAdmin(id int64) bool {
+	users.mu.Lock()
+	defer users.mu.Unlock()
+	return users.users[id].IsAdmin
+}
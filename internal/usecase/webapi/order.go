@@ -0,0 +1,54 @@
+package webapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/zura-t/go_delivery_system/config"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
+)
+
+type OrderWebAPI struct {
+	client httpclient.HttpClientI
+	config *config.Config
+}
+
+func NewOrderWebAPI(config *config.Config, client httpclient.HttpClientI) *OrderWebAPI {
+	return &OrderWebAPI{
+		client: client,
+		config: config,
+	}
+}
+
+func (webapi *OrderWebAPI) CreateOrder(ctx context.Context, req *entity.CreateOrder) (*entity.Order, error) {
+	url := fmt.Sprintf("%s/orders", webapi.config.OrdersServiceAddress)
+	return httpclient.Call[entity.CreateOrder, entity.Order](ctx, webapi.client, http.MethodPost, url, req)
+}
+
+func (webapi *OrderWebAPI) GetOrders(ctx context.Context, userId int64, limit int32, offset int32) ([]*entity.Order, error) {
+	query := url.Values{}
+	query.Add("user_id", strconv.Itoa(int(userId)))
+	query.Add("limit", strconv.Itoa(int(limit)))
+	query.Add("offset", strconv.Itoa(int(offset)))
+	url := fmt.Sprintf("%s/orders?%s", webapi.config.OrdersServiceAddress, query.Encode())
+
+	orders, err := httpclient.Call[any, []*entity.Order](ctx, webapi.client, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return *orders, nil
+}
+
+func (webapi *OrderWebAPI) GetOrder(ctx context.Context, id int64) (*entity.Order, error) {
+	url := fmt.Sprintf("%s/orders/%d", webapi.config.OrdersServiceAddress, id)
+	return httpclient.Call[any, entity.Order](ctx, webapi.client, http.MethodGet, url, nil)
+}
+
+func (webapi *OrderWebAPI) UpdateOrderStatus(ctx context.Context, id int64, req *entity.UpdateOrderStatus) (*entity.Order, error) {
+	url := fmt.Sprintf("%s/orders/%d/status", webapi.config.OrdersServiceAddress, id)
+	return httpclient.Call[entity.UpdateOrderStatus, entity.Order](ctx, webapi.client, http.MethodPatch, url, req)
+}
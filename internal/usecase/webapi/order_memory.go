@@ -0,0 +1,99 @@
+package webapi
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/internal/apperror"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+)
+
+// OrderMemoryAPI is an in-process stand-in for the orders service, used when
+// no orders service address is configured.
+type OrderMemoryAPI struct {
+	mu     sync.Mutex
+	orders map[int64]*entity.Order
+	nextID int64
+}
+
+func NewOrderMemoryAPI() *OrderMemoryAPI {
+	return &OrderMemoryAPI{
+		orders: make(map[int64]*entity.Order),
+	}
+}
+
+func (api *OrderMemoryAPI) CreateOrder(ctx context.Context, req *entity.CreateOrder) (*entity.Order, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.nextID++
+	now := time.Now()
+	order := &entity.Order{
+		ID:               api.nextID,
+		UserId:           req.UserId,
+		ShopId:           req.ShopId,
+		Items:            append([]entity.OrderItem(nil), req.Items...),
+		TotalPrice:       req.TotalPrice,
+		Status:           entity.OrderStatusPlaced,
+		DeliveryLocation: req.DeliveryLocation,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	api.orders[order.ID] = order
+
+	return copyOrder(order), nil
+}
+
+func (api *OrderMemoryAPI) GetOrders(ctx context.Context, userId int64, limit int32, offset int32) ([]*entity.Order, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	orders := make([]*entity.Order, 0)
+	for _, order := range api.orders {
+		if order.UserId == userId {
+			orders = append(orders, copyOrder(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+
+	if int(offset) >= len(orders) {
+		return []*entity.Order{}, nil
+	}
+	orders = orders[offset:]
+	if int(limit) < len(orders) {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (api *OrderMemoryAPI) GetOrder(ctx context.Context, id int64) (*entity.Order, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	order, ok := api.orders[id]
+	if !ok {
+		return nil, apperror.NotFound("order not found")
+	}
+	return copyOrder(order), nil
+}
+
+func (api *OrderMemoryAPI) UpdateOrderStatus(ctx context.Context, id int64, req *entity.UpdateOrderStatus) (*entity.Order, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	order, ok := api.orders[id]
+	if !ok {
+		return nil, apperror.NotFound("order not found")
+	}
+	order.Status = req.Status
+	order.UpdatedAt = time.Now()
+	return copyOrder(order), nil
+}
+
+func copyOrder(order *entity.Order) *entity.Order {
+	c := *order
+	c.Items = append([]entity.OrderItem(nil), order.Items...)
+	return &c
+}
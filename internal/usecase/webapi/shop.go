@@ -1,437 +1,111 @@
 package webapi
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/zura-t/go_delivery_system/config"
 	"github.com/zura-t/go_delivery_system/internal/entity"
 	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
-	"github.com/zura-t/go_delivery_system/pkg/httpserver"
 )
 
 type ShopWebAPI struct {
-	client *http.Client
+	client httpclient.HttpClientI
 	config *config.Config
 }
 
-func NewShopWebAPI(config *config.Config) *ShopWebAPI {
+func NewShopWebAPI(config *config.Config, client httpclient.HttpClientI) *ShopWebAPI {
 	return &ShopWebAPI{
-		client: &http.Client{},
+		client: client,
 		config: config,
 	}
 }
 
-func (webapi *ShopWebAPI) CreateShop(req *entity.CreateShop) (*entity.Shop, int, error) {
+func (webapi *ShopWebAPI) CreateShop(ctx context.Context, req *entity.CreateShop) (*entity.Shop, error) {
 	url := fmt.Sprintf("%s/shops", webapi.config.ShopsServiceAddress)
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPost, url)
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return &entity.Shop{}, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return &entity.Shop{}, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var shop *entity.Shop
-	newShop, err := io.ReadAll(res.Body)
-
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(newShop, &shop)
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-	return shop, http.StatusOK, nil
+	return httpclient.Call[entity.CreateShop, entity.Shop](ctx, webapi.client, http.MethodPost, url, req)
 }
 
-func (webapi *ShopWebAPI) GetShopInfo(id int64) (*entity.Shop, int, error) {
+func (webapi *ShopWebAPI) GetShopInfo(ctx context.Context, id int64) (*entity.Shop, error) {
 	url := fmt.Sprintf("%s/shops/%d", webapi.config.ShopsServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodGet, url)
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return &entity.Shop{}, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return &entity.Shop{}, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var shop *entity.Shop
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &shop)
-	if err != nil {
-		return &entity.Shop{}, http.StatusInternalServerError, err
-	}
-	return shop, http.StatusOK, nil
+	return httpclient.Call[any, entity.Shop](ctx, webapi.client, http.MethodGet, url, nil)
 }
 
-func (webapi *ShopWebAPI) GetShops(limit int32, offset int32) ([]*entity.Shop, int, error) {
-	url := fmt.Sprintf("%s/shops", webapi.config.ShopsServiceAddress)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodGet, url)
-	query := httpRequest.URL.Query()
+func (webapi *ShopWebAPI) GetShops(ctx context.Context, limit int32, offset int32) ([]*entity.Shop, error) {
+	query := url.Values{}
 	query.Add("limit", strconv.Itoa(int(limit)))
 	query.Add("offset", strconv.Itoa(int(offset)))
-	httpRequest.URL.RawQuery = query.Encode()
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
-	}
-	defer res.Body.Close()
+	url := fmt.Sprintf("%s/shops?%s", webapi.config.ShopsServiceAddress, query.Encode())
 
-	var shops []entity.Shop
-	resp, err := io.ReadAll(res.Body)
+	shops, err := httpclient.Call[any, []*entity.Shop](ctx, webapi.client, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return nil, err
 	}
-	err = json.Unmarshal(resp, &shops)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	response := make([]*entity.Shop, len(shops))
-	for i := 0; i < len(shops); i++ {
-		response[i] = &shops[i]
-	}
-	return response, http.StatusOK, nil
+	return *shops, nil
 }
 
-func (webapi *ShopWebAPI) GetShopsAdmin(user_id int64) ([]entity.Shop, int, error) {
-	url := fmt.Sprintf("%s/shops/admin", webapi.config.ShopsServiceAddress)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodGet, url)
-	query := httpRequest.URL.Query()
+func (webapi *ShopWebAPI) GetShopsAdmin(ctx context.Context, user_id int64) ([]entity.Shop, error) {
+	query := url.Values{}
 	query.Add("user_id", strconv.Itoa(int(user_id)))
-	httpRequest.URL.RawQuery = query.Encode()
+	url := fmt.Sprintf("%s/shops/admin?%s", webapi.config.ShopsServiceAddress, query.Encode())
 
+	shops, err := httpclient.Call[any, []entity.Shop](ctx, webapi.client, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
+		return nil, err
 	}
-	defer res.Body.Close()
-
-	var shops []entity.Shop
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &shops)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	return shops, http.StatusOK, nil
+	return *shops, nil
 }
 
-func (webapi *ShopWebAPI) UpdateShop(id int64, req *entity.UpdateShopInfo) (*entity.Shop, int, error) {
+func (webapi *ShopWebAPI) UpdateShop(ctx context.Context, id int64, req *entity.UpdateShopInfo) (*entity.Shop, error) {
 	url := fmt.Sprintf("%s/shops/%d", webapi.config.ShopsServiceAddress, id)
-
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPatch, url)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var shop *entity.Shop
-	newShop, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(newShop, &shop)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	return shop, http.StatusOK, nil
+	return httpclient.Call[entity.UpdateShopInfo, entity.Shop](ctx, webapi.client, http.MethodPatch, url, req)
 }
 
-func (webapi *ShopWebAPI) CreateMenu(req *entity.CreateMenuItem) ([]*entity.GetMenuItem, int, error) {
+func (webapi *ShopWebAPI) CreateMenu(ctx context.Context, req *entity.CreateMenuItem) ([]*entity.GetMenuItem, error) {
 	url := fmt.Sprintf("%s/menu_items", webapi.config.ShopsServiceAddress)
 
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPost, url)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var menuItems []entity.GetMenuItem
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &menuItems)
+	menuItems, err := httpclient.Call[entity.CreateMenuItem, []*entity.GetMenuItem](ctx, webapi.client, http.MethodPost, url, req)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return nil, err
 	}
-	response := make([]*entity.GetMenuItem, len(menuItems))
-	for i := 0; i < len(menuItems); i++ {
-		response[i] = &menuItems[i]
-	}
-
-	return response, http.StatusOK, nil
+	return *menuItems, nil
 }
 
-func (webapi *ShopWebAPI) GetMenu(shopId int64) ([]*entity.GetMenuItem, int, error) {
+func (webapi *ShopWebAPI) GetMenu(ctx context.Context, shopId int64) ([]*entity.GetMenuItem, error) {
 	url := fmt.Sprintf("%s/menu_items/list/%d", webapi.config.ShopsServiceAddress, shopId)
-	httpRequest, err := httpclient.NewHttpRequest(shopId, http.MethodGet, url)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
 
+	menuItems, err := httpclient.Call[any, []*entity.GetMenuItem](ctx, webapi.client, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
+		return nil, err
 	}
-	defer res.Body.Close()
-
-	var menuItems []entity.GetMenuItem
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &menuItems)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	response := make([]*entity.GetMenuItem, len(menuItems))
-	for i := 0; i < len(menuItems); i++ {
-		response[i] = &menuItems[i]
-	}
-	return response, http.StatusOK, nil
+	return *menuItems, nil
 }
 
-func (webapi *ShopWebAPI) UpdateMenuItem(id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, int, error) {
+func (webapi *ShopWebAPI) UpdateMenuItem(ctx context.Context, id int64, req *entity.UpdateMenuItem) (*entity.GetMenuItem, error) {
 	url := fmt.Sprintf("%s/menu_items/%d", webapi.config.ShopsServiceAddress, id)
-
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPatch, url)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var menuItem *entity.GetMenuItem
-	newMenuItem, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(newMenuItem, &menuItem)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	return menuItem, http.StatusOK, nil
+	return httpclient.Call[entity.UpdateMenuItem, entity.GetMenuItem](ctx, webapi.client, http.MethodPatch, url, req)
 }
 
-func (webapi *ShopWebAPI) GetMenuItem(id int64) (*entity.GetMenuItem, int, error) {
+func (webapi *ShopWebAPI) GetMenuItem(ctx context.Context, id int64) (*entity.GetMenuItem, error) {
 	url := fmt.Sprintf("%s/menu_items/%d", webapi.config.ShopsServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodGet, url)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return nil, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return nil, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var menuItem *entity.GetMenuItem
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &menuItem)
-	if err != nil {
-		return nil, http.StatusInternalServerError, err
-	}
-	return menuItem, http.StatusOK, nil
+	return httpclient.Call[any, entity.GetMenuItem](ctx, webapi.client, http.MethodGet, url, nil)
 }
 
-func (webapi *ShopWebAPI) DeleteShop(id int64, user_id int64) (string, int, error) {
-	url := fmt.Sprintf("%s/shops/%d", webapi.config.ShopsServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodDelete, url)
-	query := httpRequest.URL.Query()
+func (webapi *ShopWebAPI) DeleteShop(ctx context.Context, id int64, user_id int64) (string, error) {
+	query := url.Values{}
 	query.Add("user_id", strconv.Itoa(int(user_id)))
-	httpRequest.URL.RawQuery = query.Encode()
+	url := fmt.Sprintf("%s/shops/%d?%s", webapi.config.ShopsServiceAddress, id, query.Encode())
 
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return "", http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return "", res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var resp string
-	resData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resData, &resp)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	return resp, http.StatusOK, nil
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodDelete, url, nil))
 }
 
-func (webapi *ShopWebAPI) DeleteMenuItem(id int64, user_id int64) (string, int, error) {
-	url := fmt.Sprintf("%s/menu_items/%d", webapi.config.ShopsServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodDelete, url)
-
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-
-	query := httpRequest.URL.Query()
+func (webapi *ShopWebAPI) DeleteMenuItem(ctx context.Context, id int64, user_id int64) (string, error) {
+	query := url.Values{}
 	query.Add("user_id", strconv.Itoa(int(user_id)))
-	httpRequest.URL.RawQuery = query.Encode()
-
-	res, err := webapi.client.Do(httpRequest)
+	url := fmt.Sprintf("%s/menu_items/%d?%s", webapi.config.ShopsServiceAddress, id, query.Encode())
 
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return "", http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return "", res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var resp string
-	response, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(response, &resp)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	return resp, http.StatusOK, nil
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodDelete, url, nil))
 }
@@ -1,267 +1,95 @@
 package webapi
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/zura-t/go_delivery_system/config"
 	"github.com/zura-t/go_delivery_system/internal/entity"
 	"github.com/zura-t/go_delivery_system/internal/usecase/httpclient"
-	"github.com/zura-t/go_delivery_system/pkg/httpserver"
 )
 
 type UserWebAPI struct {
-	client *http.Client
+	client httpclient.HttpClientI
 	config *config.Config
 }
 
-func NewUserWebAPI(config *config.Config) *UserWebAPI {
+func NewUserWebAPI(config *config.Config, client httpclient.HttpClientI) *UserWebAPI {
 	return &UserWebAPI{
-		client: &http.Client{},
+		client: client,
 		config: config,
 	}
 }
 
-func (webapi *UserWebAPI) CreateUser(req *entity.UserRegister) (*entity.User, int, error) {
+func (webapi *UserWebAPI) CreateUser(ctx context.Context, req *entity.UserRegister) (*entity.User, error) {
 	url := fmt.Sprintf("%s/users", webapi.config.UsersServiceAddress)
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPost, url)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return &entity.User{}, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return &entity.User{}, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var user entity.User
-	newUser, err := io.ReadAll(res.Body)
-
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(newUser, &user)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	return &user, http.StatusOK, nil
+	return httpclient.Call[entity.UserRegister, entity.User](ctx, webapi.client, http.MethodPost, url, req)
 }
 
-func (webapi *UserWebAPI) LoginUser(req *entity.UserLogin) (*entity.UserLoginResponse, int, error) {
+func (webapi *UserWebAPI) LoginUser(ctx context.Context, req *entity.UserLogin) (*entity.UserLoginResponse, error) {
 	url := fmt.Sprintf("%s/login", webapi.config.UsersServiceAddress)
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPost, url)
-	if err != nil {
-		return &entity.UserLoginResponse{}, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return &entity.UserLoginResponse{}, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return &entity.UserLoginResponse{}, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return &entity.UserLoginResponse{}, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var user entity.UserLoginResponse
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return &entity.UserLoginResponse{}, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &user)
-	if err != nil {
-		return &entity.UserLoginResponse{}, http.StatusInternalServerError, err
-	}
-	return &user, http.StatusOK, nil
+	return httpclient.Call[entity.UserLogin, entity.UserLoginResponse](ctx, webapi.client, http.MethodPost, url, req)
 }
 
-func (webapi *UserWebAPI) GetMyProfile(id int64) (*entity.User, int, error) {
+func (webapi *UserWebAPI) GetMyProfile(ctx context.Context, id int64) (*entity.User, error) {
 	url := fmt.Sprintf("%s/users/my_profile/%d", webapi.config.UsersServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodGet, url)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
+	return httpclient.Call[any, entity.User](ctx, webapi.client, http.MethodGet, url, nil)
+}
 
-	res, err := webapi.client.Do(httpRequest)
+func (webapi *UserWebAPI) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	query := url.Values{}
+	query.Add("email", email)
+	url := fmt.Sprintf("%s/users/email?%s", webapi.config.UsersServiceAddress, query.Encode())
+	return httpclient.Call[any, entity.User](ctx, webapi.client, http.MethodGet, url, nil)
+}
 
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return &entity.User{}, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return &entity.User{}, res.StatusCode, err
-	}
-	defer res.Body.Close()
+func (webapi *UserWebAPI) ResetPassword(ctx context.Context, id int64, req *entity.UserResetPassword) (string, error) {
+	url := fmt.Sprintf("%s/users/password/%d", webapi.config.UsersServiceAddress, id)
+	return message(httpclient.Call[entity.UserResetPassword, string](ctx, webapi.client, http.MethodPatch, url, req))
+}
 
-	var user entity.User
-	resp, err := io.ReadAll(res.Body)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resp, &user)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	return &user, http.StatusOK, nil
+func (webapi *UserWebAPI) VerifyEmail(ctx context.Context, id int64) (string, error) {
+	url := fmt.Sprintf("%s/users/verify_email/%d", webapi.config.UsersServiceAddress, id)
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodPatch, url, nil))
 }
 
-func (webapi *UserWebAPI) AddAdminRole(id int64) (string, int, error) {
+func (webapi *UserWebAPI) AddAdminRole(ctx context.Context, id int64) (string, error) {
 	url := fmt.Sprintf("%s/users/admin/%d", webapi.config.UsersServiceAddress, id)
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodPatch, url, nil))
+}
 
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodPatch, url)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return "", http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return "", res.StatusCode, err
-	}
-	defer res.Body.Close()
+func (webapi *UserWebAPI) RemoveAdminRole(ctx context.Context, id int64) (string, error) {
+	url := fmt.Sprintf("%s/users/admin/%d", webapi.config.UsersServiceAddress, id)
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodDelete, url, nil))
+}
 
-	var resp string
-	resData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resData, &resp)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	return resp, http.StatusOK, nil
+func (webapi *UserWebAPI) AddCourierRole(ctx context.Context, id int64) (string, error) {
+	url := fmt.Sprintf("%s/users/courier/%d", webapi.config.UsersServiceAddress, id)
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodPatch, url, nil))
 }
 
-func (webapi *UserWebAPI) UpdateUser(id int64, req *entity.UserUpdate) (*entity.User, int, error) {
+func (webapi *UserWebAPI) UpdateUser(ctx context.Context, id int64, req *entity.UserUpdate) (*entity.User, error) {
 	url := fmt.Sprintf("%s/users/%d", webapi.config.UsersServiceAddress, id)
-
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPatch, url)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return &entity.User{}, http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return &entity.User{}, res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var user entity.User
-	newUser, err := io.ReadAll(res.Body)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(newUser, &user)
-	if err != nil {
-		return &entity.User{}, http.StatusInternalServerError, err
-	}
-	return &user, http.StatusOK, nil
+	return httpclient.Call[entity.UserUpdate, entity.User](ctx, webapi.client, http.MethodPatch, url, req)
 }
 
-func (webapi *UserWebAPI) AddPhone(id int64, req *entity.UserAddPhone) (string, int, error) {
+func (webapi *UserWebAPI) AddPhone(ctx context.Context, id int64, req *entity.UserAddPhone) (string, error) {
 	url := fmt.Sprintf("%s/users/phone_number/%d", webapi.config.UsersServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(req, http.MethodPatch, url)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return "", http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return "", res.StatusCode, err
-	}
-	defer res.Body.Close()
-
-	var resp string
-	resData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resData, &resp)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	return resp, http.StatusOK, nil
+	return message(httpclient.Call[entity.UserAddPhone, string](ctx, webapi.client, http.MethodPatch, url, req))
 }
 
-func (webapi *UserWebAPI) DeleteUser(id int64) (string, int, error) {
+func (webapi *UserWebAPI) DeleteUser(ctx context.Context, id int64) (string, error) {
 	url := fmt.Sprintf("%s/users/%d", webapi.config.UsersServiceAddress, id)
-	httpRequest, err := httpclient.NewHttpRequest(nil, http.MethodDelete, url)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-
-	res, err := webapi.client.Do(httpRequest)
-
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	if res.StatusCode != 200 {
-		errorMessage, err := httpserver.HttpErrorResponse(res.Body)
-		if err != nil {
-			return "", http.StatusInternalServerError, err
-		}
-		err = fmt.Errorf("Error: %s", errorMessage)
-		return "", res.StatusCode, err
-	}
-	defer res.Body.Close()
+	return message(httpclient.Call[any, string](ctx, webapi.client, http.MethodDelete, url, nil))
+}
 
-	var resp string
-	resData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", http.StatusInternalServerError, err
-	}
-	err = json.Unmarshal(resData, &resp)
+// message unwraps the plain string responses the downstream services send for
+// commands.
+func message(resp *string, err error) (string, error) {
 	if err != nil {
-		return "", http.StatusInternalServerError, err
+		return "", err
 	}
-	return resp, http.StatusOK, nil
+	return *resp, nil
 }
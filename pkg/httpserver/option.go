@@ -29,4 +29,12 @@ func ShutdownTimeout(timeout time.Duration) Option {
 	return func(s *HttpServer) {
 		s.shutdownTimeout = timeout
 	}
-}
\ No newline at end of file
+}
+
+// OnShutdown registers f to run when shutdown starts, e.g. to end long-lived
+// streams that would otherwise keep the server from draining.
+func OnShutdown(f func()) Option {
+	return func(s *HttpServer) {
+		s.server.RegisterOnShutdown(f)
+	}
+}
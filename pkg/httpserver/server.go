@@ -45,7 +45,10 @@ func New(handler http.Handler, opts ...Option) *HttpServer {
 
 func (s *HttpServer) start() {
 	go func() {
-		s.notify <- s.server.ListenAndServe()
+		err := s.server.ListenAndServe()
+		if err != http.ErrServerClosed {
+			s.notify <- err
+		}
 		close(s.notify)
 	}()
 }
@@ -58,5 +61,14 @@ func (s *HttpServer) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
+	return s.ShutdownContext(ctx)
+}
+
+// ShutdownContext stops accepting new connections and waits for in-flight
+// requests to finish until ctx is done or the shutdown timeout elapses.
+func (s *HttpServer) ShutdownContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
 	return s.server.Shutdown(ctx)
-}
\ No newline at end of file
+}
@@ -0,0 +1,201 @@
+// Package hub fans events out to in-process subscribers grouped by topic and
+// keeps the latest events of every topic so reconnecting subscribers can
+// catch up.
+package hub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultBufferSize      = 64
+	_defaultSubscriberQueue = 16
+	_defaultRetention       = time.Hour
+	_pruneInterval          = time.Minute
+)
+
+// Event IDs have the form "<epoch>-<seq>". The epoch changes when the process
+// restarts, which tells Subscribe that an ID comes from a previous run.
+type Event struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+type Subscription struct {
+	C     <-chan Event
+	c     chan Event
+	topic string
+}
+
+type topic struct {
+	seq         uint64
+	buffer      []Event
+	subscribers map[*Subscription]struct{}
+	lastActive  time.Time
+}
+
+type Hub struct {
+	mu         sync.Mutex
+	epoch      string
+	bufferSize int
+	topics     map[string]*topic
+	lastPrune  time.Time
+	closed     bool
+}
+
+// New creates a hub that keeps up to bufferSize events per topic.
+func New(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = _defaultBufferSize
+	}
+	return &Hub{
+		epoch:      strconv.FormatInt(time.Now().UnixNano(), 36),
+		bufferSize: bufferSize,
+		topics:     make(map[string]*topic),
+		lastPrune:  time.Now(),
+	}
+}
+
+// Publish stores the event in the topic's buffer and hands it to every
+// subscriber. Subscribers that can't keep up are dropped; they are expected to
+// reconnect and replay what they missed.
+func (h *Hub) Publish(topicName string, eventType string, data []byte) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(topicName)
+	t.seq++
+	event := Event{
+		ID:   fmt.Sprintf("%s-%d", h.epoch, t.seq),
+		Type: eventType,
+		Data: data,
+	}
+
+	t.buffer = append(t.buffer, event)
+	if len(t.buffer) > h.bufferSize {
+		t.buffer = t.buffer[len(t.buffer)-h.bufferSize:]
+	}
+	t.lastActive = time.Now()
+
+	for sub := range t.subscribers {
+		select {
+		case sub.c <- event:
+		default:
+			delete(t.subscribers, sub)
+			close(sub.c)
+		}
+	}
+
+	h.prune()
+	return event
+}
+
+// Subscribe follows a topic. Buffered events published after lastEventID are
+// returned for replay; an empty or unknown lastEventID replays nothing, and an
+// ID from a previous run replays the whole buffer.
+func (h *Hub) Subscribe(topicName string, lastEventID string) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan Event, _defaultSubscriberQueue)
+	sub := &Subscription{C: c, c: c, topic: topicName}
+	if h.closed {
+		close(c)
+		return sub, nil
+	}
+
+	t := h.topic(topicName)
+	t.subscribers[sub] = struct{}{}
+	t.lastActive = time.Now()
+
+	return sub, h.replay(t, lastEventID)
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t, ok := h.topics[sub.topic]
+	if !ok {
+		return
+	}
+	if _, ok := t.subscribers[sub]; ok {
+		delete(t.subscribers, sub)
+		close(sub.c)
+	}
+	t.lastActive = time.Now()
+}
+
+// Close ends every subscription, so streaming handlers return and the http
+// server can drain.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, t := range h.topics {
+		for sub := range t.subscribers {
+			delete(t.subscribers, sub)
+			close(sub.c)
+		}
+	}
+}
+
+func (h *Hub) topic(name string) *topic {
+	t, ok := h.topics[name]
+	if !ok {
+		t = &topic{subscribers: make(map[*Subscription]struct{})}
+		h.topics[name] = t
+	}
+	return t
+}
+
+func (h *Hub) replay(t *topic, lastEventID string) []Event {
+	if lastEventID == "" {
+		return nil
+	}
+
+	epoch, seqText, found := strings.Cut(lastEventID, "-")
+	if !found {
+		return nil
+	}
+	if epoch != h.epoch {
+		return append([]Event(nil), t.buffer...)
+	}
+
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	if seq >= t.seq {
+		return nil
+	}
+	// The buffer holds the events numbered t.seq-len+1 .. t.seq.
+	first := t.seq - uint64(len(t.buffer)) + 1
+	if seq+1 < first {
+		return append([]Event(nil), t.buffer...)
+	}
+	return append([]Event(nil), t.buffer[seq+1-first:]...)
+}
+
+// prune forgets topics that nobody follows and nothing was published to for
+// a while.
+func (h *Hub) prune() {
+	now := time.Now()
+	if now.Sub(h.lastPrune) < _pruneInterval {
+		return
+	}
+	h.lastPrune = now
+
+	for name, t := range h.topics {
+		if len(t.subscribers) == 0 && now.Sub(t.lastActive) > _defaultRetention {
+			delete(h.topics, name)
+		}
+	}
+}
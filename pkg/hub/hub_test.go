@@ -0,0 +1,135 @@
+package hub_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/pkg/hub"
+)
+
+// publish sends n events to topic and returns their IDs.
+func publish(h *hub.Hub, topic string, n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = h.Publish(topic, "order.status", []byte(fmt.Sprint(i))).ID
+	}
+	return ids
+}
+
+func eventIDs(events []hub.Event) []string {
+	ids := []string{}
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	h := hub.New(4)
+	defer h.Close()
+
+	// Events 1..6 were published, the buffer holds 3..6.
+	ids := publish(h, "order:1", 6)
+	publish(h, "order:2", 2)
+	epoch, _, _ := strings.Cut(ids[0], "-")
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		want        []string
+	}{
+		{"no id", "", []string{}},
+		{"same epoch", ids[3], ids[4:]},
+		{"oldest buffered", ids[2], ids[3:]},
+		{"just before the buffer", ids[1], ids[2:]},
+		{"latest", ids[5], []string{}},
+		{"previous epoch", "0-5", ids[2:]},
+		{"previous epoch with a future seq", "0-99", ids[2:]},
+		{"older than the buffer", ids[0], ids[2:]},
+		{"future seq", epoch + "-7", []string{}},
+		{"far future seq", epoch + "-18446744073709551615", []string{}},
+		{"no dash", "garbage", []string{}},
+		{"malformed seq", epoch + "-x", []string{}},
+		{"negative seq", epoch + "--1", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay := h.Subscribe("order:1", tt.lastEventID)
+			defer h.Unsubscribe(sub)
+
+			if got := eventIDs(replay); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Subscribe(%q) replayed %v, want %v", tt.lastEventID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscribeReplayOfANewTopic(t *testing.T) {
+	h := hub.New(4)
+	defer h.Close()
+
+	sub, replay := h.Subscribe("order:1", "0-3")
+	defer h.Unsubscribe(sub)
+	if len(replay) != 0 {
+		t.Errorf("Subscribe to a topic without events replayed %v", eventIDs(replay))
+	}
+}
+
+func TestPublishDropsSlowSubscribers(t *testing.T) {
+	h := hub.New(64)
+	defer h.Close()
+
+	slow, _ := h.Subscribe("order:1", "")
+	fast, _ := h.Subscribe("order:1", "")
+
+	var ids []string
+	for i := 0; i < 20; i++ {
+		ids = append(ids, publish(h, "order:1", 1)...)
+		if event := <-fast.C; event.ID != ids[i] {
+			t.Fatalf("fast subscriber got %s, want %s", event.ID, ids[i])
+		}
+	}
+
+	// The slow subscriber got what fit in its queue, then its channel was
+	// closed.
+	var received []string
+	for event := range slow.C {
+		received = append(received, event.ID)
+	}
+	if len(received) == 0 || !reflect.DeepEqual(received, ids[:len(received)]) || len(received) == len(ids) {
+		t.Fatalf("slow subscriber got %v before being dropped", received)
+	}
+
+	// It catches up on what it missed by reconnecting.
+	again, replay := h.Subscribe("order:1", received[len(received)-1])
+	defer h.Unsubscribe(again)
+	if got := eventIDs(replay); !reflect.DeepEqual(got, ids[len(received):]) {
+		t.Errorf("reconnecting replayed %v, want %v", got, ids[len(received):])
+	}
+
+	// Dropping a subscriber twice is harmless.
+	h.Unsubscribe(slow)
+}
+
+func TestUnsubscribeAndClose(t *testing.T) {
+	h := hub.New(4)
+
+	sub, _ := h.Subscribe("order:1", "")
+	other, _ := h.Subscribe("order:2", "")
+	h.Unsubscribe(sub)
+	if _, ok := <-sub.C; ok {
+		t.Error("unsubscribed channel is still open")
+	}
+	publish(h, "order:1", 1)
+
+	h.Close()
+	if _, ok := <-other.C; ok {
+		t.Error("Close left a subscription open")
+	}
+	late, _ := h.Subscribe("order:1", "")
+	if _, ok := <-late.C; ok {
+		t.Error("subscribing to a closed hub left the subscription open")
+	}
+}
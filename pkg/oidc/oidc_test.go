@@ -0,0 +1,272 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zura-t/go_delivery_system/pkg/oidc"
+	"github.com/zura-t/go_delivery_system/pkg/oidc/oidctest"
+)
+
+const (
+	clientID     = "gateway"
+	clientSecret = "secret"
+	redirectURL  = "https://gateway.example.com/v1/oauth/test/callback"
+)
+
+var identity = oidctest.Identity{
+	Subject:       "user-1",
+	Email:         "user@example.com",
+	EmailVerified: true,
+	Name:          "User",
+}
+
+func newProvider(t *testing.T, config oidc.Config) (*oidc.Provider, *oidctest.Server) {
+	t.Helper()
+
+	server, err := oidctest.NewServer(clientID, clientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	server.SignIn(identity)
+
+	if config.Issuer == "" {
+		config.Issuer = server.URL
+	}
+	if config.ClientID == "" {
+		config.ClientID = clientID
+	}
+	if config.ClientSecret == "" {
+		config.ClientSecret = clientSecret
+	}
+	config.RedirectURL = redirectURL
+	return oidc.NewProvider(config, http.DefaultClient), server
+}
+
+// authorize starts a login and returns the code the provider redirects back
+// with.
+func authorize(t *testing.T, provider *oidc.Provider, server *oidctest.Server, state string, nonce string, codeVerifier string) string {
+	t.Helper()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, returnedState, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if returnedState != state {
+		t.Fatalf("provider returned state %q, want %q", returnedState, state)
+	}
+	return code
+}
+
+func TestProviderLogin(t *testing.T) {
+	provider, server := newProvider(t, oidc.Config{Scopes: []string{"email", "profile"}})
+	ctx := context.Background()
+
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             clientID,
+		"redirect_uri":          redirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        oidc.CodeChallenge(codeVerifier),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if query.Get(name) != value {
+			t.Errorf("auth URL %s is %q, want %q", name, query.Get(name), value)
+		}
+	}
+	if query.Get("code_challenge") == codeVerifier {
+		t.Error("auth URL carries the code verifier instead of its challenge")
+	}
+
+	code, _, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := provider.Exchange(ctx, code, codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idToken.Issuer != server.URL || idToken.Subject != identity.Subject || idToken.Nonce != "nonce" ||
+		idToken.Email != identity.Email || !idToken.EmailVerified || idToken.Name != identity.Name {
+		t.Fatalf("Exchange returned %+v, want the claims of %+v", idToken, identity)
+	}
+}
+
+func TestExchangeRefused(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		config   oidc.Config
+		exchange func(provider *oidc.Provider, code string, codeVerifier string) error
+		wantCode string
+	}{
+		{
+			name: "wrong code verifier",
+			exchange: func(provider *oidc.Provider, code string, codeVerifier string) error {
+				other, err := oidc.NewCodeVerifier()
+				if err != nil {
+					return err
+				}
+				_, err = provider.Exchange(ctx, code, other)
+				return err
+			},
+			wantCode: "invalid_grant",
+		},
+		{
+			name: "code used twice",
+			exchange: func(provider *oidc.Provider, code string, codeVerifier string) error {
+				_, err := provider.Exchange(ctx, code, codeVerifier)
+				if err != nil {
+					return err
+				}
+				_, err = provider.Exchange(ctx, code, codeVerifier)
+				return err
+			},
+			wantCode: "invalid_grant",
+		},
+		{
+			name:   "wrong client secret",
+			config: oidc.Config{ClientSecret: "guess"},
+			exchange: func(provider *oidc.Provider, code string, codeVerifier string) error {
+				_, err := provider.Exchange(ctx, code, codeVerifier)
+				return err
+			},
+			wantCode: "invalid_client",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, server := newProvider(t, tc.config)
+			codeVerifier, err := oidc.NewCodeVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := authorize(t, provider, server, "state", "nonce", codeVerifier)
+
+			err = tc.exchange(provider, code, codeVerifier)
+			var refused *oidc.Error
+			if !errors.As(err, &refused) || refused.Code != tc.wantCode {
+				t.Fatalf("Exchange returned %v, want a %s provider error", err, tc.wantCode)
+			}
+		})
+	}
+}
+
+// TestVerifyIDTokenOfOtherIssuer checks a token signed by another provider
+// doesn't verify, though it is well formed and unexpired.
+func TestVerifyIDTokenOfOtherIssuer(t *testing.T) {
+	provider, _ := newProvider(t, oidc.Config{})
+	other, otherServer := newProvider(t, oidc.Config{})
+	ctx := context.Background()
+
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := authorize(t, other, otherServer, "state", "nonce", codeVerifier)
+
+	// Exchange by hand to get at the raw token of the other provider.
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	resp, err := http.PostForm(otherServer.URL+"/token", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = other.VerifyIDToken(ctx, tokens.IDToken)
+	if err != nil {
+		t.Fatalf("VerifyIDToken at its issuer: %v", err)
+	}
+	_, err = provider.VerifyIDToken(ctx, tokens.IDToken)
+	if !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("VerifyIDToken of another issuer's token: got %v, want %v", err, oidc.ErrInvalidIDToken)
+	}
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	server, err := oidctest.NewServer(clientID, clientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	// The issuer in the metadata lacks the trailing slash, so it doesn't
+	// match the configured one.
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:      server.URL + "/",
+		ClientID:    clientID,
+		RedirectURL: redirectURL,
+	}, http.DefaultClient)
+	_, err = provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if err == nil {
+		t.Fatal("AuthCodeURL succeeded against a provider with another issuer")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	a, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatal("NewCodeVerifier returned the same verifier twice")
+	}
+	// RFC 7636 wants 43 to 128 characters.
+	if len(a) < 43 || len(a) > 128 {
+		t.Fatalf("code verifier has %d characters", len(a))
+	}
+
+	challenge := oidc.CodeChallenge(a)
+	if challenge != oidc.CodeChallenge(a) || challenge == oidc.CodeChallenge(b) {
+		t.Fatal("CodeChallenge isn't a function of the verifier")
+	}
+	if len(challenge) != 43 {
+		t.Fatalf("S256 challenge has %d characters, want 43", len(challenge))
+	}
+}
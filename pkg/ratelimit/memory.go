@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const _pruneInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryLimiter keeps the buckets of this process.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastPrune: time.Now(),
+	}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	err := limit.validate()
+	if err != nil {
+		return Result{}, err
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		limiter.buckets[key] = b
+	}
+
+	var result Result
+	b.tokens, result = take(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+	b.full = now.Add(result.ResetAfter)
+
+	limiter.prune(now)
+	return result, nil
+}
+
+// prune forgets full buckets, which behave like missing ones.
+func (limiter *MemoryLimiter) prune(now time.Time) {
+	if now.Sub(limiter.lastPrune) < _pruneInterval {
+		return
+	}
+	limiter.lastPrune = now
+
+	for key, b := range limiter.buckets {
+		if now.After(b.full) {
+			delete(limiter.buckets, key)
+		}
+	}
+}
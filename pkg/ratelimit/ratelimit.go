@@ -0,0 +1,72 @@
+// Package ratelimit throttles keys, such as client IPs, with token buckets.
+// A bucket holds up to Limit.Burst tokens and refills all of them over
+// Limit.Period; every allowed request takes one token.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrInvalidLimit is returned by limiters asked to apply a limit without a
+// positive Burst and a Period of a millisecond at least, which has no rate
+// to refill its bucket with.
+var ErrInvalidLimit = errors.New("ratelimit: burst must be positive and period at least a millisecond")
+
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+func (limit Limit) validate() error {
+	if limit.Burst <= 0 || limit.Period < time.Millisecond {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// Result describes the bucket after a request. RetryAfter is zero for allowed
+// requests; ResetAfter is how long the bucket needs to fill up again.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket holding tokens elapsed after its last update and
+// takes one token if there is one. It returns the tokens left and the result.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	rate := float64(limit.Burst) / float64(limit.Period)
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+float64(elapsed)*rate)
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, newResult(tokens, allowed, limit)
+}
+
+// newResult describes a bucket left with tokens.
+func newResult(tokens float64, allowed bool, limit Limit) Result {
+	rate := float64(limit.Burst) / float64(limit.Period)
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(tokens),
+		ResetAfter: time.Duration((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate)
+	}
+	return result
+}
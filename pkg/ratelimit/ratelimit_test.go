@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTake(t *testing.T) {
+	limit := Limit{Burst: 10, Period: 10 * time.Second}
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		wantTokens float64
+		want       Result
+	}{
+		{
+			name:       "full bucket",
+			tokens:     10,
+			wantTokens: 9,
+			want:       Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: time.Second},
+		},
+		{
+			name:       "empty bucket",
+			tokens:     0,
+			wantTokens: 0,
+			want:       Result{Limit: 10, RetryAfter: time.Second, ResetAfter: 10 * time.Second},
+		},
+		{
+			name:       "half a token",
+			tokens:     0.5,
+			wantTokens: 0.5,
+			want:       Result{Limit: 10, RetryAfter: 500 * time.Millisecond, ResetAfter: 9500 * time.Millisecond},
+		},
+		{
+			name:       "refilled",
+			tokens:     0,
+			elapsed:    3 * time.Second,
+			wantTokens: 2,
+			want:       Result{Allowed: true, Limit: 10, Remaining: 2, ResetAfter: 8 * time.Second},
+		},
+		{
+			name:       "refilled past the burst",
+			tokens:     5,
+			elapsed:    time.Hour,
+			wantTokens: 9,
+			want:       Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, result := take(tt.tokens, tt.elapsed, limit)
+			// The rate is a float; durations are off by nanoseconds.
+			result.RetryAfter = result.RetryAfter.Round(time.Millisecond)
+			result.ResetAfter = result.ResetAfter.Round(time.Millisecond)
+			if tokens != tt.wantTokens {
+				t.Errorf("take left %v tokens, want %v", tokens, tt.wantTokens)
+			}
+			if result != tt.want {
+				t.Errorf("take = %+v, want %+v", result, tt.want)
+			}
+		})
+	}
+}
+
+func newLimiters(t *testing.T) map[string]Limiter {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return map[string]Limiter{
+		"memory": NewMemoryLimiter(),
+		"redis":  NewRedisLimiter(client),
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	for name, limiter := range newLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limit := Limit{Burst: 3, Period: time.Hour}
+
+			for want := 2; want >= 0; want-- {
+				result, err := limiter.Allow(ctx, "ip:10.0.0.1", limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.Allowed || result.Remaining != want || result.Limit != 3 {
+					t.Fatalf("Allow = %+v, want allowed with %d remaining", result, want)
+				}
+			}
+
+			result, err := limiter.Allow(ctx, "ip:10.0.0.1", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed {
+				t.Fatal("Allow let a request past the burst through")
+			}
+			// A token comes back every 20 minutes.
+			if d := 20*time.Minute - result.RetryAfter; d < 0 || d > time.Second {
+				t.Errorf("RetryAfter = %v, want 20m", result.RetryAfter)
+			}
+			if d := time.Hour - result.ResetAfter; d < 0 || d > time.Second {
+				t.Errorf("ResetAfter = %v, want 1h", result.ResetAfter)
+			}
+
+			result, err = limiter.Allow(ctx, "ip:10.0.0.2", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Allowed {
+				t.Error("Allow refused a key with a full bucket")
+			}
+		})
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	for name, limiter := range newLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limit := Limit{Burst: 2, Period: 200 * time.Millisecond}
+
+			for i := 0; i < 3; i++ {
+				result, err := limiter.Allow(ctx, "refill", limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Allowed != (i < 2) {
+					t.Fatalf("request %d: Allow = %+v", i, result)
+				}
+			}
+
+			time.Sleep(150 * time.Millisecond)
+			result, err := limiter.Allow(ctx, "refill", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Allowed {
+				t.Errorf("Allow = %+v after a token was refilled, want allowed", result)
+			}
+		})
+	}
+}
+
+func TestLimiterConcurrent(t *testing.T) {
+	for name, limiter := range newLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			limit := Limit{Burst: 5, Period: time.Hour}
+
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			allowed := 0
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, err := limiter.Allow(context.Background(), "concurrent", limit)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if result.Allowed {
+						mu.Lock()
+						allowed++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if allowed != limit.Burst {
+				t.Errorf("%d concurrent requests were allowed, want %d", allowed, limit.Burst)
+			}
+		})
+	}
+}
+
+func TestLimiterInvalidLimit(t *testing.T) {
+	for name, limiter := range newLimiters(t) {
+		for _, limit := range []Limit{
+			{Burst: 0, Period: time.Minute},
+			{Burst: -1, Period: time.Minute},
+			{Burst: 1, Period: 0},
+			{Burst: 1, Period: time.Microsecond},
+		} {
+			t.Run(fmt.Sprintf("%s %+v", name, limit), func(t *testing.T) {
+				_, err := limiter.Allow(context.Background(), "invalid", limit)
+				if !errors.Is(err, ErrInvalidLimit) {
+					t.Errorf("Allow returned %v, want ErrInvalidLimit", err)
+				}
+			})
+		}
+	}
+}
+
+func TestRedisLimiterExpiresFullBuckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	limiter := NewRedisLimiter(client)
+
+	_, err := limiter.Allow(context.Background(), "ip:10.0.0.1", Limit{Burst: 4, Period: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One token was taken, which takes 15s to come back.
+	ttl := server.TTL("ratelimit:ip:10.0.0.1")
+	if ttl <= 14*time.Second || ttl > 16*time.Second {
+		t.Errorf("bucket expires in %v, want 15s", ttl)
+	}
+}
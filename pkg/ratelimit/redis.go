@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// _takeScript is take in Lua, so concurrent requests of instances sharing the
+// server can't both spend the last token. A bucket is a hash of its tokens and
+// the time of its last update in milliseconds.
+var _takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) * burst / period)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * period / burst) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter keeps buckets in any Redis-compatible server under
+// "ratelimit:<key>". Buckets expire once they would be full again.
+type RedisLimiter struct {
+	client redis.Cmdable
+}
+
+func NewRedisLimiter(client redis.Cmdable) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+func (limiter *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	err := limit.validate()
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, err := _takeScript.Run(ctx, limiter.client, []string{"ratelimit:" + key},
+		limit.Burst,
+		limit.Period.Milliseconds(),
+		time.Now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(tokens, allowed == 1, limit), nil
+}
@@ -1,24 +1,50 @@
 package rmq
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"errors"
+	"sync"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+const (
+	_defaultExchange    = "logs_topic"
+	_defaultConsumerTag = "api-gateway"
+)
+
+// Handler processes a single delivery. Deliveries are acknowledged after the
+// handler returns.
+type Handler func(d amqp.Delivery)
+
 type Consumer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	exchange string
+	tag      string
+
+	notify  chan error
+	closed  chan *amqp.Error
+	done    chan struct{}
+	stopped bool
+	mu      sync.Mutex
 }
 
-func NewConsumer(conn *amqp.Connection) (Consumer, error) {
-	consumer := Consumer{
-		conn: conn,
+func NewConsumer(conn *amqp.Connection, exchange string) (*Consumer, error) {
+	if exchange == "" {
+		exchange = _defaultExchange
+	}
+
+	consumer := &Consumer{
+		conn:     conn,
+		exchange: exchange,
+		tag:      _defaultConsumerTag,
+		notify:   make(chan error, 1),
+		done:     make(chan struct{}),
 	}
 	err := consumer.setup()
 	if err != nil {
-		return Consumer{}, err
+		return nil, err
 	}
 
 	return consumer, nil
@@ -29,8 +55,23 @@ func (consumer *Consumer) setup() error {
 	if err != nil {
 		return err
 	}
-	defer ch.Close()
+
+	err = ch.ExchangeDeclare(
+		consumer.exchange, // name
+		"topic",           // type
+		true,              // durable
+		false,             // auto-deleted
+		false,             // internal
+		false,             // no-wait
+		nil,               // arguments
+	)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+
 	consumer.channel = ch
+	consumer.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
 	return nil
 }
 
@@ -39,53 +80,88 @@ type Payload struct {
 	Data string `json:"data"`
 }
 
-func (consumer *Consumer) Listen(topics []string) error {
+// Listen binds a queue to the given topics and starts delivering messages to
+// handler in the background. Use Notify to learn about a broken connection and
+// Shutdown to drain the consumer.
+func (consumer *Consumer) Listen(topics []string, handler Handler) error {
 	q, err := consumer.channel.QueueDeclare(
-		"queue.", // name
-		false,         // durable
-		false,         // delete when unused
-		false,         // exclusive
-		false,         // no-wait
-		nil,           // arguments
+		"",    // name
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
 	)
-
 	if err != nil {
 		return err
 	}
 
 	for _, s := range topics {
-		consumer.channel.QueueBind(
+		err = consumer.channel.QueueBind(
 			q.Name,
 			s,
-			"logs_topic",
+			consumer.exchange,
 			false,
 			nil,
 		)
-
 		if err != nil {
 			return err
 		}
 	}
 
-	messages, err := consumer.channel.Consume(q.Name, "", true, false, false, false, nil)
+	messages, err := consumer.channel.Consume(q.Name, consumer.tag, false, false, false, false, nil)
 	if err != nil {
 		return err
 	}
 
-	forever := make(chan bool)
 	go func() {
+		defer close(consumer.done)
 		for d := range messages {
-			// var payload Payload
-			// _ = json.Unmarshal(d.Body, &payload)
-			log.Printf("Received a message: %s", d.Body)
-			log.Printf("Done")
+			handler(d)
+			_ = d.Ack(false)
+		}
+	}()
 
-			// go handlePayload(payload)
+	go func() {
+		amqpErr, ok := <-consumer.closed
+		consumer.mu.Lock()
+		stopped := consumer.stopped
+		consumer.mu.Unlock()
+		if ok && !stopped {
+			consumer.notify <- amqpErr
 		}
+		close(consumer.notify)
 	}()
 
-	fmt.Printf("Wait for message on [Exchange, Queue ] [logs_topic, %s]\n", q.Name)
-	<-forever
+	return nil
+}
+
+func (consumer *Consumer) Notify() <-chan error {
+	return consumer.notify
+}
+
+// Shutdown stops receiving new deliveries, waits for the ones already being
+// handled and closes the channel.
+func (consumer *Consumer) Shutdown(ctx context.Context) error {
+	consumer.mu.Lock()
+	consumer.stopped = true
+	consumer.mu.Unlock()
 
+	err := consumer.channel.Cancel(consumer.tag, false)
+	if err != nil && !errors.Is(err, amqp.ErrClosed) {
+		return err
+	}
+
+	select {
+	case <-consumer.done:
+	case <-ctx.Done():
+		consumer.channel.Close()
+		return ctx.Err()
+	}
+
+	err = consumer.channel.Close()
+	if err != nil && !errors.Is(err, amqp.ErrClosed) {
+		return err
+	}
 	return nil
 }
@@ -4,15 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// Emitter publishes JSON events to a topic exchange.
 type Emitter struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	exchange string
+	mu       sync.Mutex
 }
 
 func SendRPC[Q, S any](routingKey string, r Q) (rsp *S, err error) {
@@ -91,11 +95,71 @@ type CreateUserPayload struct {
 	Data CreateUserRequest
 }
 
-func NewEmitter(conn *amqp.Connection, channel *amqp.Channel) (Emitter, error) {
-	emitter := Emitter{
-		conn:    conn,
-		channel: channel,
+func NewEmitter(conn *amqp.Connection, exchange string) (*Emitter, error) {
+	if exchange == "" {
+		exchange = _defaultExchange
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	err = channel.ExchangeDeclare(
+		exchange, // name
+		"topic",  // type
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		channel.Close()
+		return nil, err
+	}
+
+	emitter := &Emitter{
+		conn:     conn,
+		channel:  channel,
+		exchange: exchange,
 	}
 
 	return emitter, nil
 }
+
+// Publish sends event as a persistent JSON message with the given routing key.
+func (emitter *Emitter) Publish(ctx context.Context, routingKey string, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	messageId, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+
+	return emitter.channel.PublishWithContext(
+		ctx,
+		emitter.exchange, // exchange
+		routingKey,       // routing key
+		false,            // mandatory
+		false,            // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageId.String(),
+			Timestamp:    time.Now(),
+			Type:         routingKey,
+			Body:         body,
+		},
+	)
+}
+
+func (emitter *Emitter) Close() error {
+	return emitter.channel.Close()
+}
@@ -0,0 +1,86 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/pkg/totp"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// rfcVectors are the SHA1 test vectors of RFC 6238, appendix B, cut to the
+// last six of their eight digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, vector := range rfcVectors {
+		code, err := totp.Code(rfcSecret, totp.Counter(time.Unix(vector.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != vector.code {
+			t.Errorf("Code at %d = %s, want %s", vector.unix, code, vector.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, vector := range rfcVectors {
+		at := time.Unix(vector.unix, 0)
+		counter, ok, err := totp.Validate(rfcSecret, vector.code, at, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || counter != totp.Counter(at) {
+			t.Errorf("Validate of %s at %d = %d, %v, want %d, true", vector.code, vector.unix, counter, ok, totp.Counter(at))
+		}
+	}
+
+	// 1111111109 and 1111111111 fall into neighbouring time steps.
+	previous, next := time.Unix(1111111109, 0), time.Unix(1111111111, 0)
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		skew int64
+		want bool
+	}{
+		{"previous step without skew", "081804", next, 0, false},
+		{"previous step with skew", "081804", next, 1, true},
+		{"next step with skew", "050471", previous, 1, true},
+		{"two steps away", "081804", next.Add(totp.Period), 1, false},
+		{"wrong code", "123456", next, 1, false},
+		{"wrong length", "05047", next, 1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, ok, err := totp.Validate(rfcSecret, test.code, test.at, test.skew)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != test.want {
+				t.Errorf("Validate = %v, want %v", ok, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidSecret(t *testing.T) {
+	_, _, err := totp.Validate("not base32!", "123456", time.Now(), 1)
+	if err == nil {
+		t.Fatal("Validate with an invalid secret returned no error")
+	}
+}
@@ -0,0 +1,101 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// AsymmetricMaker signs tokens with RS256 or EdDSA depending on the active
+// key and verifies them with any of its keys, picked by the kid header.
+// Services that only verify tokens fetch the public keys as a JWKS.
+type AsymmetricMaker struct {
+	active  *asymmetricKey
+	keys    map[string]*asymmetricKey
+	ordered []*asymmetricKey
+}
+
+type asymmetricKey struct {
+	Key
+	method jwt.SigningMethod
+}
+
+// NewAsymmetricMaker signs with the key activeID; an empty activeID picks the
+// first key with a private part.
+func NewAsymmetricMaker(keys []Key, activeID string) (Maker, error) {
+	maker := &AsymmetricMaker{keys: make(map[string]*asymmetricKey, len(keys))}
+	for _, key := range keys {
+		method, err := key.method()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := maker.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+
+		k := &asymmetricKey{Key: key, method: method}
+		maker.keys[key.ID] = k
+		maker.ordered = append(maker.ordered, k)
+
+		if maker.active == nil && key.Private != nil && (activeID == "" || activeID == key.ID) {
+			maker.active = k
+		}
+	}
+
+	if maker.active == nil {
+		if activeID != "" {
+			return nil, fmt.Errorf("no private key with id %s", activeID)
+		}
+		return nil, errors.New("no private key to sign tokens with")
+	}
+	return maker, nil
+}
+
+func (maker *AsymmetricMaker) CreateToken(claims Claims, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(claims, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.active.method, payload)
+	jwtToken.Header["kid"] = maker.active.ID
+	token, err := jwtToken.SignedString(maker.active.Private)
+	return token, payload, err
+}
+
+func (maker *AsymmetricMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := maker.keys[kid]
+		if !ok || token.Method.Alg() != key.method.Alg() {
+			return nil, ErrorInvalidToken
+		}
+		return key.Public, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrorExpiredToken) {
+			return nil, ErrorExpiredToken
+		}
+		return nil, ErrorInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrorInvalidToken
+	}
+
+	return payload, nil
+}
+
+func (maker *AsymmetricMaker) PublicKeys() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(maker.ordered))}
+	for _, key := range maker.ordered {
+		set.Keys = append(set.Keys, newJSONWebKey(key.ID, key.method.Alg(), key.Public))
+	}
+	return set
+}
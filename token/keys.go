@@ -0,0 +1,91 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Key is a signing key identified by the kid header of the tokens it signs.
+// Keys without a private part only verify tokens, which keeps tokens signed
+// by a retired key valid until they expire.
+type Key struct {
+	ID      string
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+func (key *Key) method() (jwt.SigningMethod, error) {
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("key %s: unsupported key type %T", key.ID, key.Public)
+}
+
+// LoadKeyFiles reads PEM encoded RSA or Ed25519 keys. Private keys may be
+// PKCS#1 or PKCS#8, public keys PKIX. The kid of a key is its file name
+// without the extension.
+func LoadKeyFiles(paths []string) ([]Key, error) {
+	keys := make([]Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		key.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func parseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Private: private, Public: &private.PublicKey}, nil
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch private := private.(type) {
+		case *rsa.PrivateKey:
+			return &Key{Private: private, Public: &private.PublicKey}, nil
+		case ed25519.PrivateKey:
+			return &Key{Private: private, Public: private.Public()}, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Public: public}, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
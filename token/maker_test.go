@@ -0,0 +1,185 @@
+package token_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zura-t/go_delivery_system/token"
+	"github.com/zura-t/go_delivery_system/token/makertest"
+)
+
+const (
+	symmetricKey      = "12345678912345678912345678912345"
+	otherSymmetricKey = "abcdefghijabcdefghijabcdefghijab"
+)
+
+func TestMakers(t *testing.T) {
+	rsaKey, otherRSAKey := newRSAKey(t, "rsa-1"), newRSAKey(t, "rsa-2")
+	edKey, otherEdKey := newEd25519Key(t, "ed-1"), newEd25519Key(t, "ed-2")
+
+	tests := []struct {
+		name  string
+		maker func() (token.Maker, error)
+		other func() (token.Maker, error)
+	}{
+		{
+			name:  "jwt",
+			maker: func() (token.Maker, error) { return token.NewJwtMaker(symmetricKey) },
+			other: func() (token.Maker, error) { return token.NewJwtMaker(otherSymmetricKey) },
+		},
+		{
+			name:  "asymmetric rsa",
+			maker: func() (token.Maker, error) { return token.NewAsymmetricMaker([]token.Key{rsaKey}, "") },
+			other: func() (token.Maker, error) { return token.NewAsymmetricMaker([]token.Key{otherRSAKey}, "") },
+		},
+		{
+			name:  "asymmetric ed25519",
+			maker: func() (token.Maker, error) { return token.NewAsymmetricMaker([]token.Key{edKey}, "") },
+			other: func() (token.Maker, error) { return token.NewAsymmetricMaker([]token.Key{otherEdKey}, "") },
+		},
+		{
+			// A foreign key that reuses a kid the maker knows must not verify.
+			name:  "asymmetric same kid",
+			maker: func() (token.Maker, error) { return token.NewAsymmetricMaker([]token.Key{rsaKey}, "") },
+			other: func() (token.Maker, error) {
+				key := otherRSAKey
+				key.ID = rsaKey.ID
+				return token.NewAsymmetricMaker([]token.Key{key}, "")
+			},
+		},
+		{
+			name:  "paseto local",
+			maker: func() (token.Maker, error) { return token.NewPasetoLocalMaker(symmetricKey) },
+			other: func() (token.Maker, error) { return token.NewPasetoLocalMaker(otherSymmetricKey) },
+		},
+		{
+			name:  "paseto public",
+			maker: func() (token.Maker, error) { return token.NewPasetoPublicMaker([]token.Key{edKey}, "") },
+			other: func() (token.Maker, error) { return token.NewPasetoPublicMaker([]token.Key{otherEdKey}, "") },
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := tc.maker()
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := tc.other()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = makertest.TestMaker(maker, other)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestAsymmetricMakerRotation rotates from an RSA key to an Ed25519 key and
+// keeps the RSA key without its private part, the way a retired key is
+// deployed until the tokens it signed expire.
+func TestAsymmetricMakerRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "2026-01")
+	newKey := newEd25519Key(t, "2026-07")
+
+	before, err := token.NewAsymmetricMaker([]token.Key{oldKey}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, oldPayload, err := before.CreateToken(token.Claims{UserId: 1, Type: token.TokenTypeAccess}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retired := token.Key{ID: oldKey.ID, Public: oldKey.Public}
+	after, err := token.NewAsymmetricMaker([]token.Key{retired, newKey}, newKey.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = makertest.TestMaker(after, before)
+	if err == nil {
+		t.Fatal("a token of the retired key passed as foreign, want it to verify")
+	}
+
+	payload, err := after.VerifyToken(oldToken)
+	if err != nil {
+		t.Fatalf("VerifyToken of a token signed by the retired key: %v", err)
+	}
+	if payload.ID != oldPayload.ID {
+		t.Fatalf("VerifyToken returned token %s, want %s", payload.ID, oldPayload.ID)
+	}
+
+	newToken, _, err := after.CreateToken(token.Claims{UserId: 1, Type: token.TokenTypeAccess}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = before.VerifyToken(newToken)
+	if !errors.Is(err, token.ErrorInvalidToken) {
+		t.Fatalf("VerifyToken of a token with an unknown kid: got %v, want %v", err, token.ErrorInvalidToken)
+	}
+
+	set := after.(*token.AsymmetricMaker).PublicKeys()
+	if len(set.Keys) != 2 || set.Keys[0].Kid != oldKey.ID || set.Keys[1].Kid != newKey.ID {
+		t.Fatalf("PublicKeys returned %+v, want the retired and the active key", set.Keys)
+	}
+
+	// Once the retired key is dropped its tokens stop verifying.
+	dropped, err := token.NewAsymmetricMaker([]token.Key{newKey}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dropped.VerifyToken(oldToken)
+	if !errors.Is(err, token.ErrorInvalidToken) {
+		t.Fatalf("VerifyToken of a token of a dropped key: got %v, want %v", err, token.ErrorInvalidToken)
+	}
+}
+
+func TestNewAsymmetricMaker(t *testing.T) {
+	key := newRSAKey(t, "a")
+	public := token.Key{ID: "b", Public: key.Public}
+
+	tests := []struct {
+		name     string
+		keys     []token.Key
+		activeID string
+	}{
+		{"no keys", nil, ""},
+		{"only public keys", []token.Key{public}, ""},
+		{"active key without private part", []token.Key{key, public}, public.ID},
+		{"unknown active key", []token.Key{key}, "c"},
+		{"duplicate kid", []token.Key{key, key}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := token.NewAsymmetricMaker(tc.keys, tc.activeID)
+			if err == nil {
+				t.Fatal("NewAsymmetricMaker succeeded, want an error")
+			}
+		})
+	}
+}
+
+func newRSAKey(t *testing.T, id string) token.Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Key{ID: id, Private: private, Public: &private.PublicKey}
+}
+
+func newEd25519Key(t *testing.T, id string) token.Key {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Key{ID: id, Private: private, Public: public}
+}
@@ -0,0 +1,101 @@
+// Package makertest checks token.Maker implementations against the behaviour
+// the rest of the gateway relies on, in the spirit of testing/fstest.
+package makertest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/internal/entity"
+	"github.com/zura-t/go_delivery_system/token"
+)
+
+// TestMaker creates and verifies tokens with maker and reports the first
+// deviation:
+//   - a token round-trips every Payload claim,
+//   - expired tokens fail with token.ErrorExpiredToken,
+//   - tampered, truncated and foreign tokens fail with token.ErrorInvalidToken.
+//
+// other must be a maker of the same kind with different keys; tokens it
+// issues must not verify with maker.
+func TestMaker(maker token.Maker, other token.Maker) error {
+	claims := token.Claims{
+		UserId:        42,
+		Email:         "courier@example.com",
+		EmailVerified: true,
+		IsAdmin:       true,
+		Roles: []entity.RoleGrant{
+			{Role: entity.RoleCustomer},
+			{Role: entity.RoleShopOwner, ShopId: 7},
+		},
+		Type:      token.TokenTypeRefresh,
+		SessionID: uuid.New(),
+	}
+
+	tok, issued, err := maker.CreateToken(claims, time.Minute)
+	if err != nil {
+		return fmt.Errorf("CreateToken: %w", err)
+	}
+	if tok == "" || issued == nil {
+		return errors.New("CreateToken returned an empty token or payload")
+	}
+
+	payload, err := maker.VerifyToken(tok)
+	if err != nil {
+		return fmt.Errorf("VerifyToken of a fresh token: %w", err)
+	}
+	if payload.ID != issued.ID || !reflect.DeepEqual(payload.Claims(), claims) {
+		return fmt.Errorf("VerifyToken returned %+v, want the claims of %+v", payload, issued)
+	}
+	if !payload.IssuedAt.Equal(issued.IssuedAt) || !payload.ExpiredAt.Equal(issued.ExpiredAt) {
+		return fmt.Errorf("VerifyToken changed the token times: got %v-%v, want %v-%v",
+			payload.IssuedAt, payload.ExpiredAt, issued.IssuedAt, issued.ExpiredAt)
+	}
+
+	expired, _, err := maker.CreateToken(claims, -time.Minute)
+	if err != nil {
+		return fmt.Errorf("CreateToken of an expired token: %w", err)
+	}
+	_, err = maker.VerifyToken(expired)
+	if !errors.Is(err, token.ErrorExpiredToken) {
+		return fmt.Errorf("VerifyToken of an expired token: got %v, want %v", err, token.ErrorExpiredToken)
+	}
+
+	invalid := map[string]string{
+		"empty":     "",
+		"garbage":   "not a token",
+		"truncated": tok[:len(tok)/2],
+		"tampered":  tamper(tok),
+	}
+	if other != nil {
+		foreign, _, err := other.CreateToken(claims, time.Minute)
+		if err != nil {
+			return fmt.Errorf("CreateToken with the other maker: %w", err)
+		}
+		invalid["foreign"] = foreign
+	}
+	for name, tok := range invalid {
+		_, err = maker.VerifyToken(tok)
+		if !errors.Is(err, token.ErrorInvalidToken) {
+			return fmt.Errorf("VerifyToken of a %s token: got %v, want %v", name, err, token.ErrorInvalidToken)
+		}
+	}
+
+	return nil
+}
+
+// tamper flips a character in the middle of the token body.
+func tamper(tok string) string {
+	i := len(tok) / 2
+	if tok[i] == '.' {
+		i++
+	}
+	c := "A"
+	if tok[i] == 'A' {
+		c = "B"
+	}
+	return tok[:i] + c + tok[i+1:]
+}
@@ -0,0 +1,266 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// PASETO v4 tokens, see https://github.com/paseto-standard/paseto-spec.
+// v4.local encrypts the payload with a shared 32 byte key; v4.public signs it
+// with Ed25519 and names the key in the footer, so keys rotate like JWT kids.
+const (
+	_pasetoLocalHeader  = "v4.local."
+	_pasetoPublicHeader = "v4.public."
+	_pasetoKeySize      = 32
+	_pasetoNonceSize    = 32
+	_pasetoMacSize      = 32
+)
+
+var _pasetoEncoding = base64.RawURLEncoding
+
+type pasetoFooter struct {
+	Kid string `json:"kid"`
+}
+
+type PasetoLocalMaker struct {
+	key []byte
+}
+
+func NewPasetoLocalMaker(symmetricKey string) (Maker, error) {
+	if len(symmetricKey) != _pasetoKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", _pasetoKeySize)
+	}
+	return &PasetoLocalMaker{key: []byte(symmetricKey)}, nil
+}
+
+func (maker *PasetoLocalMaker) CreateToken(claims Claims, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(claims, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	nonce := make([]byte, _pasetoNonceSize)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", payload, err
+	}
+
+	encKey, counterNonce, authKey := maker.splitKey(nonce)
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return "", payload, err
+	}
+	ciphertext := make([]byte, len(message))
+	cipher.XORKeyStream(ciphertext, message)
+
+	mac := pasetoMac(authKey, pae([]byte(_pasetoLocalHeader), nonce, ciphertext, nil, nil))
+
+	body := append(append(nonce, ciphertext...), mac...)
+	return _pasetoLocalHeader + _pasetoEncoding.EncodeToString(body), payload, nil
+}
+
+func (maker *PasetoLocalMaker) VerifyToken(token string) (*Payload, error) {
+	body, footer, err := splitPaseto(token, _pasetoLocalHeader)
+	if err != nil || len(footer) != 0 || len(body) < _pasetoNonceSize+_pasetoMacSize {
+		return nil, ErrorInvalidToken
+	}
+
+	nonce := body[:_pasetoNonceSize]
+	ciphertext := body[_pasetoNonceSize : len(body)-_pasetoMacSize]
+	mac := body[len(body)-_pasetoMacSize:]
+
+	encKey, counterNonce, authKey := maker.splitKey(nonce)
+	expected := pasetoMac(authKey, pae([]byte(_pasetoLocalHeader), nonce, ciphertext, nil, nil))
+	if subtle.ConstantTimeCompare(mac, expected) != 1 {
+		return nil, ErrorInvalidToken
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+	message := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(message, ciphertext)
+
+	return decodePasetoPayload(message)
+}
+
+// splitKey derives the encryption key, the XChaCha20 nonce and the
+// authentication key for one token.
+func (maker *PasetoLocalMaker) splitKey(nonce []byte) ([]byte, []byte, []byte) {
+	tmp := pasetoHash(maker.key, 56, []byte("paseto-encryption-key"), nonce)
+	authKey := pasetoHash(maker.key, 32, []byte("paseto-auth-key-for-aead"), nonce)
+	return tmp[:32], tmp[32:], authKey
+}
+
+type PasetoPublicMaker struct {
+	active *Key
+	keys   map[string]ed25519.PublicKey
+}
+
+// NewPasetoPublicMaker signs with the Ed25519 key activeID; an empty
+// activeID picks the first key with a private part.
+func NewPasetoPublicMaker(keys []Key, activeID string) (Maker, error) {
+	maker := &PasetoPublicMaker{keys: make(map[string]ed25519.PublicKey, len(keys))}
+	for i := range keys {
+		key := &keys[i]
+		public, ok := key.Public.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key %s: v4.public needs an Ed25519 key, got %T", key.ID, key.Public)
+		}
+		if _, ok := maker.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+		maker.keys[key.ID] = public
+
+		if maker.active == nil && key.Private != nil && (activeID == "" || activeID == key.ID) {
+			maker.active = key
+		}
+	}
+
+	if maker.active == nil {
+		if activeID != "" {
+			return nil, fmt.Errorf("no private key with id %s", activeID)
+		}
+		return nil, errors.New("no private key to sign tokens with")
+	}
+	return maker, nil
+}
+
+func (maker *PasetoPublicMaker) CreateToken(claims Claims, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(claims, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+	footer, err := json.Marshal(pasetoFooter{Kid: maker.active.ID})
+	if err != nil {
+		return "", payload, err
+	}
+
+	signature := ed25519.Sign(maker.active.Private.(ed25519.PrivateKey), pae([]byte(_pasetoPublicHeader), message, footer, nil))
+
+	token := _pasetoPublicHeader + _pasetoEncoding.EncodeToString(append(message, signature...)) +
+		"." + _pasetoEncoding.EncodeToString(footer)
+	return token, payload, nil
+}
+
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	body, footer, err := splitPaseto(token, _pasetoPublicHeader)
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, ErrorInvalidToken
+	}
+
+	var f pasetoFooter
+	err = json.Unmarshal(footer, &f)
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+	public, ok := maker.keys[f.Kid]
+	if !ok {
+		return nil, ErrorInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(public, pae([]byte(_pasetoPublicHeader), message, footer, nil), signature) {
+		return nil, ErrorInvalidToken
+	}
+
+	return decodePasetoPayload(message)
+}
+
+func splitPaseto(token string, header string) ([]byte, []byte, error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, ErrorInvalidToken
+	}
+
+	parts := strings.Split(token[len(header):], ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrorInvalidToken
+	}
+
+	body, err := _pasetoEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	var footer []byte
+	if len(parts) == 2 {
+		footer, err = _pasetoEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return body, footer, nil
+}
+
+func decodePasetoPayload(message []byte) (*Payload, error) {
+	var payload Payload
+	err := json.Unmarshal(message, &payload)
+	if err != nil {
+		return nil, ErrorInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// pae is the pre-authentication encoding of the spec: the number of pieces
+// and then every piece prefixed with its length, all as little-endian
+// 64-bit integers.
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+	le64 := func(n int) {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(n)&(1<<63-1))
+		buf.Write(b[:])
+	}
+
+	le64(len(pieces))
+	for _, piece := range pieces {
+		le64(len(piece))
+		buf.Write(piece)
+	}
+	return buf.Bytes()
+}
+
+func pasetoHash(key []byte, size int, parts ...[]byte) []byte {
+	h, err := blake2b.New(size, key)
+	if err != nil {
+		// Only reachable with a size or key length outside of what blake2b
+		// supports, which the constants above never produce.
+		panic(err)
+	}
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+func pasetoMac(key []byte, message []byte) []byte {
+	return pasetoHash(key, _pasetoMacSize, message)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zura-t/go_delivery_system/internal/entity"
 )
 
 var (
@@ -12,33 +13,108 @@ var (
 	ErrorExpiredToken = errors.New("token has expired")
 )
 
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeAdminInvite tokens let their user redeem the admin role.
+	TokenTypeAdminInvite TokenType = "admin_invite"
+	// TokenTypePasswordReset and TokenTypeEmailVerification tokens are mailed
+	// to the user's Email.
+	TokenTypePasswordReset     TokenType = "password_reset"
+	TokenTypeEmailVerification TokenType = "email_verification"
+	// TokenTypeMFAChallenge tokens stand for a password login that still
+	// needs a second factor.
+	TokenTypeMFAChallenge TokenType = "mfa_challenge"
+	// TokenTypeAPIKey marks payloads of requests authenticated with an API
+	// key rather than a token.
+	TokenTypeAPIKey TokenType = "api_key"
+)
+
+// Claims are the facts about a user a token is issued for. SessionID ties
+// together every token issued since the user logged in.
+type Claims struct {
+	UserId        int64
+	Email         string
+	EmailVerified bool
+	IsAdmin       bool
+	Roles         []entity.RoleGrant
+	Type          TokenType
+	SessionID     uuid.UUID
+}
+
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
+	Type      TokenType `json:"type"`
+	SessionID uuid.UUID `json:"session_id"`
 	UserId    int64     `json:"user_id"`
 	Email     string    `json:"email"`
-	IsAdmin   bool      `json:"is_admin"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expired_at"`
+	// EmailVerified gates actions such as ordering without asking the
+	// accounts service.
+	EmailVerified bool `json:"email_verified,omitempty"`
+	IsAdmin       bool `json:"is_admin"`
+	// Roles are checked by the gateway without asking the accounts service.
+	Roles []entity.RoleGrant `json:"roles,omitempty"`
+	// Scopes limit what an API key may do with its roles.
+	Scopes    []entity.Permission `json:"scopes,omitempty"`
+	IssuedAt  time.Time           `json:"issued_at"`
+	ExpiredAt time.Time           `json:"expired_at"`
 }
 
-func NewPayload(user_id int64, is_admin bool, email string, duration time.Duration) (*Payload, error) {
+func NewPayload(claims Claims, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
 	payload := &Payload{
-		ID:        tokenID,
-		UserId:    user_id,
-		Email:     email,
-		IsAdmin:   is_admin,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		ID:            tokenID,
+		Type:          claims.Type,
+		SessionID:     claims.SessionID,
+		UserId:        claims.UserId,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		IsAdmin:       claims.IsAdmin,
+		Roles:         claims.Roles,
+		IssuedAt:      time.Now(),
+		ExpiredAt:     time.Now().Add(duration),
 	}
 
 	return payload, nil
 }
 
+// Claims returns the claims the token was issued with.
+func (payload *Payload) Claims() Claims {
+	return Claims{
+		UserId:        payload.UserId,
+		Email:         payload.Email,
+		EmailVerified: payload.EmailVerified,
+		IsAdmin:       payload.IsAdmin,
+		Roles:         payload.Roles,
+		Type:          payload.Type,
+		SessionID:     payload.SessionID,
+	}
+}
+
+// HasPermission reports whether the token grants permission in the shop, or
+// in any shop for a zero shopId.
+func (payload *Payload) HasPermission(permission entity.Permission, shopId int64) bool {
+	if payload.Type == TokenTypeAPIKey && !hasScope(payload.Scopes, permission) {
+		return false
+	}
+	return entity.HasPermission(payload.Roles, permission, shopId)
+}
+
+func hasScope(scopes []entity.Permission, permission entity.Permission) bool {
+	for _, scope := range scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrorExpiredToken